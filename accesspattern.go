@@ -0,0 +1,173 @@
+package dynamodbfriend
+
+import (
+	"fmt"
+	"strings"
+)
+
+// KeyConditionOp is the operator of a sort key condition of a query.
+type KeyConditionOp string
+
+// Sort key condition operators of access patterns.
+const (
+	// KeyConditionNone is a query with no sort key condition.
+	KeyConditionNone             KeyConditionOp = "none"
+	KeyConditionEquals           KeyConditionOp = "="
+	KeyConditionLessThan         KeyConditionOp = "<"
+	KeyConditionGreaterThan      KeyConditionOp = ">"
+	KeyConditionLessThanEqual    KeyConditionOp = "<="
+	KeyConditionGreaterThanEqual KeyConditionOp = ">="
+	KeyConditionBetween          KeyConditionOp = "BETWEEN"
+	KeyConditionBeginsWith       KeyConditionOp = "begins_with"
+)
+
+// AccessPattern is an approved way of reading a table, declared in a catalog of access patterns.
+type AccessPattern struct {
+	Name      string
+	TableName string
+
+	// IndexName is the name of the global secondary index queried, or empty for the table's
+	// primary index.
+	IndexName string
+
+	// SortKeyConditions are the approved sort key conditions of queries. If empty, queries with
+	// any sort key condition, or none, are approved.
+	SortKeyConditions []KeyConditionOp
+
+	// Scan approves scans of the table. Queries are not approved by scan access patterns.
+	Scan bool
+}
+
+// AccessPatternEnforcement is the action taken on reads that match no declared access pattern.
+type AccessPatternEnforcement int
+
+const (
+	// AccessPatternsOff disables checking reads against access patterns. This is the default.
+	AccessPatternsOff AccessPatternEnforcement = iota
+	// AccessPatternsLog logs a warning for reads that match no access pattern.
+	AccessPatternsLog
+	// AccessPatternsReject refuses reads that match no access pattern with
+	// ErrAccessPatternViolation.
+	AccessPatternsReject
+)
+
+// ErrAccessPatternViolation is returned when a read matches no declared access pattern while access
+// patterns are enforced.
+type ErrAccessPatternViolation struct {
+	TableName string
+	Shape     string
+}
+
+func (e ErrAccessPatternViolation) Error() string {
+	return fmt.Sprintf("%s on table \"%s\" matches no declared access pattern", e.Shape,
+		e.TableName)
+}
+
+// accessPatternCatalog is a set of declared access patterns and their enforcement.
+type accessPatternCatalog struct {
+	enforcement AccessPatternEnforcement
+	patterns    []AccessPattern
+}
+
+// WithAccessPatterns declares the approved access patterns of tables subsequently instantiated
+// from this client, and the action taken on queries and scans that match no access pattern. Access
+// patterns catch unplanned reads, such as filter-heavy scans, before they reach production scale.
+func (client *Client) WithAccessPatterns(enforcement AccessPatternEnforcement,
+	patterns ...AccessPattern) *Client {
+
+	client.accessPatterns = &accessPatternCatalog{
+		enforcement: enforcement,
+		patterns:    patterns,
+	}
+	return client
+}
+
+// checkQueryPattern checks a query of the index against the declared access patterns. A nil
+// sort key condition matches patterns of any sort key condition, for queries whose key condition
+// is not known.
+func (table *Table) checkQueryPattern(indexName string, sortKeyCondition *KeyConditionOp) error {
+	catalog := table.accessPatterns
+	if catalog == nil || catalog.enforcement == AccessPatternsOff {
+		return nil
+	}
+	if indexName == tablePrimaryIndexName {
+		indexName = ""
+	}
+
+	for _, pattern := range catalog.patterns {
+		if pattern.Scan || pattern.TableName != table.Name || pattern.IndexName != indexName {
+			continue
+		}
+		if sortKeyCondition == nil || len(pattern.SortKeyConditions) == 0 {
+			return nil
+		}
+		for _, op := range pattern.SortKeyConditions {
+			if op == *sortKeyCondition {
+				return nil
+			}
+		}
+	}
+
+	shape := []string{"query"}
+	if indexName != "" {
+		shape = append(shape, fmt.Sprintf("of index \"%s\"", indexName))
+	}
+	if sortKeyCondition != nil {
+		shape = append(shape, fmt.Sprintf("with sort key condition %s", *sortKeyCondition))
+	}
+	return table.accessPatternViolation(strings.Join(shape, " "))
+}
+
+// checkScanPattern checks a scan of the table against the declared access patterns.
+func (table *Table) checkScanPattern() error {
+	catalog := table.accessPatterns
+	if catalog == nil || catalog.enforcement == AccessPatternsOff {
+		return nil
+	}
+
+	for _, pattern := range catalog.patterns {
+		if pattern.Scan && pattern.TableName == table.Name {
+			return nil
+		}
+	}
+	return table.accessPatternViolation("scan")
+}
+
+func (table *Table) accessPatternViolation(shape string) error {
+	err := ErrAccessPatternViolation{TableName: table.Name, Shape: shape}
+	if table.accessPatterns.enforcement == AccessPatternsLog {
+		table.logger.Printf("warning: %s\n", err.Error())
+		return nil
+	}
+	table.logger.Printf("error: %s\n", err.Error())
+	return err
+}
+
+// sortKeyConditionOf returns the sort key condition of a query expression on the index.
+func (expr *QueryExpr) sortKeyConditionOf(index *tableIndex) KeyConditionOp {
+	if !index.IsComposite {
+		return KeyConditionNone
+	}
+	filter, found := expr.filterOn(index.SortKey)
+	if !found {
+		return KeyConditionNone
+	}
+
+	switch filter.Op() {
+	case equalsOp:
+		return KeyConditionEquals
+	case lessThanOp:
+		return KeyConditionLessThan
+	case greaterThanOp:
+		return KeyConditionGreaterThan
+	case lessThanEqualOp:
+		return KeyConditionLessThanEqual
+	case greaterThanEqualOp:
+		return KeyConditionGreaterThanEqual
+	case betweenOp:
+		return KeyConditionBetween
+	case beginsWithOp:
+		return KeyConditionBeginsWith
+	}
+	return KeyConditionNone
+}
@@ -0,0 +1,84 @@
+package dynamodbfriend
+
+import (
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// WithAttributeAlias maps an attribute name used by the application, such as in "dynamodbav"
+// struct tags and query expressions, to the attribute name stored in the table. The alias is
+// applied on writes, reads, query conditions, and projections. Conditions added with
+// QueryExpr.WithFilter must use stored attribute names.
+func (table *Table) WithAttributeAlias(name, storedName string) *Table {
+	if table.attributeAliases == nil {
+		table.attributeAliases = map[string]string{}
+	}
+	table.attributeAliases[name] = storedName
+	return table
+}
+
+// WithAliasDualWrite sets whether aliased attributes are written under both the application name
+// and the stored name. This is useful while migrating a table from one attribute name to another.
+// On reads, the stored name takes precedence.
+func (table *Table) WithAliasDualWrite(dualWrite bool) *Table {
+	table.aliasDualWrite = dualWrite
+	return table
+}
+
+func (table *Table) storedName(name string) string {
+	if storedName, found := table.attributeAliases[name]; found {
+		return storedName
+	}
+	return name
+}
+
+func (table *Table) storedNames(names []string) []string {
+	storedNames := make([]string, len(names))
+	for i, name := range names {
+		storedNames[i] = table.storedName(name)
+	}
+	return storedNames
+}
+
+func (table *Table) applyAliases(item map[string]*dynamodb.AttributeValue) {
+	for name, storedName := range table.attributeAliases {
+		av, found := item[name]
+		if !found {
+			continue
+		}
+		item[storedName] = av
+		if !table.aliasDualWrite {
+			delete(item, name)
+		}
+	}
+}
+
+func (table *Table) removeAliases(item map[string]*dynamodb.AttributeValue) {
+	for name, storedName := range table.attributeAliases {
+		av, found := item[storedName]
+		if !found {
+			continue
+		}
+		delete(item, storedName)
+		item[name] = av
+	}
+}
+
+// aliasedExpr returns a copy of the query expression with attribute names replaced by their
+// stored names.
+func (table *Table) aliasedExpr(expr *QueryExpr) *QueryExpr {
+	if len(table.attributeAliases) == 0 {
+		return expr
+	}
+
+	aliased := *expr
+	aliased.filters = map[string][]queryFilter{}
+	for key, filters := range expr.filters {
+		storedKey := table.storedName(key)
+		aliased.filters[storedKey] = append(aliased.filters[storedKey], filters...)
+	}
+	aliased.filterGroups = renamedFilterGroups(expr.filterGroups, table.storedName)
+	aliased.attributes = table.storedNames(expr.attributes)
+	aliased.orderKey = table.storedName(expr.orderKey)
+
+	return &aliased
+}
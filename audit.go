@@ -0,0 +1,78 @@
+package dynamodbfriend
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// AuditOperation identifies the kind of write described by an AuditEvent.
+type AuditOperation string
+
+// Audit operations reported to an Auditor.
+const (
+	AuditOperationPut        AuditOperation = "Put"
+	AuditOperationSoftDelete AuditOperation = "SoftDelete"
+	AuditOperationMigrate    AuditOperation = "Migrate"
+	AuditOperationDelete     AuditOperation = "Delete"
+	AuditOperationUpdate     AuditOperation = "Update"
+)
+
+// AuditEvent describes a single write made to a table. OldImage and NewImage are set when
+// available for the operation. Err is set if the write failed.
+type AuditEvent struct {
+	Operation AuditOperation
+	TableName string
+	Key       map[string]*dynamodb.AttributeValue
+	OldImage  map[string]*dynamodb.AttributeValue
+	NewImage  map[string]*dynamodb.AttributeValue
+	Err       error
+
+	// Tags are the tags carried by the context of the write, set with WithTag.
+	Tags map[string]string
+}
+
+// Auditor is an interface for receiving audit events for all writes made through a table. The
+// context passed to Audit is the caller's context for the write.
+type Auditor interface {
+	Audit(ctx context.Context, event AuditEvent)
+}
+
+// WithAuditor sets the default auditor for all tables subsequently instantiated from this client.
+func (client *Client) WithAuditor(auditor Auditor) *Client {
+	client.auditor = auditor
+	return client
+}
+
+// WithAuditor sets the auditor notified of all writes made to this table.
+func (table *Table) WithAuditor(auditor Auditor) *Table {
+	table.auditor = auditor
+	return table
+}
+
+func (table *Table) audit(ctx context.Context, event AuditEvent) {
+	if table.auditor == nil {
+		return
+	}
+	event.TableName = table.Name
+	event.Tags = Tags(ctx)
+	table.auditor.Audit(ctx, event)
+}
+
+// primaryKeyOf extracts the table's primary key attributes from an item. Index metadata must
+// already be loaded.
+func (table *Table) primaryKeyOf(
+	item map[string]*dynamodb.AttributeValue) map[string]*dynamodb.AttributeValue {
+
+	key := map[string]*dynamodb.AttributeValue{}
+	primaryIndex, found := table.allIndexes()[tablePrimaryIndexName]
+	if !found {
+		return key
+	}
+	for _, keyName := range primaryIndex.getKeys() {
+		if av, found := item[keyName]; found {
+			key[keyName] = av
+		}
+	}
+	return key
+}
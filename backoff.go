@@ -0,0 +1,30 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+const (
+	batchRetryBaseDelay = 50 * time.Millisecond
+	batchRetryMaxDelay  = 5 * time.Second
+	batchMaxRetries     = 8
+)
+
+// sleepBackoff blocks for an exponentially increasing delay with full jitter before the specified
+// retry attempt, starting at 1, or until the context is cancelled.
+func sleepBackoff(ctx context.Context, attempt int) error {
+	maxDelay := batchRetryBaseDelay << uint(attempt-1)
+	if maxDelay > batchRetryMaxDelay || maxDelay <= 0 {
+		maxDelay = batchRetryMaxDelay
+	}
+	delay := time.Duration(rand.Int63n(int64(maxDelay) + 1))
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(delay):
+		return nil
+	}
+}
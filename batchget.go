@@ -0,0 +1,205 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// batchGetChunkSize is the maximum number of keys of a single BatchGetItem request.
+const batchGetChunkSize = 100
+
+// BatchGet reads the items with the specified keys into out. The keys must be a slice of structs
+// or maps containing the table's primary key attributes, and out must be a non-nil pointer to a
+// slice. Found items are appended to out in the order of their keys, and keys with no item are
+// skipped, as are soft-deleted and expired items when those features are enabled. Keys are read
+// in requests of up to 100 keys, and unprocessed keys are retried with exponential backoff. Reads
+// use the item cache and a consistent read if consistent reads are the table's default.
+func (table *Table) BatchGet(ctx context.Context, keys interface{}, out interface{}) error {
+	keysValue := reflect.ValueOf(keys)
+	outValue := reflect.ValueOf(out)
+	if keysValue.Kind() != reflect.Slice {
+		err := fmt.Errorf("keys must be a slice")
+		table.logger.Printf("error: %s\n", err.Error())
+		return err
+	} else if outValue.Kind() != reflect.Ptr || outValue.IsNil() ||
+		outValue.Elem().Kind() != reflect.Slice {
+		err := fmt.Errorf("out must be a non-nil pointer to a slice")
+		table.logger.Printf("error: %s\n", err.Error())
+		return err
+	}
+
+	if err := table.loadIndexMetadata(ctx); err != nil {
+		return err
+	}
+	if table.excludeExpiredItems {
+		if err := table.loadTTLMetadata(ctx); err != nil {
+			return err
+		}
+	}
+
+	consistent := table.consistentReads
+	useCache := table.itemCache != nil && !consistent
+
+	// resolve keys as stored in the table, omitting duplicates and items served from cache
+	cacheKeys := make([]string, 0, keysValue.Len())
+	storedItems := map[string]map[string]*dynamodb.AttributeValue{}
+	pendingKeys := []map[string]*dynamodb.AttributeValue{}
+	for i := 0; i < keysValue.Len(); i++ {
+		keyMap, err := table.marshalItem(keysValue.Index(i).Interface())
+		if err != nil {
+			table.logger.Printf("error: %s\n", err.Error())
+			return err
+		}
+		keyMap, err = table.storedKey(ctx, keyMap)
+		if err != nil {
+			return err
+		}
+		keyMap = table.primaryKeyOf(keyMap)
+
+		cacheKey := itemCacheKey(table.Name, keyMap)
+		cacheKeys = append(cacheKeys, cacheKey)
+		if _, seen := storedItems[cacheKey]; seen {
+			continue
+		}
+
+		if useCache {
+			if item, found := table.itemCache.GetItem(cacheKey); found {
+				storedItems[cacheKey] = item
+				continue
+			}
+		}
+
+		storedItems[cacheKey] = nil
+		pendingKeys = append(pendingKeys, keyMap)
+	}
+
+	if cached := len(storedItems) - len(pendingKeys); cached > 0 {
+		table.logger.Printf("%d items served from cache\n", cached)
+	}
+
+	// read remaining keys in chunks
+	for start := 0; start < len(pendingKeys); start += batchGetChunkSize {
+		end := start + batchGetChunkSize
+		if end > len(pendingKeys) {
+			end = len(pendingKeys)
+		}
+
+		items, err := table.batchGetChunk(ctx, pendingKeys[start:end], consistent)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			storedItems[itemCacheKey(table.Name, table.primaryKeyOf(item))] = item
+		}
+
+		if useCache {
+			for _, key := range pendingKeys[start:end] {
+				cacheKey := itemCacheKey(table.Name, key)
+				if item := storedItems[cacheKey]; item != nil {
+					table.itemCache.SetItem(cacheKey, item, table.itemCacheTTL)
+				} else if table.negativeItemCacheTTL > 0 {
+					table.itemCache.SetItem(cacheKey, nil, table.negativeItemCacheTTL)
+				}
+			}
+		}
+	}
+
+	// unmarshal found items in order of their keys
+	sliceValue := outValue.Elem()
+	elemType := sliceValue.Type().Elem()
+	for _, cacheKey := range cacheKeys {
+		storedItem := storedItems[cacheKey]
+		if storedItem == nil || table.isCounterItem(storedItem) || table.isSoftDeleted(storedItem) ||
+			table.isExpired(storedItem) {
+			continue
+		}
+
+		item, err := table.itemFromStore(storedItem)
+		if err != nil {
+			return err
+		}
+		table.stripRestrictedAttributes(ctx, item)
+
+		itemPtr := reflect.New(elemType)
+		if err := table.unmarshalItem(item, itemPtr.Interface()); err != nil {
+			table.logger.Printf("error: %s\n", err.Error())
+			return err
+		}
+		sliceValue.Set(reflect.Append(sliceValue, itemPtr.Elem()))
+	}
+
+	return nil
+}
+
+// batchGetChunk reads the items with up to 100 keys as stored in the table, retrying unprocessed
+// keys with exponential backoff. Items are returned as stored in the table, in no particular
+// order.
+func (table *Table) batchGetChunk(ctx context.Context, keys []map[string]*dynamodb.AttributeValue,
+	consistent bool) ([]map[string]*dynamodb.AttributeValue, error) {
+
+	readClient := table.baseClient
+	if !consistent {
+		readClient = table.readClient(ctx)
+	}
+
+	requestItems := map[string]*dynamodb.KeysAndAttributes{
+		table.Name: {
+			Keys:           keys,
+			ConsistentRead: aws.Bool(consistent),
+		},
+	}
+
+	items := []map[string]*dynamodb.AttributeValue{}
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			unprocessed := len(requestItems[table.Name].Keys)
+			if attempt > batchMaxRetries {
+				err := ErrUnprocessedKeys{TableName: table.Name, Unprocessed: unprocessed}
+				table.logger.Printf("error: %s\n", err.Error())
+				return nil, err
+			}
+			table.logger.Printf("retrying %d unprocessed keys of table \"%s\"\n",
+				unprocessed, table.Name)
+			if err := sleepBackoff(ctx, attempt); err != nil {
+				return nil, err
+			}
+		}
+
+		start := time.Now()
+		batchOutput, err := readClient.BatchGetItemWithContext(ctx, &dynamodb.BatchGetItemInput{
+			RequestItems:           requestItems,
+			ReturnConsumedCapacity: table.returnConsumedCapacity(),
+		})
+
+		stats := OperationStats{
+			Operation: "BatchGetItem",
+			Latency:   time.Since(start),
+			Err:       err,
+		}
+		if batchOutput != nil {
+			for _, capacity := range batchOutput.ConsumedCapacity {
+				stats.ConsumedCapacity += consumedCapacityUnits(capacity)
+			}
+			stats.Items = len(batchOutput.Responses[table.Name])
+		}
+		table.emitStats(ctx, stats)
+
+		if err != nil {
+			table.logger.Printf("error: %s\n", err.Error())
+			return nil, err
+		}
+
+		items = append(items, batchOutput.Responses[table.Name]...)
+
+		unprocessed, found := batchOutput.UnprocessedKeys[table.Name]
+		if !found || len(unprocessed.Keys) == 0 {
+			return items, nil
+		}
+		requestItems = batchOutput.UnprocessedKeys
+	}
+}
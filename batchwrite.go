@@ -0,0 +1,266 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// batchWriteChunkSize is the maximum number of items of a single BatchWriteItem request.
+const batchWriteChunkSize = 25
+
+// batchWrite is a single put or delete request of a batch write.
+type batchWrite struct {
+	index    int
+	key      map[string]*dynamodb.AttributeValue
+	item     map[string]*dynamodb.AttributeValue
+	request  *dynamodb.WriteRequest
+	attempts int
+}
+
+// BatchPut puts items into the table. The items must be a slice of values that may be given to
+// Put. Items are written in requests of up to 25 items, and unprocessed items are retried with
+// jittered exponential backoff. If multiple items share a primary key, only the last is written,
+// and the others are reported as superseded in the result. Items that could not be written are
+// reported in the result, and ErrBulkWriteFailed is returned if any item failed.
+// ErrVersionedTable is returned if the table has a version attribute.
+func (table *Table) BatchPut(ctx context.Context, items interface{}) (*BulkResult, error) {
+	itemsValue := reflect.ValueOf(items)
+	if itemsValue.Kind() != reflect.Slice {
+		err := fmt.Errorf("items must be a slice")
+		table.logger.Printf("error: %s\n", err.Error())
+		return nil, err
+	}
+
+	// batch writes cannot be conditioned on the stored version of items
+	if err := table.requireUnversioned("BatchPut"); err != nil {
+		return nil, err
+	}
+
+	if err := table.loadIndexMetadata(ctx); err != nil {
+		return nil, err
+	}
+
+	result := &BulkResult{}
+	writes := []*batchWrite{}
+	for i := 0; i < itemsValue.Len(); i++ {
+		attrMap, err := table.encodeItem(itemsValue.Index(i).Interface())
+		if err == nil {
+			err = table.checkProtectedAttributes(attrMap)
+		}
+		if err == nil {
+			err = table.prepareItemForWrite(ctx, attrMap)
+		}
+		if err != nil {
+			result.Failed = append(result.Failed, BulkFailure{Index: i, Item: attrMap, Err: err})
+			continue
+		}
+
+		writes = append(writes, &batchWrite{
+			index: i,
+			key:   table.primaryKeyOf(attrMap),
+			item:  attrMap,
+			request: &dynamodb.WriteRequest{
+				PutRequest: &dynamodb.PutRequest{Item: attrMap},
+			},
+		})
+	}
+
+	table.batchWrite(ctx, writes, AuditOperationPut, result)
+
+	return result, table.bulkResultErr(result)
+}
+
+// BatchDelete removes the items with the specified keys from the table. The keys must be a slice
+// of structs or maps containing the table's primary key attributes. Keys are deleted in requests
+// of up to 25 keys, and unprocessed keys are retried with jittered exponential backoff. Repeated
+// keys are deleted once and reported as superseded in the result. Keys that could not be deleted
+// are reported in the result, and ErrBulkWriteFailed is returned if any key failed.
+func (table *Table) BatchDelete(ctx context.Context, keys interface{}) (*BulkResult, error) {
+	keysValue := reflect.ValueOf(keys)
+	if keysValue.Kind() != reflect.Slice {
+		err := fmt.Errorf("keys must be a slice")
+		table.logger.Printf("error: %s\n", err.Error())
+		return nil, err
+	}
+
+	if err := table.loadIndexMetadata(ctx); err != nil {
+		return nil, err
+	}
+
+	result := &BulkResult{}
+	writes := []*batchWrite{}
+	for i := 0; i < keysValue.Len(); i++ {
+		keyMap, err := table.marshalItem(keysValue.Index(i).Interface())
+		if err == nil {
+			keyMap, err = table.storedKey(ctx, keyMap)
+		}
+		if err != nil {
+			result.Failed = append(result.Failed, BulkFailure{Index: i, Key: keyMap, Err: err})
+			continue
+		}
+
+		key := table.primaryKeyOf(keyMap)
+		writes = append(writes, &batchWrite{
+			index: i,
+			key:   key,
+			request: &dynamodb.WriteRequest{
+				DeleteRequest: &dynamodb.DeleteRequest{Key: key},
+			},
+		})
+	}
+
+	table.batchWrite(ctx, writes, AuditOperationDelete, result)
+
+	return result, table.bulkResultErr(result)
+}
+
+func (table *Table) bulkResultErr(result *BulkResult) error {
+	err := result.Err()
+	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
+	}
+	return err
+}
+
+// batchWrite makes the writes in chunks, recording the outcome of each write in the result. Only
+// the last write of each primary key is made, since a request may not include a key twice, and
+// earlier writes of the key are recorded as superseded.
+func (table *Table) batchWrite(ctx context.Context, writes []*batchWrite,
+	operation AuditOperation, result *BulkResult) {
+
+	lastWrites := map[string]*batchWrite{}
+	for _, write := range writes {
+		lastWrites[itemCacheKey(table.Name, write.key)] = write
+	}
+
+	pending := []*batchWrite{}
+	for _, write := range writes {
+		if lastWrites[itemCacheKey(table.Name, write.key)] == write {
+			pending = append(pending, write)
+		} else {
+			result.Superseded = append(result.Superseded, write.index)
+		}
+	}
+	if len(result.Superseded) > 0 {
+		table.logger.Printf("skipping %d writes replaced by later writes of the same key\n",
+			len(result.Superseded))
+	}
+
+	for start := 0; start < len(pending); start += batchWriteChunkSize {
+		end := start + batchWriteChunkSize
+		if end > len(pending) {
+			end = len(pending)
+		}
+
+		// writes of remaining chunks fail without being attempted once the context is done
+		if err := ctx.Err(); err != nil {
+			table.logger.Printf("error: %s\n", err.Error())
+			failBatchWrites(result, pending[start:], err)
+			return
+		}
+		table.batchWriteChunk(ctx, pending[start:end], operation, result)
+	}
+}
+
+// failBatchWrites records the writes as failed with the error in the result.
+func failBatchWrites(result *BulkResult, writes []*batchWrite, err error) {
+	for _, write := range writes {
+		result.Failed = append(result.Failed, BulkFailure{
+			Index:    write.index,
+			Key:      write.key,
+			Item:     write.item,
+			Err:      err,
+			Attempts: write.attempts,
+		})
+	}
+}
+
+// batchWriteChunk makes up to 25 writes, retrying unprocessed writes with jittered exponential
+// backoff.
+func (table *Table) batchWriteChunk(ctx context.Context, chunk []*batchWrite,
+	operation AuditOperation, result *BulkResult) {
+
+	pending := chunk
+	for attempt := 1; ; attempt++ {
+		requests := make([]*dynamodb.WriteRequest, len(pending))
+		for i, write := range pending {
+			requests[i] = write.request
+			write.attempts = attempt
+		}
+
+		start := time.Now()
+		batchOutput, err := table.baseClient.BatchWriteItemWithContext(ctx,
+			&dynamodb.BatchWriteItemInput{
+				RequestItems: map[string][]*dynamodb.WriteRequest{
+					table.Name: requests,
+				},
+				ReturnConsumedCapacity: table.returnConsumedCapacity(),
+			})
+
+		stats := OperationStats{
+			Operation: "BatchWriteItem",
+			Latency:   time.Since(start),
+			Err:       err,
+		}
+		if batchOutput != nil {
+			for _, capacity := range batchOutput.ConsumedCapacity {
+				stats.ConsumedCapacity += consumedCapacityUnits(capacity)
+			}
+			stats.Items = len(pending) - len(batchOutput.UnprocessedItems[table.Name])
+		}
+		table.emitStats(ctx, stats)
+
+		if err != nil {
+			table.logger.Printf("error: %s\n", err.Error())
+			failBatchWrites(result, pending, err)
+			return
+		}
+
+		// identify unprocessed writes by key
+		unprocessedKeys := newNameSet()
+		for _, request := range batchOutput.UnprocessedItems[table.Name] {
+			if request.PutRequest != nil {
+				unprocessedKeys.Insert(
+					itemCacheKey(table.Name, table.primaryKeyOf(request.PutRequest.Item)))
+			} else if request.DeleteRequest != nil {
+				unprocessedKeys.Insert(itemCacheKey(table.Name, request.DeleteRequest.Key))
+			}
+		}
+
+		unprocessed := []*batchWrite{}
+		for _, write := range pending {
+			if unprocessedKeys.Contains(itemCacheKey(table.Name, write.key)) {
+				unprocessed = append(unprocessed, write)
+				continue
+			}
+
+			result.Succeeded = append(result.Succeeded, write.key)
+			table.invalidateItem(write.key)
+			table.audit(ctx, AuditEvent{
+				Operation: operation,
+				Key:       write.key,
+				NewImage:  write.item,
+			})
+		}
+
+		pending = unprocessed
+		if len(pending) == 0 {
+			return
+		} else if attempt > batchMaxRetries {
+			failBatchWrites(result, pending, ErrUnprocessedKeys{TableName: table.Name, Unprocessed: len(pending)})
+			return
+		}
+
+		table.logger.Printf("retrying %d unprocessed writes of table \"%s\"\n",
+			len(pending), table.Name)
+		if err := sleepBackoff(ctx, attempt); err != nil {
+			failBatchWrites(result, pending, err)
+			return
+		}
+		result.Retries++
+	}
+}
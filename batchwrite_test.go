@@ -0,0 +1,49 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestBatchPutCanceledBetweenChunks(t *testing.T) {
+	fake := newFakeDynamoDB("items", "id", "")
+	table := newFakeTable(fake)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fake.afterBatchWrite = cancel
+
+	// the first item is superseded by the last, leaving 30 writes in two chunks
+	items := []map[string]string{}
+	for i := 0; i < 30; i++ {
+		items = append(items, map[string]string{"id": fmt.Sprintf("item%d", i)})
+	}
+	items = append(items, map[string]string{"id": "item0"})
+
+	result, err := table.BatchPut(ctx, items)
+	if _, isBulkErr := err.(ErrBulkWriteFailed); !isBulkErr {
+		t.Fatalf("expected ErrBulkWriteFailed, got %v", err)
+	} else if expected := "bulk write failed for 5 of 31 items"; err.Error() != expected {
+		t.Errorf("expected error %q, got %q", expected, err.Error())
+	}
+
+	if len(fake.batchWriteInputs) != 1 {
+		t.Errorf("expected 1 batch write, got %d", len(fake.batchWriteInputs))
+	}
+	if len(result.Succeeded) != batchWriteChunkSize {
+		t.Errorf("expected %d items to succeed, got %d", batchWriteChunkSize, len(result.Succeeded))
+	}
+	if len(result.Superseded) != 1 || result.Superseded[0] != 0 {
+		t.Errorf("expected item 0 to be superseded, got %v", result.Superseded)
+	}
+	if len(result.Failed) != 5 {
+		t.Fatalf("expected 5 items to fail, got %d", len(result.Failed))
+	}
+	for _, failure := range result.Failed {
+		if failure.Err != context.Canceled {
+			t.Errorf("expected failure of item %d to be canceled, got %v", failure.Index,
+				failure.Err)
+		}
+	}
+}
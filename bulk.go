@@ -0,0 +1,52 @@
+package dynamodbfriend
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// BulkResult reports the outcome of a bulk write, so that callers may persist and retry only the
+// items that failed rather than treating the whole write as failed.
+type BulkResult struct {
+	// Succeeded holds the primary keys of all items written successfully.
+	Succeeded []map[string]*dynamodb.AttributeValue
+
+	// Failed holds all items that could not be written, along with their individual errors.
+	Failed []BulkFailure
+
+	// Superseded holds the indexes of the given items that were not written because a later item
+	// of the same bulk write has the same primary key.
+	Superseded []int
+
+	// Retries is the total number of retried requests made during the bulk write.
+	Retries int
+}
+
+// BulkFailure describes a single item that could not be written by a bulk write. Index is the
+// index of the item in the slice given to the bulk write.
+type BulkFailure struct {
+	Index    int
+	Key      map[string]*dynamodb.AttributeValue
+	Item     map[string]*dynamodb.AttributeValue
+	Err      error
+	Attempts int
+}
+
+// Err returns ErrBulkWriteFailed if any items failed, or nil if all items succeeded.
+func (r *BulkResult) Err() error {
+	if len(r.Failed) == 0 {
+		return nil
+	}
+	return ErrBulkWriteFailed{Result: r}
+}
+
+// ErrBulkWriteFailed is returned when one or more items of a bulk write could not be written.
+type ErrBulkWriteFailed struct {
+	Result *BulkResult
+}
+
+func (e ErrBulkWriteFailed) Error() string {
+	total := len(e.Result.Failed) + len(e.Result.Succeeded) + len(e.Result.Superseded)
+	return fmt.Sprintf("bulk write failed for %d of %d items", len(e.Result.Failed), total)
+}
@@ -0,0 +1,97 @@
+package dynamodbfriend
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// ItemCache is an interface for storing items read from tables. Items in the cache should be
+// treated as immutable. A nil item indicates that the item is known not to exist in the table.
+type ItemCache interface {
+	GetItem(key string) (item map[string]*dynamodb.AttributeValue, found bool)
+	SetItem(key string, item map[string]*dynamodb.AttributeValue, ttl time.Duration)
+	DeleteItem(key string)
+}
+
+// WithItemCache enables read-through caching of items on this table. Cached items expire after
+// ttl, and are invalidated when written through this table.
+func (table *Table) WithItemCache(cache ItemCache, ttl time.Duration) *Table {
+	table.itemCache = cache
+	table.itemCacheTTL = ttl
+	return table
+}
+
+// WithNegativeItemCaching enables caching of lookups for items that do not exist on tables with
+// an item cache. Missing items are cached for ttl, which should typically be short, and are
+// invalidated when the item is written through this table.
+func (table *Table) WithNegativeItemCaching(ttl time.Duration) *Table {
+	table.negativeItemCacheTTL = ttl
+	return table
+}
+
+// invalidateItem discards all state held about an item, such as after it is written.
+func (table *Table) invalidateItem(key map[string]*dynamodb.AttributeValue) {
+	if table.itemCache != nil {
+		table.itemCache.DeleteItem(itemCacheKey(table.Name, key))
+	}
+	table.forgetWrite(key)
+}
+
+// itemCacheKey returns a canonical string for an item key as stored in a table.
+func itemCacheKey(tableName string, key map[string]*dynamodb.AttributeValue) string {
+	names := []string{}
+	for name := range key {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	sb.WriteString(tableName)
+	for _, name := range names {
+		sb.WriteString("|")
+		sb.WriteString(name)
+		sb.WriteString("=")
+		sb.WriteString(canonicalValue(key[name]))
+	}
+	return sb.String()
+}
+
+// LRUCache is an in-memory ItemCache that evicts the least recently used items once its capacity
+// is reached. LRUCache also implements ItemInvalidator.
+type LRUCache struct {
+	store *lruStore
+}
+
+// NewLRUCache creates a new LRUCache holding at most capacity items.
+func NewLRUCache(capacity int) *LRUCache {
+	return &LRUCache{
+		store: newLRUStore(capacity),
+	}
+}
+
+// GetItem returns the cached item for key, if found and not expired.
+func (c *LRUCache) GetItem(key string) (map[string]*dynamodb.AttributeValue, bool) {
+	value, found := c.store.Get(key)
+	if !found {
+		return nil, false
+	}
+	return value.(map[string]*dynamodb.AttributeValue), true
+}
+
+// SetItem caches an item for key until ttl has passed.
+func (c *LRUCache) SetItem(key string, item map[string]*dynamodb.AttributeValue, ttl time.Duration) {
+	c.store.Set(key, item, ttl)
+}
+
+// DeleteItem removes the cached item for key, if present.
+func (c *LRUCache) DeleteItem(key string) {
+	c.store.Delete(key)
+}
+
+// InvalidateItem removes the cached item for a key in the named table.
+func (c *LRUCache) InvalidateItem(tableName string, key map[string]*dynamodb.AttributeValue) {
+	c.DeleteItem(itemCacheKey(tableName, key))
+}
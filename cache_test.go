@@ -0,0 +1,99 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestItemCacheInvalidationOnWrite(t *testing.T) {
+	key := map[string]string{"id": "a"}
+
+	cases := []struct {
+		name  string
+		write func(table *Table) error
+	}{
+		{
+			name: "Put",
+			write: func(table *Table) error {
+				return table.Put(context.Background(), map[string]string{"id": "a", "status": "x"})
+			},
+		},
+		{
+			name: "Update",
+			write: func(table *Table) error {
+				return table.Update(context.Background(), key, NewUpdate().Set("status", "x"))
+			},
+		},
+		{
+			name: "Delete",
+			write: func(table *Table) error {
+				return table.Delete(context.Background(), key)
+			},
+		},
+		{
+			name: "BatchPut",
+			write: func(table *Table) error {
+				_, err := table.BatchPut(context.Background(), []map[string]string{key})
+				return err
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := newFakeDynamoDB("items", "id", "")
+			fake.putItems(stringItem(map[string]string{"id": "a", "status": "open"}))
+			table := newFakeTable(fake).WithItemCache(NewLRUCache(16), time.Minute)
+
+			get := func() {
+				var item map[string]string
+				err := table.Get(context.Background(), key, &item)
+				if _, notFound := err.(ErrItemNotFound); err != nil && !notFound {
+					t.Fatalf("unexpected error: %s", err)
+				}
+			}
+
+			get()
+			get()
+			if len(fake.getInputs) != 1 {
+				t.Fatalf("expected second read to be served from cache, got %d reads",
+					len(fake.getInputs))
+			}
+
+			if err := tc.write(table); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			get()
+			if len(fake.getInputs) != 2 {
+				t.Errorf("expected read after write to bypass cache, got %d reads",
+					len(fake.getInputs))
+			}
+		})
+	}
+}
+
+func TestNegativeItemCacheInvalidationOnWrite(t *testing.T) {
+	fake := newFakeDynamoDB("items", "id", "")
+	table := newFakeTable(fake).
+		WithItemCache(NewLRUCache(16), time.Minute).
+		WithNegativeItemCaching(time.Minute)
+
+	key := map[string]string{"id": "a"}
+	var item map[string]string
+	for i := 0; i < 2; i++ {
+		if err := table.Get(context.Background(), key, &item); err == nil {
+			t.Fatalf("expected item not to be found")
+		}
+	}
+	if len(fake.getInputs) != 1 {
+		t.Fatalf("expected missing item to be cached, got %d reads", len(fake.getInputs))
+	}
+
+	if err := table.Put(context.Background(), key); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := table.Get(context.Background(), key, &item); err != nil {
+		t.Errorf("expected put item to be found, got %v", err)
+	}
+}
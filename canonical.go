@@ -0,0 +1,150 @@
+package dynamodbfriend
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"math/big"
+	"sort"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// ItemFingerprint returns a deterministic hash of an item's attributes, usable as an idempotency
+// token or to detect changes to an item. The item may be a struct or map marshaled as with
+// dynamodbattribute.MarshalMap, or an attribute map. Items with equal attributes have equal
+// fingerprints regardless of the order of attributes and set elements, and of the formatting of
+// numbers, such as "1.50" and "1.5".
+func ItemFingerprint(item interface{}) (string, error) {
+	attrMap, isAttrMap := item.(map[string]*dynamodb.AttributeValue)
+	if !isAttrMap {
+		var err error
+		attrMap, err = dynamodbattribute.MarshalMap(item)
+		if err != nil {
+			return "", err
+		}
+	}
+	return canonicalItemHash(attrMap), nil
+}
+
+// canonicalItemHash returns the hex-encoded SHA-256 hash of the canonical encoding of an item.
+func canonicalItemHash(item map[string]*dynamodb.AttributeValue) string {
+	hash := sha256.Sum256(canonicalItem(item))
+	return hex.EncodeToString(hash[:])
+}
+
+// canonicalItem returns the canonical encoding of an item, in which attributes are sorted by name,
+// set elements are sorted, and numbers are formatted as exact reduced fractions.
+func canonicalItem(item map[string]*dynamodb.AttributeValue) []byte {
+	var buf bytes.Buffer
+	appendCanonicalMap(&buf, item)
+	return buf.Bytes()
+}
+
+// canonicalValue returns the canonical encoding of a single attribute value.
+func canonicalValue(av *dynamodb.AttributeValue) string {
+	var buf bytes.Buffer
+	appendCanonicalValue(&buf, av)
+	return buf.String()
+}
+
+func appendCanonicalMap(buf *bytes.Buffer, m map[string]*dynamodb.AttributeValue) {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buf.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(strconv.Quote(name))
+		buf.WriteByte(':')
+		appendCanonicalValue(buf, m[name])
+	}
+	buf.WriteByte('}')
+}
+
+func appendCanonicalValue(buf *bytes.Buffer, av *dynamodb.AttributeValue) {
+	switch {
+	case av == nil:
+		buf.WriteString("?")
+	case av.S != nil:
+		buf.WriteString("S")
+		buf.WriteString(strconv.Quote(*av.S))
+	case av.N != nil:
+		buf.WriteString("N")
+		buf.WriteString(canonicalNumber(*av.N))
+	case av.B != nil:
+		buf.WriteString("B")
+		buf.WriteString(base64.StdEncoding.EncodeToString(av.B))
+	case av.BOOL != nil:
+		buf.WriteString("BOOL")
+		buf.WriteString(strconv.FormatBool(*av.BOOL))
+	case av.NULL != nil:
+		buf.WriteString("NULL")
+	case av.SS != nil:
+		elements := make([]string, len(av.SS))
+		for i, s := range av.SS {
+			elements[i] = strconv.Quote(*s)
+		}
+		appendCanonicalSet(buf, "SS", elements)
+	case av.NS != nil:
+		elements := make([]string, len(av.NS))
+		for i, n := range av.NS {
+			elements[i] = canonicalNumber(*n)
+		}
+		appendCanonicalSet(buf, "NS", elements)
+	case av.BS != nil:
+		elements := make([]string, len(av.BS))
+		for i, b := range av.BS {
+			elements[i] = base64.StdEncoding.EncodeToString(b)
+		}
+		appendCanonicalSet(buf, "BS", elements)
+	case av.L != nil:
+		buf.WriteString("L[")
+		for i, element := range av.L {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			appendCanonicalValue(buf, element)
+		}
+		buf.WriteByte(']')
+	case av.M != nil:
+		buf.WriteString("M")
+		appendCanonicalMap(buf, av.M)
+	default:
+		buf.WriteString("?")
+	}
+}
+
+// appendCanonicalSet appends the encoded elements of a set in sorted order, since sets are
+// unordered.
+func appendCanonicalSet(buf *bytes.Buffer, setType string, elements []string) {
+	sort.Strings(elements)
+	buf.WriteString(setType)
+	buf.WriteByte('[')
+	for i, element := range elements {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(element)
+	}
+	buf.WriteByte(']')
+}
+
+// canonicalNumber formats a DynamoDB number exactly as a reduced fraction, so that equal numbers
+// such as "1.50", "1.5", and "15e-1" are formatted identically. Numbers that cannot be parsed are
+// returned as is.
+func canonicalNumber(n string) string {
+	r, ok := new(big.Rat).SetString(n)
+	if !ok {
+		return n
+	}
+	return r.RatString()
+}
@@ -0,0 +1,45 @@
+package dynamodbfriend
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestCanonicalNumberEquality(t *testing.T) {
+	cases := []struct {
+		a, b  string
+		equal bool
+	}{
+		{a: "1.5", b: "1.50", equal: true},
+		{a: "1.5", b: "15e-1", equal: true},
+		{a: "100", b: "1E2", equal: true},
+		{a: "0", b: "-0", equal: true},
+		{a: "0.0", b: "0", equal: true},
+		{a: "007", b: "7", equal: true},
+		{a: "0.1", b: "0.10000000000000000001", equal: false},
+		{a: "12345678901234567890123456789012345678", b: "12345678901234567890123456789012345679",
+			equal: false},
+		{a: "1.5", b: "-1.5", equal: false},
+	}
+
+	for _, tc := range cases {
+		if equal := canonicalNumber(tc.a) == canonicalNumber(tc.b); equal != tc.equal {
+			t.Errorf("expected equality of %s and %s to be %t, got %t (%s, %s)", tc.a, tc.b,
+				tc.equal, equal, canonicalNumber(tc.a), canonicalNumber(tc.b))
+		}
+	}
+}
+
+func TestCanonicalItemHashOfNumberSets(t *testing.T) {
+	a := map[string]*dynamodb.AttributeValue{
+		"ns": {NS: aws.StringSlice([]string{"1.50", "2", "3e0"})},
+	}
+	b := map[string]*dynamodb.AttributeValue{
+		"ns": {NS: aws.StringSlice([]string{"3", "1.5", "2.0"})},
+	}
+	if canonicalItemHash(a) != canonicalItemHash(b) {
+		t.Errorf("expected equal number sets to have equal hashes")
+	}
+}
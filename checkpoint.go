@@ -0,0 +1,117 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+const (
+	checkpointPrefix   = "CHECKPOINT#"
+	checkpointPosition = "position"
+)
+
+// CheckpointStore is an interface for persisting the progress of long-running readers, such as the
+// last handled record of each stream shard read by ConsumeStreamWithCheckpoints or the scan cursor
+// of each segment of a Sweeper or Job. Checkpoints are identified by an id chosen by the reader.
+type CheckpointStore interface {
+	LoadCheckpoint(ctx context.Context, id string) (position string, found bool, err error)
+	SaveCheckpoint(ctx context.Context, id, position string) error
+}
+
+// tableCheckpointStore stores checkpoints as items in a table.
+type tableCheckpointStore struct {
+	table        *Table
+	consumerName string
+}
+
+// Checkpoints returns a checkpoint store that persists checkpoints for the named consumer as items
+// in this table, with all primary key attributes set to "CHECKPOINT#<consumerName>#<id>". The
+// table's primary key attributes must be strings.
+func (table *Table) Checkpoints(consumerName string) CheckpointStore {
+	return &tableCheckpointStore{
+		table:        table,
+		consumerName: consumerName,
+	}
+}
+
+func (store *tableCheckpointStore) checkpointKey(ctx context.Context,
+	id string) (map[string]*dynamodb.AttributeValue, error) {
+
+	if err := store.table.loadIndexMetadata(ctx); err != nil {
+		return nil, err
+	}
+	return store.table.reservedItemKey(ctx, checkpointPrefix+store.consumerName+"#"+id)
+}
+
+func (store *tableCheckpointStore) LoadCheckpoint(ctx context.Context,
+	id string) (string, bool, error) {
+
+	key, err := store.checkpointKey(ctx, id)
+	if err != nil {
+		return "", false, err
+	}
+
+	// checkpoint items are read and written directly, since their reserved keys already carry the
+	// tenant prefix, while stats are still emitted
+	start := time.Now()
+	getOutput, err := store.table.baseClient.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName:              aws.String(store.table.Name),
+		Key:                    key,
+		ConsistentRead:         aws.Bool(true),
+		ReturnConsumedCapacity: store.table.returnConsumedCapacity(),
+	})
+
+	stats := OperationStats{
+		Operation: "GetItem",
+		Latency:   time.Since(start),
+		Items:     1,
+		Err:       err,
+	}
+	if getOutput != nil {
+		stats.ConsumedCapacity = consumedCapacityUnits(getOutput.ConsumedCapacity)
+	}
+	store.table.emitStats(ctx, stats)
+
+	if err != nil {
+		return "", false, err
+	}
+
+	av, found := getOutput.Item[checkpointPosition]
+	if !found || av.S == nil {
+		return "", false, nil
+	}
+	return *av.S, true, nil
+}
+
+func (store *tableCheckpointStore) SaveCheckpoint(ctx context.Context,
+	id, position string) error {
+
+	item, err := store.checkpointKey(ctx, id)
+	if err != nil {
+		return err
+	}
+	item[checkpointPosition] = &dynamodb.AttributeValue{S: aws.String(position)}
+
+	start := time.Now()
+	putOutput, err := store.table.baseClient.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName:              aws.String(store.table.Name),
+		Item:                   item,
+		ReturnConsumedCapacity: store.table.returnConsumedCapacity(),
+	})
+
+	stats := OperationStats{
+		Operation: "PutItem",
+		Latency:   time.Since(start),
+		Items:     1,
+		Err:       err,
+	}
+	if putOutput != nil {
+		stats.ConsumedCapacity = consumedCapacityUnits(putOutput.ConsumedCapacity)
+	}
+	store.table.emitStats(ctx, stats)
+
+	return err
+}
@@ -9,9 +9,47 @@ import (
 // package.
 type Client struct {
 	Base dynamodbiface.DynamoDBAPI
+
+	logger Logger
+
+	tenant string
+
+	auditor Auditor
+
+	statsEmitter StatsEmitter
+
+	registry *tableRegistry
+
+	operationTimeouts OperationTimeouts
+
+	timeEncoding TimeEncoding
+
+	accessPatterns *accessPatternCatalog
+
+	logRedaction RedactionPolicy
+
+	transactionTables *transactionTables
 }
 
 // NewClient creates a new Client instance from a regular DynamoDB client from the AWS SDK v1 for Go.
 func NewClient(dynamoDB dynamodbiface.DynamoDBAPI) *Client {
-	return &Client{Base: dynamoDB}
+	return &Client{
+		Base:              dynamoDB,
+		logger:            nullLogger{},
+		registry:          newTableRegistry(),
+		transactionTables: &transactionTables{tables: map[string]*Table{}},
+	}
+}
+
+// WithLogger sets the default logger for all tables subsequently instantiated from this client.
+func (client *Client) WithLogger(logger Logger) *Client {
+	client.logger = logger
+	return client
+}
+
+func (client *Client) getLogger() Logger {
+	if client.logger == nil {
+		return nullLogger{}
+	}
+	return client.logger
 }
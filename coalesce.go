@@ -0,0 +1,55 @@
+package dynamodbfriend
+
+import "sync"
+
+// requestGroup deduplicates concurrent identical requests, so that a burst of requests for the
+// same key results in a single call shared by all callers.
+type requestGroup struct {
+	mu    sync.Mutex
+	calls map[string]*requestCall
+}
+
+type requestCall struct {
+	wg     sync.WaitGroup
+	result interface{}
+	err    error
+}
+
+// WithRequestCoalescing sets whether concurrent identical reads on this table are coalesced into
+// a single DynamoDB request shared by all callers. Only the first page of a query is coalesced.
+// A coalesced request uses the context of the caller that issued it, so its cancellation is
+// observed by all callers sharing the request.
+func (table *Table) WithRequestCoalescing(enabled bool) *Table {
+	if enabled {
+		table.requestCoalescing = &requestGroup{calls: map[string]*requestCall{}}
+	} else {
+		table.requestCoalescing = nil
+	}
+	return table
+}
+
+// Do calls fn unless a call for key is already in flight, in which case it waits for and returns
+// the result of that call. The shared return value is true if the result was shared with
+// another caller.
+func (g *requestGroup) Do(key string, fn func() (interface{}, error)) (interface{}, error, bool) {
+	g.mu.Lock()
+	if call, found := g.calls[key]; found {
+		g.mu.Unlock()
+		call.wg.Wait()
+		return call.result, call.err, true
+	}
+
+	call := &requestCall{}
+	call.wg.Add(1)
+	g.calls[key] = call
+	g.mu.Unlock()
+
+	call.result, call.err = fn()
+	call.wg.Done()
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+
+	return call.result, call.err, false
+}
@@ -0,0 +1,57 @@
+package dynamodbfriend
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRequestGroupConcurrentCalls(t *testing.T) {
+	group := &requestGroup{calls: map[string]*requestCall{}}
+
+	// the first call is in flight until released, so that later calls may share its result
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var calls int32
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		result, err, _ := group.Do("key", func() (interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			close(started)
+			<-release
+			return "result", nil
+		})
+		if result != "result" || err != nil {
+			t.Errorf("expected result, got %v and %v", result, err)
+		}
+	}()
+	<-started
+
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err, _ := group.Do("key", func() (interface{}, error) {
+				atomic.AddInt32(&calls, 1)
+				return "result", nil
+			})
+			if result != "result" || err != nil {
+				t.Errorf("expected result, got %v and %v", result, err)
+			}
+		}()
+	}
+	close(release)
+	wg.Wait()
+
+	if calls < 1 || calls > 17 {
+		t.Errorf("expected between 1 and 17 calls, got %d", calls)
+	}
+	group.mu.Lock()
+	defer group.mu.Unlock()
+	if len(group.calls) != 0 {
+		t.Errorf("expected no calls in flight, got %d", len(group.calls))
+	}
+}
@@ -0,0 +1,178 @@
+package dynamodbfriend
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+const defaultCodecDiscriminator = "codec"
+
+// Codec encodes an entity type to the attributes of an item and decodes items back into the
+// entity type, such as to store a particular serialization format of the entity.
+type Codec interface {
+	Encode(item interface{}) (map[string]*dynamodb.AttributeValue, error)
+	Decode(attrMap map[string]*dynamodb.AttributeValue, val interface{}) error
+}
+
+// AttributeCodec is a Codec storing each field of an entity as a separate attribute, which is the
+// default serialization of items.
+type AttributeCodec struct{}
+
+// Encode marshals the item to attribute values.
+func (AttributeCodec) Encode(item interface{}) (map[string]*dynamodb.AttributeValue, error) {
+	return dynamodbattribute.MarshalMap(item)
+}
+
+// Decode unmarshals attribute values into val.
+func (AttributeCodec) Decode(attrMap map[string]*dynamodb.AttributeValue, val interface{}) error {
+	return dynamodbattribute.UnmarshalMap(attrMap, val)
+}
+
+// JSONBlobCodec is a Codec storing an entity as a single JSON document in the named string
+// attribute. The key attributes are also stored as separate attributes, so that the item may be
+// addressed and indexed.
+type JSONBlobCodec struct {
+	Attribute     string
+	KeyAttributes []string
+}
+
+// Encode marshals the item to a JSON document alongside its key attributes.
+func (codec JSONBlobCodec) Encode(item interface{}) (map[string]*dynamodb.AttributeValue, error) {
+	attrMap, err := dynamodbattribute.MarshalMap(item)
+	if err != nil {
+		return nil, err
+	}
+
+	document, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded := map[string]*dynamodb.AttributeValue{
+		codec.Attribute: {S: aws.String(string(document))},
+	}
+	for _, keyName := range codec.KeyAttributes {
+		if av, found := attrMap[keyName]; found {
+			encoded[keyName] = av
+		}
+	}
+	return encoded, nil
+}
+
+// Decode unmarshals the JSON document of the item into val.
+func (codec JSONBlobCodec) Decode(attrMap map[string]*dynamodb.AttributeValue,
+	val interface{}) error {
+
+	av, found := attrMap[codec.Attribute]
+	if !found || av.S == nil {
+		return fmt.Errorf("item has no JSON document attribute \"%s\"", codec.Attribute)
+	}
+	return json.Unmarshal([]byte(*av.S), val)
+}
+
+// entityCodecs holds the codecs registered for an entity type, in order of registration.
+type entityCodecs struct {
+	names  []string
+	codecs map[string]Codec
+}
+
+// WithCodecDiscriminator sets the attribute storing the name of the codec used to encode each
+// item of an entity type with registered codecs. The default attribute is "codec".
+func (table *Table) WithCodecDiscriminator(attribute string) *Table {
+	table.codecDiscriminator = attribute
+	return table
+}
+
+// RegisterCodec registers a named codec for the entity type of the given value, which may be a
+// value or pointer. Items of the entity type are written with the most recently registered codec
+// for the type, and the codec's name is stored in the codec discriminator attribute. On reads, the
+// codec named by the discriminator attribute is used, and items without the attribute are decoded
+// with the first codec registered for the type. Register codecs from oldest to newest format.
+func (table *Table) RegisterCodec(entity interface{}, name string, codec Codec) *Table {
+	if table.codecs == nil {
+		table.codecs = map[reflect.Type]*entityCodecs{}
+	}
+
+	entityType := indirectType(reflect.TypeOf(entity))
+	codecs, found := table.codecs[entityType]
+	if !found {
+		codecs = &entityCodecs{codecs: map[string]Codec{}}
+		table.codecs[entityType] = codecs
+	}
+	if _, replaced := codecs.codecs[name]; !replaced {
+		codecs.names = append(codecs.names, name)
+	}
+	codecs.codecs[name] = codec
+	return table
+}
+
+// ErrUnknownCodec is returned when an item's codec discriminator names a codec that is not
+// registered for the entity type it is read into.
+type ErrUnknownCodec struct {
+	Entity string
+	Codec  string
+}
+
+func (e ErrUnknownCodec) Error() string {
+	return fmt.Sprintf("no codec \"%s\" registered for entity type %s", e.Codec, e.Entity)
+}
+
+func (table *Table) discriminatorAttribute() string {
+	if table.codecDiscriminator == "" {
+		return defaultCodecDiscriminator
+	}
+	return table.codecDiscriminator
+}
+
+// entityCodecsOf returns the codecs registered for the type of an item, or nil if none are
+// registered.
+func (table *Table) entityCodecsOf(item interface{}) *entityCodecs {
+	if table.codecs == nil || item == nil {
+		return nil
+	}
+	return table.codecs[indirectType(reflect.TypeOf(item))]
+}
+
+// encodeWithCodec encodes an item with the newest codec registered for its entity type.
+func (table *Table) encodeWithCodec(codecs *entityCodecs,
+	item interface{}) (map[string]*dynamodb.AttributeValue, error) {
+
+	name := codecs.names[len(codecs.names)-1]
+	attrMap, err := codecs.codecs[name].Encode(item)
+	if err != nil {
+		return nil, err
+	}
+	attrMap[table.discriminatorAttribute()] = &dynamodb.AttributeValue{S: aws.String(name)}
+	return attrMap, nil
+}
+
+// decodeWithCodec decodes an item into val with the codec named by its discriminator attribute.
+func (table *Table) decodeWithCodec(codecs *entityCodecs,
+	attrMap map[string]*dynamodb.AttributeValue, val interface{}) error {
+
+	name := codecs.names[0]
+	if av, found := attrMap[table.discriminatorAttribute()]; found && av.S != nil {
+		name = *av.S
+	}
+
+	codec, found := codecs.codecs[name]
+	if !found {
+		return ErrUnknownCodec{
+			Entity: indirectType(reflect.TypeOf(val)).String(),
+			Codec:  name,
+		}
+	}
+	return codec.Decode(attrMap, val)
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,235 @@
+package dynamodbfriend
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// WithResultMemoryBudget limits the estimated size in bytes of query results held in memory by
+// QueryParser.All, QueryParser.Collect, and ScanParser.All. A zero budget is unlimited.
+func (table *Table) WithResultMemoryBudget(bytes int64) *Table {
+	table.resultMemoryBudget = bytes
+	return table
+}
+
+// WithResultSpill sets whether results collected with QueryParser.Collect beyond the result
+// memory budget are spilled to a temporary file in dir rather than failing with
+// ErrMemoryBudgetExceeded. An empty dir uses the default directory for temporary files.
+func (table *Table) WithResultSpill(enabled bool, dir string) *Table {
+	table.resultSpill = enabled
+	table.resultSpillDir = dir
+	return table
+}
+
+// All reads all remaining items of the query into items, which must be a non-nil pointer to a
+// slice. ErrMemoryBudgetExceeded is returned if the items exceed the table's result memory budget.
+// The parser is closed when All returns.
+func (parser *QueryParser) All(ctx context.Context, items interface{}) error {
+	defer parser.Close()
+
+	return parser.table.drainAll(ctx, items, parser.expr.logger, parser.nextStoredItem,
+		parser.decodeStoredItem)
+}
+
+// drainAll reads all remaining items returned by nextItem into items, which must be a non-nil
+// pointer to a slice, decoding each item with decode. Items are read until nextItem returns
+// ErrParsingComplete. ErrMemoryBudgetExceeded is returned if the items exceed the table's result
+// memory budget.
+func (table *Table) drainAll(ctx context.Context, items interface{}, logger Logger,
+	nextItem func(ctx context.Context) (map[string]*dynamodb.AttributeValue, error),
+	decode func(ctx context.Context, storedItem map[string]*dynamodb.AttributeValue,
+		val interface{}) error) error {
+
+	itemsValue := reflect.ValueOf(items)
+	if itemsValue.Kind() != reflect.Ptr || itemsValue.IsNil() ||
+		itemsValue.Elem().Kind() != reflect.Slice {
+		err := fmt.Errorf("items must be a non-nil pointer to a slice")
+		logger.Printf("error: %s\n", err.Error())
+		return err
+	}
+	sliceValue := itemsValue.Elem()
+	elemType := sliceValue.Type().Elem()
+
+	budget := table.resultMemoryBudget
+	var size int64
+	for {
+		storedItem, err := nextItem(ctx)
+		if _, parsingComplete := err.(ErrParsingComplete); parsingComplete {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		size += int64(itemSize(storedItem))
+		if budget > 0 && size > budget {
+			err := ErrMemoryBudgetExceeded{TableName: table.Name, Budget: budget}
+			logger.Printf("error: %s\n", err.Error())
+			return err
+		}
+
+		itemPtr := reflect.New(elemType)
+		if err := decode(ctx, storedItem, itemPtr.Interface()); err != nil {
+			return err
+		}
+		sliceValue.Set(reflect.Append(sliceValue, itemPtr.Elem()))
+	}
+}
+
+// Collect reads all remaining items of the query into a result set, so that the query's pages are
+// not held open while items are consumed. Items beyond the table's result memory budget are
+// spilled to a temporary file if result spill is enabled, and otherwise ErrMemoryBudgetExceeded
+// is returned. The result set should be closed to remove any temporary file. The parser is closed
+// when Collect returns.
+func (parser *QueryParser) Collect(ctx context.Context) (*ResultSet, error) {
+	defer parser.Close()
+
+	results := &ResultSet{parser: parser}
+
+	budget := parser.table.resultMemoryBudget
+	var size int64
+	for {
+		storedItem, err := parser.nextStoredItem(ctx)
+		if _, parsingComplete := err.(ErrParsingComplete); parsingComplete {
+			break
+		} else if err != nil {
+			results.Close()
+			return nil, err
+		}
+
+		size += int64(itemSize(storedItem))
+		if budget > 0 && size > budget {
+			if !parser.table.resultSpill {
+				results.Close()
+				err := ErrMemoryBudgetExceeded{TableName: parser.table.Name, Budget: budget}
+				parser.expr.logger.Printf("error: %s\n", err.Error())
+				return nil, err
+			}
+			if err := results.spill(storedItem); err != nil {
+				results.Close()
+				parser.expr.logger.Printf("error: %s\n", err.Error())
+				return nil, err
+			}
+			continue
+		}
+
+		results.items = append(results.items, storedItem)
+	}
+
+	if err := results.finishSpill(); err != nil {
+		results.Close()
+		parser.expr.logger.Printf("error: %s\n", err.Error())
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// ResultSet holds all items collected from a query, either in memory or spilled to a temporary
+// file.
+type ResultSet struct {
+	parser *QueryParser
+
+	items     []map[string]*dynamodb.AttributeValue
+	nextIndex int
+
+	spillFile    *os.File
+	spillWriter  *bufio.Writer
+	spillDecoder *DynamoJSONDecoder
+	spilled      int
+	closed       bool
+}
+
+var _ Iterator = (*ResultSet)(nil)
+
+// Len returns the total number of items in the result set, including spilled items.
+func (results *ResultSet) Len() int {
+	return len(results.items) + results.spilled
+}
+
+// Next unmarshals the next item of the result set into val. ErrParsingComplete is returned once
+// all items have been read.
+func (results *ResultSet) Next(ctx context.Context, val interface{}) error {
+	if results.closed {
+		return ErrParsingComplete{reason: "result set has been closed"}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var storedItem map[string]*dynamodb.AttributeValue
+	if results.nextIndex < len(results.items) {
+		storedItem = results.items[results.nextIndex]
+		results.nextIndex++
+	} else if results.spillDecoder != nil {
+		err := results.spillDecoder.Decode(&storedItem)
+		if err == io.EOF {
+			return ErrParsingComplete{reason: "all items have been parsed"}
+		} else if err != nil {
+			return err
+		}
+	} else {
+		return ErrParsingComplete{reason: "all items have been parsed"}
+	}
+
+	return results.parser.decodeStoredItem(ctx, storedItem, val)
+}
+
+// Close releases the items of the result set and removes any temporary file. Close always
+// returns nil.
+func (results *ResultSet) Close() error {
+	if results.closed {
+		return nil
+	}
+	results.closed = true
+	results.items = nil
+
+	if results.spillFile != nil {
+		results.spillFile.Close()
+		os.Remove(results.spillFile.Name())
+		results.spillFile = nil
+	}
+	return nil
+}
+
+func (results *ResultSet) spill(storedItem map[string]*dynamodb.AttributeValue) error {
+	if results.spillFile == nil {
+		file, err := ioutil.TempFile(results.parser.table.resultSpillDir, "dynamodbfriend-*")
+		if err != nil {
+			return err
+		}
+		results.parser.expr.logger.Printf(
+			"query results exceed memory budget, spilling to \"%s\"\n", file.Name())
+		results.spillFile = file
+		results.spillWriter = bufio.NewWriter(file)
+	}
+
+	// spilled items are written as DynamoDB JSON lines, which retain zero values of all types
+	if err := MarshalDynamoJSON(storedItem, results.spillWriter); err != nil {
+		return err
+	}
+	results.spilled++
+	return nil
+}
+
+// finishSpill flushes spilled items and prepares them to be read back.
+func (results *ResultSet) finishSpill() error {
+	if results.spillFile == nil {
+		return nil
+	}
+
+	if err := results.spillWriter.Flush(); err != nil {
+		return err
+	}
+	if _, err := results.spillFile.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	results.spillDecoder = NewDynamoJSONDecoder(bufio.NewReader(results.spillFile))
+	return nil
+}
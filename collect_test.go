@@ -0,0 +1,183 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestResultSetSpillRoundTrip(t *testing.T) {
+	cases := []struct {
+		name string
+		item map[string]*dynamodb.AttributeValue
+	}{
+		{
+			name: "false bool",
+			item: map[string]*dynamodb.AttributeValue{"v": {BOOL: aws.Bool(false)}},
+		},
+		{
+			name: "empty string",
+			item: map[string]*dynamodb.AttributeValue{"v": {S: aws.String("")}},
+		},
+		{
+			name: "zero number",
+			item: map[string]*dynamodb.AttributeValue{"v": {N: aws.String("0")}},
+		},
+		{
+			name: "empty list",
+			item: map[string]*dynamodb.AttributeValue{"v": {L: []*dynamodb.AttributeValue{}}},
+		},
+		{
+			name: "empty map",
+			item: map[string]*dynamodb.AttributeValue{"v": {M: map[string]*dynamodb.AttributeValue{}}},
+		},
+		{
+			name: "null",
+			item: map[string]*dynamodb.AttributeValue{"v": {NULL: aws.Bool(true)}},
+		},
+		{
+			name: "nested zero values",
+			item: map[string]*dynamodb.AttributeValue{
+				"id": {S: aws.String("a")},
+				"v": {M: map[string]*dynamodb.AttributeValue{
+					"flag": {BOOL: aws.Bool(false)},
+					"list": {L: []*dynamodb.AttributeValue{{S: aws.String("")}, {N: aws.String("0")}}},
+				}},
+				"b":  {B: []byte{0, 1}},
+				"ns": {NS: aws.StringSlice([]string{"0", "1"})},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			results := &ResultSet{parser: &QueryParser{
+				table: &Table{},
+				expr:  &QueryExpr{logger: nullLogger{}},
+			}}
+			defer results.Close()
+
+			if err := results.spill(tc.item); err != nil {
+				t.Fatalf("spill: %s", err)
+			}
+			if err := results.finishSpill(); err != nil {
+				t.Fatalf("finishSpill: %s", err)
+			}
+
+			var decoded map[string]*dynamodb.AttributeValue
+			if err := results.spillDecoder.Decode(&decoded); err != nil {
+				t.Fatalf("decode: %s", err)
+			}
+			if !reflect.DeepEqual(decoded, tc.item) {
+				t.Errorf("expected %v, got %v", tc.item, decoded)
+			}
+			if err := results.spillDecoder.Decode(&decoded); err != io.EOF {
+				t.Errorf("expected io.EOF after last item, got %v", err)
+			}
+		})
+	}
+}
+
+func TestAllClosesParser(t *testing.T) {
+	items := []map[string]*dynamodb.AttributeValue{
+		stringItem(map[string]string{"id": "a", "ts": "1", "name": "first"}),
+		stringItem(map[string]string{"id": "a", "ts": "2", "name": "second"}),
+	}
+
+	cases := []struct {
+		name   string
+		budget int64
+		query  bool
+	}{
+		{name: "query drained", query: true},
+		{name: "query over memory budget", budget: 1, query: true},
+		{name: "scan drained"},
+		{name: "scan over memory budget", budget: 1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := newFakeDynamoDB("items", "id", "ts")
+			fake.putItems(items...)
+			fake.queryItems = items
+			table := newFakeTable(fake).WithResultMemoryBudget(tc.budget)
+			ctx := context.Background()
+
+			var closed func() bool
+			var err error
+			decoded := []map[string]interface{}{}
+			if tc.query {
+				parser, queryErr := table.Query(ctx, NewQuery("id").Equals("a"))
+				if queryErr != nil {
+					t.Fatalf("unexpected error: %s", queryErr)
+				}
+				closed = func() bool { return parser.closed }
+				err = parser.All(ctx, &decoded)
+			} else {
+				parser, scanErr := table.Scan(ctx, NewScan())
+				if scanErr != nil {
+					t.Fatalf("unexpected error: %s", scanErr)
+				}
+				closed = func() bool { return parser.closed }
+				err = parser.All(ctx, &decoded)
+			}
+
+			if _, exceeded := err.(ErrMemoryBudgetExceeded); tc.budget > 0 && !exceeded {
+				t.Errorf("expected ErrMemoryBudgetExceeded, got %v", err)
+			} else if tc.budget == 0 && err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+			if !closed() {
+				t.Errorf("expected parser to be closed")
+			}
+		})
+	}
+}
+
+func TestCollectClosesParser(t *testing.T) {
+	cases := []struct {
+		name      string
+		budget    int64
+		expectErr bool
+	}{
+		{name: "within memory budget"},
+		{name: "over memory budget without spill", budget: 1, expectErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := newFakeDynamoDB("items", "id", "ts")
+			fake.queryItems = []map[string]*dynamodb.AttributeValue{
+				stringItem(map[string]string{"id": "a", "ts": "1"}),
+				stringItem(map[string]string{"id": "a", "ts": "2"}),
+			}
+			table := newFakeTable(fake).WithResultMemoryBudget(tc.budget)
+			ctx := context.Background()
+
+			parser, err := table.Query(ctx, NewQuery("id").Equals("a"))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			results, err := parser.Collect(ctx)
+			if (err != nil) != tc.expectErr {
+				t.Errorf("expected error %t, got %v", tc.expectErr, err)
+			}
+			if results != nil {
+				// collected items remain readable once the parser is closed
+				var item map[string]interface{}
+				if err := results.Next(ctx, &item); err != nil {
+					t.Errorf("unexpected error reading result set: %s", err)
+				}
+				results.Close()
+			}
+			if !parser.closed {
+				t.Errorf("expected parser to be closed")
+			}
+		})
+	}
+}
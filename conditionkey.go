@@ -0,0 +1,210 @@
+package dynamodbfriend
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+// ConditionKey is a partially-formed condition on an attribute of an expression of type E, such as
+// a QueryExpr, ScanExpr, FilterGroup, DeleteExpr, UpdateExpr, or PutExpr.
+//
+// To add the condition to the expression, the key part must be followed by a conditional.
+// Attribute names are translated by the attribute aliases of the table the expression is used
+// with.
+type ConditionKey[E any] struct {
+	key string
+
+	// add adds a condition to the expression, and fail records an error building the expression
+	add  func(filter queryFilter) E
+	fail func(err error) E
+}
+
+// Equals is a conditional where the value of the attribute must equal val.
+func (k *ConditionKey[E]) Equals(val interface{}) E {
+	return k.add(&equalsFilter{
+		key:   k.key,
+		value: val,
+	})
+}
+
+// NotEquals is a conditional where the value of the attribute must not equal val. Items without
+// the attribute also meet the condition.
+func (k *ConditionKey[E]) NotEquals(val interface{}) E {
+	return k.add(&notEqualsFilter{
+		key:   k.key,
+		value: val,
+	})
+}
+
+// LessThan is a conditional where the value of the attribute must be less than val.
+func (k *ConditionKey[E]) LessThan(val interface{}) E {
+	return k.add(&lessThanFilter{
+		key:   k.key,
+		value: val,
+	})
+}
+
+// GreaterThan is a conditional where the value of the attribute must be greater than val.
+func (k *ConditionKey[E]) GreaterThan(val interface{}) E {
+	return k.add(&greaterThanFilter{
+		key:   k.key,
+		value: val,
+	})
+}
+
+// LessThanEqual is a conditional where the value of the attribute must be less than or equal to
+// val.
+func (k *ConditionKey[E]) LessThanEqual(val interface{}) E {
+	return k.add(&lessThanEqualFilter{
+		key:   k.key,
+		value: val,
+	})
+}
+
+// GreaterThanEqual is a conditional where the value of the attribute must be greater than or
+// equal to val.
+func (k *ConditionKey[E]) GreaterThanEqual(val interface{}) E {
+	return k.add(&greaterThanEqualFilter{
+		key:   k.key,
+		value: val,
+	})
+}
+
+// Between is a conditional where the value of the attribute must be between lowval and highval.
+func (k *ConditionKey[E]) Between(lowval, highval interface{}) E {
+	return k.add(&betweenFilter{
+		key:     k.key,
+		lowval:  lowval,
+		highval: highval,
+	})
+}
+
+// BeginsWith is a conditional where the value of the attribute must begin with a specified
+// prefix.
+func (k *ConditionKey[E]) BeginsWith(prefix string) E {
+	return k.add(&beginsWithFilter{
+		key:    k.key,
+		prefix: prefix,
+	})
+}
+
+// Contains is a conditional where the value of the attribute must be a string containing val as a
+// substring, or a string set containing val as an element.
+func (k *ConditionKey[E]) Contains(val string) E {
+	return k.add(&containsFilter{
+		key:   k.key,
+		value: val,
+	})
+}
+
+// NotContains is a conditional where the value of the attribute must not be a string containing
+// val as a substring, nor a string set containing val as an element. Items without the attribute
+// also meet the condition.
+func (k *ConditionKey[E]) NotContains(val string) E {
+	return k.add(&notContainsFilter{
+		key:   k.key,
+		value: val,
+	})
+}
+
+// Exists is a conditional where the attribute must be set on the item.
+func (k *ConditionKey[E]) Exists() E {
+	return k.add(&existsFilter{
+		key: k.key,
+	})
+}
+
+// NotExists is a conditional where the attribute must not be set on the item.
+func (k *ConditionKey[E]) NotExists() E {
+	return k.add(&notExistsFilter{
+		key: k.key,
+	})
+}
+
+// In is a conditional where the value of the attribute must equal any of vals. At least one value
+// is required, and otherwise an error is returned when the expression is used.
+func (k *ConditionKey[E]) In(vals ...interface{}) E {
+	if len(vals) == 0 {
+		return k.fail(fmt.Errorf("key \"%s\" requires at least one value in \"%s\" condition",
+			k.key, inOp))
+	}
+
+	return k.add(&inFilter{
+		key:    k.key,
+		values: vals,
+	})
+}
+
+// writeConditions are the conditions of a write expression that must be met by the existing item.
+type writeConditions struct {
+	// filters are conditions on attribute names translated by the table's attribute aliases
+	filters []queryFilter
+
+	// raw are conditions on attribute names as stored in the table
+	raw []expression.ConditionBuilder
+
+	buildErr error
+}
+
+// newWriteConditionKey begins a condition on the named attribute of a write expression, adding
+// the condition to conditions. Build errors are logged with the expression's current logger.
+func newWriteConditionKey[E any](expr E, key string, conditions *writeConditions,
+	logger *Logger) *ConditionKey[E] {
+
+	return &ConditionKey[E]{
+		key: key,
+		add: func(filter queryFilter) E {
+			conditions.filters = append(conditions.filters, filter)
+			return expr
+		},
+		fail: func(err error) E {
+			(*logger).Printf("error: %s\n", err.Error())
+			conditions.buildErr = err
+			return expr
+		},
+	}
+}
+
+// empty returns true if there are no conditions.
+func (c *writeConditions) empty() bool {
+	return len(c.filters) == 0 && len(c.raw) == 0
+}
+
+// withRaw returns a copy of the conditions with an additional raw condition.
+func (c *writeConditions) withRaw(condition expression.ConditionBuilder) writeConditions {
+	return writeConditions{
+		filters:  c.filters,
+		raw:      append(append([]expression.ConditionBuilder{}, c.raw...), condition),
+		buildErr: c.buildErr,
+	}
+}
+
+// condition returns the combined condition on attribute names as stored in the table, or nil if
+// there are no conditions. An error is returned if a condition could not be built.
+func (c *writeConditions) condition(
+	storedName func(string) string) (*expression.ConditionBuilder, error) {
+
+	if c.buildErr != nil {
+		return nil, c.buildErr
+	}
+
+	conditions := make([]expression.ConditionBuilder, 0, len(c.filters)+len(c.raw))
+	for _, filter := range c.filters {
+		conditions = append(conditions,
+			filter.Condition(expression.Name(storedName(filter.Key()))))
+	}
+	conditions = append(conditions, c.raw...)
+
+	var condition *expression.ConditionBuilder
+	for _, next := range conditions {
+		next := next
+		if condition == nil {
+			condition = &next
+		} else {
+			combined := condition.And(next)
+			condition = &combined
+		}
+	}
+	return condition, nil
+}
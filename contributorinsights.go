@@ -0,0 +1,106 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// HotKey is a key reported by CloudWatch Contributor Insights as frequently accessed.
+type HotKey struct {
+	// Keys holds the key attribute values identifying the contributor, starting with the
+	// partition key value.
+	Keys []string
+
+	// Count is the approximate number of accesses to the key over the reported period.
+	Count float64
+}
+
+// contributor insights rule suffix for most accessed partition keys
+const mostAccessedPartitionKeysRule = "-PKC-"
+
+// EnableContributorInsights enables CloudWatch Contributor Insights on the table, or on the named
+// index if indexName is not empty.
+func (table *Table) EnableContributorInsights(ctx context.Context, indexName string) error {
+	input := &dynamodb.UpdateContributorInsightsInput{
+		TableName:                 aws.String(table.Name),
+		ContributorInsightsAction: aws.String(dynamodb.ContributorInsightsActionEnable),
+	}
+	if indexName != "" {
+		input.IndexName = aws.String(indexName)
+	}
+
+	_, err := table.baseClient.UpdateContributorInsightsWithContext(ctx, input)
+	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
+		return err
+	}
+
+	table.logger.Printf("enabled contributor insights for table \"%s\" index \"%s\"\n",
+		table.Name, indexName)
+	return nil
+}
+
+// MostAccessedKeys reads the most accessed partition keys of the table, or of the named index if
+// indexName is not empty, over the period ending now from the Contributor Insights report in
+// CloudWatch. At most maxKeys keys are returned, in descending order of access count. Contributor
+// Insights must be enabled with EnableContributorInsights.
+func (table *Table) MostAccessedKeys(ctx context.Context, cw cloudwatchiface.CloudWatchAPI,
+	indexName string, period time.Duration, maxKeys int) ([]HotKey, error) {
+
+	input := &dynamodb.DescribeContributorInsightsInput{
+		TableName: aws.String(table.Name),
+	}
+	if indexName != "" {
+		input.IndexName = aws.String(indexName)
+	}
+
+	describeInfo, err := table.baseClient.DescribeContributorInsightsWithContext(ctx, input)
+	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
+		return nil, err
+	}
+
+	ruleName := ""
+	for _, rule := range describeInfo.ContributorInsightsRuleList {
+		if strings.Contains(aws.StringValue(rule), mostAccessedPartitionKeysRule) {
+			ruleName = aws.StringValue(rule)
+		}
+	}
+	if ruleName == "" {
+		err := fmt.Errorf("contributor insights not enabled for table \"%s\" index \"%s\"",
+			table.Name, indexName)
+		table.logger.Printf("error: %s\n", err.Error())
+		return nil, err
+	}
+
+	endTime := time.Now()
+	reportInfo, err := cw.GetInsightRuleReportWithContext(ctx, &cloudwatch.GetInsightRuleReportInput{
+		RuleName:            aws.String(ruleName),
+		StartTime:           aws.Time(endTime.Add(-period)),
+		EndTime:             aws.Time(endTime),
+		Period:              aws.Int64(int64(period / time.Second)),
+		MaxContributorCount: aws.Int64(int64(maxKeys)),
+		OrderBy:             aws.String("Sum"),
+	})
+	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
+		return nil, err
+	}
+
+	hotKeys := []HotKey{}
+	for _, contributor := range reportInfo.Contributors {
+		hotKeys = append(hotKeys, HotKey{
+			Keys:  aws.StringValueSlice(contributor.Keys),
+			Count: aws.Float64Value(contributor.ApproximateAggregateValue),
+		})
+	}
+
+	return hotKeys, nil
+}
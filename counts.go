@@ -0,0 +1,308 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams"
+)
+
+const (
+	countPrefix    = "COUNT#"
+	countValueAttr = "itemCount"
+)
+
+// PutCounted adds an operation putting a new item into the table, along with an operation adding
+// 1 to the materialized count of items in the item's partition. The put requires that no item
+// with the same primary key exists, so that the count only changes when an item is added, and the
+// transaction is canceled otherwise. The count is read with Table.CountFast. Counts are stored in
+// counter items of the table with all primary key attributes set to "COUNT#<partition key
+// value>", so the table's primary key attributes must be strings. Counter items are not returned
+// by scans, queries, or batch reads of the table.
+func (tx *Transaction) PutCounted(table *Table, item interface{}) *Transaction {
+	tx.put(table, item, nil, newItem)
+	return tx.addCount(table, tx.ops[len(tx.ops)-1], 1)
+}
+
+// DeleteCounted adds an operation removing the item with the specified key from the table, along
+// with an operation subtracting 1 from the materialized count of items in the item's partition,
+// as maintained by PutCounted. The delete requires the item to exist, so that the count only
+// changes when an item is removed, and the transaction is canceled otherwise.
+func (tx *Transaction) DeleteCounted(table *Table, key interface{}) *Transaction {
+	tx.deleteIf(table, key, NewDelete(), existingItem)
+	return tx.addCount(table, tx.ops[len(tx.ops)-1], -1)
+}
+
+// addCount adds an operation adding delta to the count of the partition of the item written by
+// the write operation, which must be built before the count operation.
+func (tx *Transaction) addCount(table *Table, write *transactionOp, delta int64) *Transaction {
+	op := &transactionOp{table: table}
+	op.build = func(ctx context.Context) (*dynamodb.TransactWriteItem, error) {
+		partitionKey := table.allIndexes()[tablePrimaryIndexName].PartitionKey
+		id, err := storedPartitionID(write.key[partitionKey])
+		if err != nil {
+			return nil, err
+		}
+		key, err := table.countKeyOfID(id)
+		if err != nil {
+			return nil, err
+		}
+		op.key = key
+
+		dbExpr, err := countUpdateExpression(delta)
+		if err != nil {
+			return nil, err
+		}
+		return &dynamodb.TransactWriteItem{Update: &dynamodb.Update{
+			TableName:                 aws.String(table.Name),
+			Key:                       key,
+			UpdateExpression:          dbExpr.Update(),
+			ExpressionAttributeNames:  dbExpr.Names(),
+			ExpressionAttributeValues: dbExpr.Values(),
+		}}, nil
+	}
+	return tx.add(op)
+}
+
+// CountStreamHandler returns a StreamHandler maintaining the materialized count of items in each
+// partition of the table from its stream, as an alternative to PutCounted and DeleteCounted in
+// transactions.
+// Inserted items increment the count of their partition, and removed items decrement it. Counter
+// items are not counted. Records handled more than once, such as after a restart of
+// ConsumeStreamWithCheckpoints, are counted more than once.
+func (table *Table) CountStreamHandler() StreamHandler {
+	return func(ctx context.Context, record *dynamodbstreams.Record) error {
+		var delta int64
+		switch aws.StringValue(record.EventName) {
+		case dynamodbstreams.OperationTypeInsert:
+			delta = 1
+		case dynamodbstreams.OperationTypeRemove:
+			delta = -1
+		default:
+			return nil
+		}
+
+		if err := table.loadIndexMetadata(ctx); err != nil {
+			return err
+		}
+		if record.Dynamodb == nil {
+			return nil
+		}
+
+		partitionKey := table.allIndexes()[tablePrimaryIndexName].PartitionKey
+		streamValue, found := record.Dynamodb.Keys[partitionKey]
+		if !found {
+			err := fmt.Errorf("stream record of table \"%s\" has no partition key \"%s\"",
+				table.Name, partitionKey)
+			table.logger.Printf("error: %s\n", err.Error())
+			return err
+		}
+
+		// skip counter items, whose updates are also written to the stream
+		id, err := storedPartitionID(streamValue)
+		if err != nil {
+			table.logger.Printf("error: %s\n", err.Error())
+			return err
+		} else if strings.HasPrefix(id, countPrefix) {
+			return nil
+		}
+
+		key, err := table.countKeyOfID(id)
+		if err != nil {
+			return err
+		}
+		return table.addCount(ctx, key, delta)
+	}
+}
+
+// CountFast returns the materialized count of items in the partition of the table with the given
+// partition key value, as maintained by PutCounted and DeleteCounted or CountStreamHandler. A
+// single item is read, rather than paging through a query of the partition. The count is 0 if it
+// has never been maintained.
+func (table *Table) CountFast(ctx context.Context, partitionValue interface{}) (int64, error) {
+	key, err := table.countKey(ctx, partitionValue)
+	if err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	getOutput, err := table.baseClient.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName:              aws.String(table.Name),
+		Key:                    key,
+		ConsistentRead:         aws.Bool(table.consistentReads),
+		ReturnConsumedCapacity: table.returnConsumedCapacity(),
+	})
+
+	stats := OperationStats{
+		Operation: "GetItem",
+		Latency:   time.Since(start),
+		Err:       err,
+	}
+	if getOutput != nil {
+		stats.ConsumedCapacity = consumedCapacityUnits(getOutput.ConsumedCapacity)
+		stats.Items = len(getOutput.Item)
+	}
+	table.emitStats(ctx, stats)
+
+	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
+		return 0, err
+	}
+
+	av, found := getOutput.Item[countValueAttr]
+	if !found || av.N == nil {
+		return 0, nil
+	}
+	count, err := strconv.ParseInt(*av.N, 10, 64)
+	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
+		return 0, err
+	}
+	return count, nil
+}
+
+// addCount atomically adds delta to the counter item with the given key.
+func (table *Table) addCount(ctx context.Context, key map[string]*dynamodb.AttributeValue,
+	delta int64) error {
+
+	dbExpr, err := countUpdateExpression(delta)
+	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
+		return err
+	}
+
+	start := time.Now()
+	updateOutput, err := table.baseClient.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(table.Name),
+		Key:                       key,
+		UpdateExpression:          dbExpr.Update(),
+		ExpressionAttributeNames:  dbExpr.Names(),
+		ExpressionAttributeValues: dbExpr.Values(),
+		ReturnConsumedCapacity:    table.returnConsumedCapacity(),
+	})
+
+	stats := OperationStats{
+		Operation: "UpdateItem",
+		Latency:   time.Since(start),
+		Items:     1,
+		Err:       err,
+	}
+	if updateOutput != nil {
+		stats.ConsumedCapacity = consumedCapacityUnits(updateOutput.ConsumedCapacity)
+	}
+	table.emitStats(ctx, stats)
+
+	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
+		return err
+	}
+	return nil
+}
+
+func countUpdateExpression(delta int64) (expression.Expression, error) {
+	update := expression.Add(expression.Name(countValueAttr), expression.Value(delta))
+	return expression.NewBuilder().WithUpdate(update).Build()
+}
+
+// countKey returns the key of the counter item of the partition with the given partition key
+// value, as stored in the table.
+func (table *Table) countKey(ctx context.Context,
+	partitionValue interface{}) (map[string]*dynamodb.AttributeValue, error) {
+
+	if err := table.loadIndexMetadata(ctx); err != nil {
+		return nil, err
+	}
+
+	av, err := dynamodbattribute.Marshal(partitionValue)
+	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
+		return nil, err
+	}
+
+	// counts are kept per stored partition, including any tenant prefix
+	partitionKey := table.allIndexes()[tablePrimaryIndexName].PartitionKey
+	stored := map[string]*dynamodb.AttributeValue{partitionKey: av}
+	if err := table.applyTenantPrefix(ctx, stored); err != nil {
+		return nil, err
+	}
+
+	id, err := storedPartitionID(stored[partitionKey])
+	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
+		return nil, err
+	}
+	return table.countKeyOfID(id)
+}
+
+// isCounterItem returns true if an item as stored in the table, or its key, is a counter item.
+// Index metadata must already be loaded.
+func (table *Table) isCounterItem(storedItem map[string]*dynamodb.AttributeValue) bool {
+	partitionKey := table.allIndexes()[tablePrimaryIndexName].PartitionKey
+	av, found := storedItem[partitionKey]
+	if !found || av.S == nil || !strings.HasPrefix(*av.S, countPrefix) {
+		return false
+	}
+
+	// counter items have the same value for all primary key attributes
+	for _, keyName := range table.allIndexes()[tablePrimaryIndexName].getKeys() {
+		if keyAV, found := storedItem[keyName]; !found || aws.StringValue(keyAV.S) != *av.S {
+			return false
+		}
+	}
+	return true
+}
+
+// withoutCounterItems returns the items as stored in the table excluding counter items. Index
+// metadata must already be loaded.
+func (table *Table) withoutCounterItems(
+	storedItems []map[string]*dynamodb.AttributeValue) []map[string]*dynamodb.AttributeValue {
+
+	items := make([]map[string]*dynamodb.AttributeValue, 0, len(storedItems))
+	for _, storedItem := range storedItems {
+		if !table.isCounterItem(storedItem) {
+			items = append(items, storedItem)
+		}
+	}
+	return items
+}
+
+// countKeyOfID returns the key of the counter item of the partition with the given stored ID.
+// Counter keys are not tenant prefixed, since the ID already includes any tenant prefix. An error
+// is returned if any primary key attribute of the table is not a string.
+func (table *Table) countKeyOfID(id string) (map[string]*dynamodb.AttributeValue, error) {
+	primaryIndex := table.allIndexes()[tablePrimaryIndexName]
+	key := map[string]*dynamodb.AttributeValue{}
+	for _, keyName := range primaryIndex.getKeys() {
+		if keyType := primaryIndex.keyType(keyName); keyType != dynamodb.ScalarAttributeTypeS {
+			err := fmt.Errorf("counts require string primary key attributes, but key \"%s\" of "+
+				"table \"%s\" has type %s", keyName, table.Name, keyType)
+			table.logger.Printf("error: %s\n", err.Error())
+			return nil, err
+		}
+		key[keyName] = &dynamodb.AttributeValue{S: aws.String(countPrefix + id)}
+	}
+	return key, nil
+}
+
+// storedPartitionID returns a string identifying a partition key value as stored in the table.
+// Numbers are canonicalized, so that equal numbers such as "1.0" and "1" identify the same
+// partition.
+func storedPartitionID(av *dynamodb.AttributeValue) (string, error) {
+	switch {
+	case av.S != nil:
+		return *av.S, nil
+	case av.N != nil:
+		return canonicalNumber(*av.N), nil
+	case av.B != nil:
+		return base64.StdEncoding.EncodeToString(av.B), nil
+	}
+	return "", fmt.Errorf("partition key value must be a string, number, or binary")
+}
@@ -0,0 +1,130 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestCountKeyTypes(t *testing.T) {
+	cases := []struct {
+		name      string
+		keyType   string
+		expectErr bool
+	}{
+		{name: "string key", keyType: dynamodb.ScalarAttributeTypeS},
+		{name: "number key", keyType: dynamodb.ScalarAttributeTypeN, expectErr: true},
+		{name: "binary key", keyType: dynamodb.ScalarAttributeTypeB, expectErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := newFakeDynamoDB("items", "id", "ts")
+			for _, definition := range fake.description.AttributeDefinitions {
+				if *definition.AttributeName == "ts" {
+					definition.AttributeType = aws.String(tc.keyType)
+				}
+			}
+			table := newFakeTable(fake)
+
+			_, err := table.CountFast(context.Background(), "a")
+			if (err != nil) != tc.expectErr {
+				t.Errorf("expected error %t, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestStoredPartitionIDCanonicalNumbers(t *testing.T) {
+	numbers := []string{"15", "15.0", "1.5e1", "015"}
+
+	for _, n := range numbers {
+		id, err := storedPartitionID(&dynamodb.AttributeValue{N: aws.String(n)})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		} else if id != "15" {
+			t.Errorf("expected partition ID of %s to be 15, got %s", n, id)
+		}
+	}
+}
+
+func TestCounterItemsNotReturned(t *testing.T) {
+	counterItem := stringItem(map[string]string{"id": "COUNT#a", "ts": "COUNT#a"})
+	counterItem[countValueAttr] = &dynamodb.AttributeValue{N: aws.String("2")}
+	items := []map[string]*dynamodb.AttributeValue{
+		stringItem(map[string]string{"id": "a", "ts": "1"}),
+		counterItem,
+		stringItem(map[string]string{"id": "a", "ts": "2"}),
+	}
+	keys := []map[string]string{
+		{"id": "a", "ts": "1"},
+		{"id": "COUNT#a", "ts": "COUNT#a"},
+		{"id": "a", "ts": "2"},
+	}
+
+	cases := []struct {
+		name string
+		read func(table *Table, out *[]map[string]string) error
+	}{
+		{
+			name: "Query",
+			read: func(table *Table, out *[]map[string]string) error {
+				parser, err := table.Query(context.Background(), NewQuery("id").Equals("a"))
+				if err != nil {
+					return err
+				}
+				return parser.All(context.Background(), out)
+			},
+		},
+		{
+			name: "BatchGet",
+			read: func(table *Table, out *[]map[string]string) error {
+				return table.BatchGet(context.Background(), keys, out)
+			},
+		},
+		{
+			name: "GetMany",
+			read: func(table *Table, out *[]map[string]string) error {
+				return table.GetMany(context.Background(), keys, out, GetManyBatch)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := newFakeDynamoDB("items", "id", "ts")
+			fake.putItems(items...)
+			fake.queryItems = items
+			table := newFakeTable(fake)
+
+			out := []map[string]string{}
+			if err := tc.read(table, &out); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if len(out) != 2 {
+				t.Fatalf("expected 2 items, got %d", len(out))
+			}
+			for _, item := range out {
+				if item["id"] != "a" {
+					t.Errorf("expected only items of partition a, got %v", item)
+				}
+			}
+		})
+	}
+
+	t.Run("GetMany of counter key", func(t *testing.T) {
+		fake := newFakeDynamoDB("items", "id", "ts")
+		fake.putItems(items...)
+		table := newFakeTable(fake)
+
+		out := []map[string]string{}
+		err := table.GetMany(context.Background(), keys[1:2], &out, GetManySnapshotRequired)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		} else if len(out) != 0 {
+			t.Errorf("expected no items, got %d", len(out))
+		}
+	})
+}
@@ -0,0 +1,115 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+// Delete removes the item with the specified key from the table. The key may be a struct or map
+// containing the table's primary key attributes. Deleting an item that does not exist succeeds.
+func (table *Table) Delete(ctx context.Context, key interface{}) error {
+	return table.DeleteIf(ctx, key, NewDelete())
+}
+
+// DeleteIf removes the item with the specified key from the table if the existing item meets all
+// conditions of the delete expression. ErrConditionFailed is returned if the item does not meet
+// the conditions or does not exist while the expression has conditions.
+func (table *Table) DeleteIf(ctx context.Context, key interface{}, expr *DeleteExpr) error {
+	// fall back to table logger if no logger is set on the expression
+	if !expr.loggerSpecified {
+		expr.logger = table.logger
+	}
+
+	keyMap, err := table.marshalStoredKey(ctx, key)
+	if err != nil {
+		expr.logger.Printf("error: %s\n", err.Error())
+		return err
+	}
+
+	condition, err := expr.condition(table.storedName)
+	if err != nil {
+		return err
+	}
+
+	err = table.deleteItem(ctx, keyMap, condition)
+	if awsErr, isAWSErr := err.(awserr.Error); isAWSErr &&
+		awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+
+		err = ErrConditionFailed{TableName: table.Name}
+		expr.logger.Printf("error: %s\n", err.Error())
+	}
+
+	return err
+}
+
+// deleteItem deletes the item with the key as stored in the table, if the condition is met.
+func (table *Table) deleteItem(ctx context.Context, key map[string]*dynamodb.AttributeValue,
+	condition *expression.ConditionBuilder) error {
+
+	deleteInput := &dynamodb.DeleteItemInput{
+		TableName: aws.String(table.Name),
+		Key:       key,
+	}
+
+	// apply condition expression, if specified
+	if condition != nil {
+		dbExpr, err := expression.NewBuilder().WithCondition(*condition).Build()
+		if err != nil {
+			table.logger.Printf("error: %s\n", err.Error())
+			return err
+		}
+		deleteInput.ConditionExpression = dbExpr.Condition()
+		deleteInput.ExpressionAttributeNames = dbExpr.Names()
+		deleteInput.ExpressionAttributeValues = dbExpr.Values()
+
+		err = table.validateExpressions(dbExpr.Names(),
+			expressionPart{kind: "condition", expression: dbExpr.Condition()})
+		if err != nil {
+			return err
+		}
+	}
+
+	// request old image for auditing, if applicable
+	if table.auditor != nil {
+		deleteInput.ReturnValues = aws.String(dynamodb.ReturnValueAllOld)
+	}
+
+	deleteInput.ReturnConsumedCapacity = table.returnConsumedCapacity()
+
+	start := time.Now()
+	deleteOutput, err := table.baseClient.DeleteItemWithContext(ctx, deleteInput)
+
+	stats := OperationStats{
+		Operation: "DeleteItem",
+		Latency:   time.Since(start),
+		Items:     1,
+		Err:       err,
+	}
+	if deleteOutput != nil {
+		stats.ConsumedCapacity = consumedCapacityUnits(deleteOutput.ConsumedCapacity)
+	}
+	table.emitStats(ctx, stats)
+
+	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
+	} else {
+		table.invalidateItem(key)
+	}
+
+	event := AuditEvent{
+		Operation: AuditOperationDelete,
+		Key:       key,
+		Err:       err,
+	}
+	if deleteOutput != nil {
+		event.OldImage = deleteOutput.Attributes
+	}
+	table.audit(ctx, event)
+
+	return err
+}
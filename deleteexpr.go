@@ -0,0 +1,53 @@
+package dynamodbfriend
+
+import "github.com/aws/aws-sdk-go/service/dynamodb/expression"
+
+// DeleteExpr is an expression of conditions that must be met by an existing item for it to be
+// deleted with Table.DeleteIf.
+type DeleteExpr struct {
+	conditions writeConditions
+
+	loggerSpecified bool
+	logger          Logger
+}
+
+// DeleteExprKey is a partially-formed delete expression.
+//
+// To make a fully-formed delete expression, the key part must be followed by a conditional.
+type DeleteExprKey = ConditionKey[*DeleteExpr]
+
+// NewDelete begins a new delete expression.
+func NewDelete() *DeleteExpr {
+	return &DeleteExpr{
+		logger: nullLogger{},
+	}
+}
+
+// Where begins a condition on the named attribute of the item to delete. Multiple conditions must
+// all be met.
+func (expr *DeleteExpr) Where(key string) *DeleteExprKey {
+	return newWriteConditionKey(expr, key, &expr.conditions, &expr.logger)
+}
+
+// WithCondition adds a condition that must be met by the item to delete. Attribute names in the
+// condition are used as stored in the table, and are not translated by attribute aliases.
+func (expr *DeleteExpr) WithCondition(condition expression.ConditionBuilder) *DeleteExpr {
+	expr.conditions.raw = append(expr.conditions.raw, condition)
+	return expr
+}
+
+// WithLogger sets the logger used when deleting with this expression. If not set, the table's
+// logger is used.
+func (expr *DeleteExpr) WithLogger(logger Logger) *DeleteExpr {
+	expr.loggerSpecified = true
+	expr.logger = logger
+	return expr
+}
+
+// condition returns the combined condition of the expression on attribute names as stored in the
+// table, or nil if the expression has no conditions.
+func (expr *DeleteExpr) condition(
+	storedName func(string) string) (*expression.ConditionBuilder, error) {
+
+	return expr.conditions.condition(storedName)
+}
@@ -0,0 +1,40 @@
+package dynamodbfriend
+
+import (
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// DerivedAttributeFunc computes the value of an attribute from the other attributes of an item.
+// A nil value leaves the attribute unset.
+type DerivedAttributeFunc func(item map[string]*dynamodb.AttributeValue) (*dynamodb.AttributeValue, error)
+
+type derivedAttribute struct {
+	name    string
+	compute DerivedAttributeFunc
+}
+
+// WithDerivedAttribute registers a function computing the named attribute on every item written
+// through this table, such as a composite index key built from other attributes. Derived
+// attributes are computed in registration order, so later functions may use attributes derived
+// by earlier functions.
+func (table *Table) WithDerivedAttribute(name string, compute DerivedAttributeFunc) *Table {
+	table.derivedAttributes = append(table.derivedAttributes, derivedAttribute{
+		name:    name,
+		compute: compute,
+	})
+	return table
+}
+
+func (table *Table) applyDerivedAttributes(item map[string]*dynamodb.AttributeValue) error {
+	for _, derived := range table.derivedAttributes {
+		av, err := derived.compute(item)
+		if err != nil {
+			table.logger.Printf("error: derived attribute \"%s\": %s\n", derived.name, err.Error())
+			return err
+		}
+		if av != nil {
+			item[derived.name] = av
+		}
+	}
+	return nil
+}
@@ -0,0 +1,284 @@
+package dynamodbfriend
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+// derivedUpdate returns a copy of an update expression that also sets or removes the derived and
+// sparse attributes of the item as changed by the update. The update is applied to the current
+// item to compute the attributes, so the returned update is conditioned on the item being
+// unchanged since it was read.
+func (table *Table) derivedUpdate(ctx context.Context, keyMap map[string]*dynamodb.AttributeValue,
+	expr *UpdateExpr) (*UpdateExpr, error) {
+
+	storedItem, err := table.getStoredItem(ctx, keyMap, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var item map[string]*dynamodb.AttributeValue
+	var unchanged expression.ConditionBuilder
+	if storedItem != nil {
+		item, err = table.itemFromStore(storedItem)
+		if err != nil {
+			return nil, err
+		}
+		var checkable bool
+		unchanged, checkable = table.unchangedCondition(storedItem)
+		if !checkable {
+			err := fmt.Errorf("item has too many attributes to apply derived and sparse attributes " +
+				"to an update")
+			expr.logger.Printf("error: %s\n", err.Error())
+			return nil, err
+		}
+	} else {
+		item = copyItem(keyMap)
+		table.stripTenantPrefix(item)
+		table.removeAliases(item)
+		partitionKey := table.allIndexes()[tablePrimaryIndexName].PartitionKey
+		unchanged = expression.AttributeNotExists(expression.Name(partitionKey))
+	}
+
+	if err := applyUpdateActions(item, expr.actions); err != nil {
+		expr.logger.Printf("error: %s\n", err.Error())
+		return nil, err
+	}
+	updatedItem := copyItem(item)
+	if err := table.applyDerivedAttributes(updatedItem); err != nil {
+		return nil, err
+	}
+	table.applySparseAttributes(updatedItem)
+
+	// attributes changed by the rules replace any action of the update on the attribute
+	ruleNames := []string{}
+	seen := newNameSet()
+	for _, derived := range table.derivedAttributes {
+		if !seen.Contains(derived.name) {
+			seen.Insert(derived.name)
+			ruleNames = append(ruleNames, derived.name)
+		}
+	}
+	for _, sparse := range table.sparseAttributes {
+		if !seen.Contains(sparse.name) {
+			seen.Insert(sparse.name)
+			ruleNames = append(ruleNames, sparse.name)
+		}
+	}
+
+	changed := newNameSet()
+	storedValues := map[string]*dynamodb.AttributeValue{}
+	for _, name := range ruleNames {
+		if attributeValuesEqual(item[name], updatedItem[name]) {
+			continue
+		}
+		changed.Insert(name)
+		if av, found := updatedItem[name]; found {
+			storedValues[table.storedName(name)] = av
+		}
+	}
+	if changed.Empty() {
+		derived := *expr
+		derived.conditions = expr.conditions.withRaw(unchanged)
+		return &derived, nil
+	}
+
+	// derived values of partition keys are prefixed with the tenant like the values of puts
+	if err := table.applyTenantPrefix(ctx, storedValues); err != nil {
+		return nil, err
+	}
+
+	derived := *expr
+	derived.actions = []updateAction{}
+	for _, action := range expr.actions {
+		if !changed.Contains(action.name) {
+			derived.actions = append(derived.actions, action)
+		}
+	}
+	for _, name := range ruleNames {
+		if !changed.Contains(name) {
+			continue
+		}
+		if av, found := storedValues[table.storedName(name)]; found {
+			derived.addAction(setOp, name, av)
+		} else {
+			derived.addAction(removeOp, name, nil)
+		}
+	}
+	derived.conditions = expr.conditions.withRaw(unchanged)
+	return &derived, nil
+}
+
+// applyUpdateActions applies the actions of an update to an item as DynamoDB would. Only actions
+// on top-level attributes may be applied.
+func applyUpdateActions(item map[string]*dynamodb.AttributeValue, actions []updateAction) error {
+	for _, action := range actions {
+		if strings.ContainsAny(action.name, ".[") {
+			return fmt.Errorf("update of nested attribute \"%s\" is not supported with derived or "+
+				"sparse attributes", action.name)
+		}
+
+		if action.op == removeOp {
+			delete(item, action.name)
+			continue
+		}
+
+		av, err := dynamodbattribute.Marshal(action.value)
+		if err != nil {
+			return err
+		}
+		current, found := item[action.name]
+
+		switch action.op {
+		case setOp:
+			item[action.name] = av
+		case setIfNotExistsOp:
+			if !found {
+				item[action.name] = av
+			}
+		case addOp:
+			if !found {
+				item[action.name] = av
+				continue
+			}
+			sum, err := addAttributeValues(current, av)
+			if err != nil {
+				return fmt.Errorf("cannot add to attribute \"%s\": %s", action.name, err.Error())
+			}
+			item[action.name] = sum
+		case deleteOp:
+			if !found {
+				continue
+			}
+			remaining, err := deleteSetElements(current, av)
+			if err != nil {
+				return fmt.Errorf("cannot delete from attribute \"%s\": %s", action.name, err.Error())
+			}
+			if remaining == nil {
+				delete(item, action.name)
+			} else {
+				item[action.name] = remaining
+			}
+		}
+	}
+	return nil
+}
+
+// addAttributeValues returns the sum of two numbers or the union of two sets of the same type.
+func addAttributeValues(a, b *dynamodb.AttributeValue) (*dynamodb.AttributeValue, error) {
+	switch {
+	case a.N != nil && b.N != nil:
+		sum, err := addNumbers(*a.N, *b.N)
+		if err != nil {
+			return nil, err
+		}
+		return &dynamodb.AttributeValue{N: &sum}, nil
+	case a.SS != nil && b.SS != nil:
+		return &dynamodb.AttributeValue{SS: unionStrings(a.SS, b.SS, identity)}, nil
+	case a.NS != nil && b.NS != nil:
+		return &dynamodb.AttributeValue{NS: unionStrings(a.NS, b.NS, canonicalNumber)}, nil
+	case a.BS != nil && b.BS != nil:
+		union := append([][]byte{}, a.BS...)
+		for _, element := range b.BS {
+			if !containsBytes(union, element) {
+				union = append(union, element)
+			}
+		}
+		return &dynamodb.AttributeValue{BS: union}, nil
+	}
+	return nil, fmt.Errorf("values must be numbers or sets of the same type")
+}
+
+// deleteSetElements returns the elements of set a not in set b, or nil if no elements remain.
+func deleteSetElements(a, b *dynamodb.AttributeValue) (*dynamodb.AttributeValue, error) {
+	var remaining *dynamodb.AttributeValue
+	switch {
+	case a.SS != nil && b.SS != nil:
+		remaining = &dynamodb.AttributeValue{SS: differenceStrings(a.SS, b.SS, identity)}
+		if len(remaining.SS) == 0 {
+			return nil, nil
+		}
+	case a.NS != nil && b.NS != nil:
+		remaining = &dynamodb.AttributeValue{NS: differenceStrings(a.NS, b.NS, canonicalNumber)}
+		if len(remaining.NS) == 0 {
+			return nil, nil
+		}
+	case a.BS != nil && b.BS != nil:
+		remaining = &dynamodb.AttributeValue{BS: [][]byte{}}
+		for _, element := range a.BS {
+			if !containsBytes(b.BS, element) {
+				remaining.BS = append(remaining.BS, element)
+			}
+		}
+		if len(remaining.BS) == 0 {
+			return nil, nil
+		}
+	default:
+		return nil, fmt.Errorf("values must be sets of the same type")
+	}
+	return remaining, nil
+}
+
+// addNumbers returns the exact sum of two DynamoDB numbers.
+func addNumbers(a, b string) (string, error) {
+	x, okA := new(big.Rat).SetString(a)
+	y, okB := new(big.Rat).SetString(b)
+	if !okA || !okB {
+		return "", fmt.Errorf("invalid numbers \"%s\" and \"%s\"", a, b)
+	}
+
+	sum := x.Add(x, y)
+	if sum.IsInt() {
+		return sum.Num().String(), nil
+	}
+	// sums of decimal numbers are decimal numbers, with at most 38 digits in DynamoDB
+	for precision := 1; precision < 38; precision++ {
+		formatted := sum.FloatString(precision)
+		if r, _ := new(big.Rat).SetString(formatted); r.Cmp(sum) == 0 {
+			return formatted, nil
+		}
+	}
+	return sum.FloatString(38), nil
+}
+
+func identity(s string) string {
+	return s
+}
+
+func unionStrings(a, b []*string, canonical func(string) string) []*string {
+	union := append([]*string{}, a...)
+	for _, element := range differenceStrings(b, a, canonical) {
+		union = append(union, element)
+	}
+	return union
+}
+
+func differenceStrings(a, b []*string, canonical func(string) string) []*string {
+	excluded := newNameSet()
+	for _, element := range b {
+		excluded.Insert(canonical(*element))
+	}
+	difference := []*string{}
+	for _, element := range a {
+		if !excluded.Contains(canonical(*element)) {
+			difference = append(difference, element)
+		}
+	}
+	return difference
+}
+
+func containsBytes(set [][]byte, element []byte) bool {
+	for _, member := range set {
+		if bytes.Equal(member, element) {
+			return true
+		}
+	}
+	return false
+}
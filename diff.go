@@ -0,0 +1,275 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"math"
+	"reflect"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// DiffOptions configures a comparison of two tables with Diff.
+type DiffOptions struct {
+	// Query restricts the comparison to items matching the query expression in each table, such as
+	// a key range. If nil, both tables are scanned.
+	Query *QueryExpr
+
+	// SampleRate is the fraction of items compared, between 0 and 1. Items are sampled by key, so
+	// the same items are sampled from both tables. A zero sample rate compares all items.
+	SampleRate float64
+
+	// IgnoreAttributes are excluded from comparison, such as timestamps set by the copy process.
+	IgnoreAttributes []string
+
+	// MaxDifferences stops the comparison after the number of differences is found. A zero value
+	// finds all differences.
+	MaxDifferences int
+}
+
+// AttributeDiff describes an attribute with different values in two tables. A nil value
+// indicates the attribute is missing from the corresponding table.
+type AttributeDiff struct {
+	Name   string
+	ValueA *dynamodb.AttributeValue
+	ValueB *dynamodb.AttributeValue
+}
+
+// ItemDiff describes an item that differs between two tables.
+type ItemDiff struct {
+	Key        map[string]*dynamodb.AttributeValue
+	MissingInA bool
+	MissingInB bool
+	Attributes []AttributeDiff
+}
+
+// DiffReport is the result of comparing two tables with Diff.
+type DiffReport struct {
+	ItemsCompared int
+	Differences   []ItemDiff
+	Truncated     bool
+}
+
+// errStopIteration stops iteration over table items without error.
+var errStopIteration = errors.New("stop iteration")
+
+// Diff compares the items of two tables, such as to validate a migration, and reports items
+// missing from either table and items with mismatched attributes. Items are compared as returned
+// by reads of each table, so the tables may differ in tenant isolation, aliases, and schema
+// versions. Items of table A are compared to the item with the same key in table B, then table B
+// is read for items missing from table A.
+func Diff(ctx context.Context, tableA, tableB *Table, opts DiffOptions) (*DiffReport, error) {
+	if err := tableA.loadIndexMetadata(ctx); err != nil {
+		return nil, err
+	}
+	if err := tableB.loadIndexMetadata(ctx); err != nil {
+		return nil, err
+	}
+
+	ignored := newNameSet(opts.IgnoreAttributes...)
+	report := &DiffReport{}
+	addDifference := func(diff ItemDiff) error {
+		report.Differences = append(report.Differences, diff)
+		if opts.MaxDifferences > 0 && len(report.Differences) >= opts.MaxDifferences {
+			report.Truncated = true
+			return errStopIteration
+		}
+		return nil
+	}
+
+	// compare items of table A to table B
+	err := tableA.forEachStoredItem(ctx, opts.Query,
+		func(storedItem map[string]*dynamodb.AttributeValue) error {
+			key := tableA.logicalKeyOf(storedItem)
+			if !diffSampled(key, opts.SampleRate) {
+				return nil
+			}
+			report.ItemsCompared++
+
+			itemA, err := tableA.itemFromStore(storedItem)
+			if err != nil {
+				return err
+			}
+			itemB, err := tableB.getLogicalItem(ctx, key)
+			if err != nil {
+				return err
+			} else if itemB == nil {
+				return addDifference(ItemDiff{Key: key, MissingInB: true})
+			}
+
+			if attributeDiffs := diffItems(itemA, itemB, ignored); len(attributeDiffs) > 0 {
+				return addDifference(ItemDiff{Key: key, Attributes: attributeDiffs})
+			}
+			return nil
+		})
+	if err == errStopIteration {
+		return report, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	// find items of table B missing from table A
+	err = tableB.forEachStoredItem(ctx, opts.Query,
+		func(storedItem map[string]*dynamodb.AttributeValue) error {
+			key := tableB.logicalKeyOf(storedItem)
+			if !diffSampled(key, opts.SampleRate) {
+				return nil
+			}
+
+			itemA, err := tableA.getLogicalItem(ctx, key)
+			if err != nil {
+				return err
+			} else if itemA == nil {
+				return addDifference(ItemDiff{Key: key, MissingInA: true})
+			}
+			return nil
+		})
+	if err != nil && err != errStopIteration {
+		return nil, err
+	}
+
+	tableA.logger.Printf("found %d differences between tables \"%s\" and \"%s\"\n",
+		len(report.Differences), tableA.Name, tableB.Name)
+
+	return report, nil
+}
+
+// forEachStoredItem calls fn for each item as stored in the table, either matching the query or
+// from a scan of the table if the query is nil. Index metadata must already be loaded.
+func (table *Table) forEachStoredItem(ctx context.Context, query *QueryExpr,
+	fn func(storedItem map[string]*dynamodb.AttributeValue) error) error {
+
+	if query == nil {
+		scan := segmentScan{segment: 0, totalSegments: 1}
+		return table.scanSegment(ctx, scan,
+			func(ctx context.Context, page *dynamodb.ScanOutput) error {
+				for _, storedItem := range page.Items {
+					if err := fn(storedItem); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+	}
+
+	parser, err := table.Query(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer parser.Close()
+
+	for {
+		storedItem, err := parser.nextStoredItem(ctx)
+		if _, parsingComplete := err.(ErrParsingComplete); parsingComplete {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if err := fn(storedItem); err != nil {
+			return err
+		}
+	}
+}
+
+// logicalKeyOf returns the primary key of a stored item as given by callers.
+func (table *Table) logicalKeyOf(
+	storedItem map[string]*dynamodb.AttributeValue) map[string]*dynamodb.AttributeValue {
+
+	key := copyItem(table.primaryKeyOf(storedItem))
+	table.stripTenantPrefix(key)
+	table.removeAliases(key)
+	return key
+}
+
+// getLogicalItem reads the item with a key as given by callers, returning the item as returned by
+// reads. A nil item is returned if no item exists with the key.
+func (table *Table) getLogicalItem(ctx context.Context,
+	key map[string]*dynamodb.AttributeValue) (map[string]*dynamodb.AttributeValue, error) {
+
+	storedKey, err := table.storedKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	storedItem, err := table.getStoredItem(ctx, storedKey, false)
+	if err != nil || storedItem == nil {
+		return nil, err
+	}
+
+	return table.itemFromStore(storedItem)
+}
+
+// diffSampled returns true if the item with the key is included in a sample at the rate.
+func diffSampled(key map[string]*dynamodb.AttributeValue, sampleRate float64) bool {
+	if sampleRate <= 0 || sampleRate >= 1 {
+		return true
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(itemCacheKey("", key)))
+	return float64(hash.Sum32()) < sampleRate*math.MaxUint32
+}
+
+// diffItems returns the attributes with different values in two items.
+func diffItems(itemA, itemB map[string]*dynamodb.AttributeValue,
+	ignored *nameSet) []AttributeDiff {
+
+	names := newNameSet()
+	for name := range itemA {
+		names.Insert(name)
+	}
+	for name := range itemB {
+		names.Insert(name)
+	}
+
+	sortedNames := names.Names()
+	sort.Strings(sortedNames)
+
+	diffs := []AttributeDiff{}
+	for _, name := range sortedNames {
+		if ignored.Contains(name) {
+			continue
+		}
+		if !attributeValuesEqual(itemA[name], itemB[name]) {
+			diffs = append(diffs, AttributeDiff{Name: name, ValueA: itemA[name], ValueB: itemB[name]})
+		}
+	}
+	return diffs
+}
+
+// attributeValuesEqual compares attribute values, ignoring the order of set elements.
+func attributeValuesEqual(a, b *dynamodb.AttributeValue) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	a, b = normalizedSets(a), normalizedSets(b)
+	return reflect.DeepEqual(a, b)
+}
+
+// normalizedSets returns a copy of the attribute value with set elements sorted.
+func normalizedSets(av *dynamodb.AttributeValue) *dynamodb.AttributeValue {
+	if av.SS == nil && av.NS == nil && av.BS == nil {
+		return av
+	}
+
+	normalized := *av
+	if av.SS != nil {
+		normalized.SS = append([]*string{}, av.SS...)
+		sort.Slice(normalized.SS, func(i, j int) bool { return *normalized.SS[i] < *normalized.SS[j] })
+	}
+	if av.NS != nil {
+		normalized.NS = append([]*string{}, av.NS...)
+		sort.Slice(normalized.NS, func(i, j int) bool { return *normalized.NS[i] < *normalized.NS[j] })
+	}
+	if av.BS != nil {
+		normalized.BS = append([][]byte{}, av.BS...)
+		sort.Slice(normalized.BS, func(i, j int) bool {
+			return string(normalized.BS[i]) < string(normalized.BS[j])
+		})
+	}
+	return &normalized
+}
@@ -0,0 +1,73 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"encoding/hex"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// DigestOn restricts the digest computed by QueryParser.Digest to the named attributes of each
+// item, such as a version or last-modified attribute, rather than all item attributes.
+func (expr *QueryExpr) DigestOn(attributes ...string) *QueryExpr {
+	expr.digestAttributes = attributes
+	return expr
+}
+
+// Digest returns a stable hash over all items read by the parser so far, in order. Once all items
+// have been read, the digest identifies the query's result set and may be compared with a digest
+// from a previous execution of the query to detect changes.
+func (parser *QueryParser) Digest() string {
+	return hex.EncodeToString(parser.digest.Sum(nil))
+}
+
+func (parser *QueryParser) writeDigest(item map[string]*dynamodb.AttributeValue) {
+	digestItem := item
+	if len(parser.expr.digestAttributes) > 0 {
+		digestItem = map[string]*dynamodb.AttributeValue{}
+		for _, attribute := range parser.expr.digestAttributes {
+			if av, found := item[attribute]; found {
+				digestItem[attribute] = av
+			}
+		}
+	}
+
+	parser.digest.Write(canonicalItem(digestItem))
+	parser.digest.Write([]byte{'\n'})
+}
+
+// QueryIfChanged executes the query and compares the digest of its results to lastDigest. If the
+// results are unchanged, the returned parser is nil. Otherwise, the returned parser iterates over
+// the results, which have already been read into memory. The digest of the results is returned in
+// either case.
+func (table *Table) QueryIfChanged(ctx context.Context, expr *QueryExpr,
+	lastDigest string) (*QueryParser, string, error) {
+
+	parser, err := table.Query(ctx, expr)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// read all pages into memory
+	items := []map[string]*dynamodb.AttributeValue{}
+	for !parser.allItemsParsed() && !parser.maxPaginationReached() {
+		if err := ctx.Err(); err != nil {
+			return nil, "", err
+		}
+		if err := parser.fetchNextPage(ctx); err != nil {
+			return nil, "", err
+		}
+		items = append(items, parser.bufferedItems...)
+	}
+
+	digest := parser.Digest()
+	if digest == lastDigest {
+		parser.expr.logger.Printf("query results unchanged\n")
+		return nil, digest, nil
+	}
+
+	parser.bufferedItems = items
+	parser.currentBufferIndex = 0
+
+	return parser, digest, nil
+}
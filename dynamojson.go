@@ -0,0 +1,115 @@
+package dynamodbfriend
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// MarshalDynamoJSON writes an item to w in DynamoDB JSON, the typed form such as
+// {"name": {"S": "value"}} used by table exports, stream records, and the AWS CLI. The item may be
+// a struct or map marshaled as with dynamodbattribute.MarshalMap, or an attribute map. The item is
+// written on a single line followed by a newline, so that items may be written as JSON lines.
+func MarshalDynamoJSON(item interface{}, w io.Writer) error {
+	attrMap, isAttrMap := item.(map[string]*dynamodb.AttributeValue)
+	if !isAttrMap {
+		var err error
+		attrMap, err = dynamodbattribute.MarshalMap(item)
+		if err != nil {
+			return err
+		}
+	}
+
+	document := make(map[string]interface{}, len(attrMap))
+	for name, av := range attrMap {
+		value, err := dynamoJSONValue(av)
+		if err != nil {
+			return fmt.Errorf("attribute \"%s\": %s", name, err.Error())
+		}
+		document[name] = value
+	}
+
+	return json.NewEncoder(w).Encode(document)
+}
+
+// UnmarshalDynamoJSON reads a single item in DynamoDB JSON from r into item, which must be a
+// non-nil pointer to a struct or map as with dynamodbattribute.UnmarshalMap, or to an attribute
+// map. Use DynamoJSONDecoder to read a stream of items, such as JSON lines.
+func UnmarshalDynamoJSON(r io.Reader, item interface{}) error {
+	return NewDynamoJSONDecoder(r).Decode(item)
+}
+
+// DynamoJSONDecoder reads successive items in DynamoDB JSON from a stream, such as the JSON lines
+// of a table export.
+type DynamoJSONDecoder struct {
+	decoder *json.Decoder
+}
+
+// NewDynamoJSONDecoder creates a new decoder of items in DynamoDB JSON read from r.
+func NewDynamoJSONDecoder(r io.Reader) *DynamoJSONDecoder {
+	return &DynamoJSONDecoder{decoder: json.NewDecoder(r)}
+}
+
+// Decode reads the next item into item, as with UnmarshalDynamoJSON. io.EOF is returned once no
+// items remain.
+func (d *DynamoJSONDecoder) Decode(item interface{}) error {
+	attrMap := map[string]*dynamodb.AttributeValue{}
+	if err := d.decoder.Decode(&attrMap); err != nil {
+		return err
+	}
+
+	if attrMapPtr, isAttrMap := item.(*map[string]*dynamodb.AttributeValue); isAttrMap {
+		*attrMapPtr = attrMap
+		return nil
+	}
+	return dynamodbattribute.UnmarshalMap(attrMap, item)
+}
+
+// dynamoJSONValue returns the DynamoDB JSON form of an attribute value, with only its set type.
+func dynamoJSONValue(av *dynamodb.AttributeValue) (map[string]interface{}, error) {
+	switch {
+	case av == nil:
+		return nil, fmt.Errorf("attribute value is nil")
+	case av.S != nil:
+		return map[string]interface{}{"S": *av.S}, nil
+	case av.N != nil:
+		return map[string]interface{}{"N": *av.N}, nil
+	case av.B != nil:
+		return map[string]interface{}{"B": av.B}, nil
+	case av.BOOL != nil:
+		return map[string]interface{}{"BOOL": *av.BOOL}, nil
+	case av.NULL != nil:
+		return map[string]interface{}{"NULL": *av.NULL}, nil
+	case av.SS != nil:
+		return map[string]interface{}{"SS": aws.StringValueSlice(av.SS)}, nil
+	case av.NS != nil:
+		return map[string]interface{}{"NS": aws.StringValueSlice(av.NS)}, nil
+	case av.BS != nil:
+		return map[string]interface{}{"BS": av.BS}, nil
+	case av.L != nil:
+		list := make([]interface{}, len(av.L))
+		for i, element := range av.L {
+			value, err := dynamoJSONValue(element)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = value
+		}
+		return map[string]interface{}{"L": list}, nil
+	case av.M != nil:
+		m := make(map[string]interface{}, len(av.M))
+		for name, element := range av.M {
+			value, err := dynamoJSONValue(element)
+			if err != nil {
+				return nil, err
+			}
+			m[name] = value
+		}
+		return map[string]interface{}{"M": m}, nil
+	}
+	return nil, fmt.Errorf("attribute value has no type")
+}
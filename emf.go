@@ -0,0 +1,98 @@
+package dynamodbfriend
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// EMFEmitter is a StatsEmitter that writes stats as CloudWatch Embedded Metric Format JSON lines.
+// When written to standard output in AWS Lambda, the metrics are extracted by CloudWatch without
+// additional infrastructure.
+type EMFEmitter struct {
+	Namespace string
+
+	mu     sync.Mutex
+	writer io.Writer
+}
+
+// NewEMFEmitter creates a new EMFEmitter writing metrics in the namespace to w.
+func NewEMFEmitter(w io.Writer, namespace string) *EMFEmitter {
+	return &EMFEmitter{
+		Namespace: namespace,
+		writer:    w,
+	}
+}
+
+type emfMetric struct {
+	Name string `json:"Name"`
+	Unit string `json:"Unit"`
+}
+
+type emfMetricDirective struct {
+	Namespace  string      `json:"Namespace"`
+	Dimensions [][]string  `json:"Dimensions"`
+	Metrics    []emfMetric `json:"Metrics"`
+}
+
+type emfMetadata struct {
+	Timestamp         int64                `json:"Timestamp"`
+	CloudWatchMetrics []emfMetricDirective `json:"CloudWatchMetrics"`
+}
+
+// EmitStats writes stats as a single EMF JSON line.
+func (e *EMFEmitter) EmitStats(stats OperationStats) {
+	throttles := 0
+	if stats.Throttled {
+		throttles = 1
+	}
+
+	record := map[string]interface{}{
+		"_aws": emfMetadata{
+			Timestamp: time.Now().UnixNano() / int64(time.Millisecond),
+			CloudWatchMetrics: []emfMetricDirective{{
+				Namespace:  e.Namespace,
+				Dimensions: [][]string{{"TableName", "Operation"}},
+				Metrics: []emfMetric{
+					{Name: "Latency", Unit: "Milliseconds"},
+					{Name: "ConsumedCapacity", Unit: "Count"},
+					{Name: "Items", Unit: "Count"},
+					{Name: "Throttles", Unit: "Count"},
+				},
+			}},
+		},
+		"TableName":        stats.TableName,
+		"Operation":        stats.Operation,
+		"Latency":          float64(stats.Latency) / float64(time.Millisecond),
+		"ConsumedCapacity": stats.ConsumedCapacity,
+		"Items":            stats.Items,
+		"Throttles":        throttles,
+	}
+	if stats.IndexName != "" {
+		record["IndexName"] = stats.IndexName
+	}
+	if stats.TotalSegments > 0 {
+		record["Segment"] = stats.Segment
+		record["TotalSegments"] = stats.TotalSegments
+	}
+	if stats.Canceled {
+		record["Canceled"] = true
+	}
+
+	// tags are included as properties rather than dimensions to avoid unbounded metric cardinality
+	for key, value := range stats.Tags {
+		if _, reserved := record[key]; !reserved {
+			record[key] = value
+		}
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.writer.Write(append(line, '\n'))
+}
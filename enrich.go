@@ -0,0 +1,201 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// LookupFn looks up the item with the specified key value in the named reference data and
+// unmarshals it into val, which must be a non-nil pointer. The return value is false if no item
+// has the key value. ReferenceSet.Lookup is a LookupFn, and enrichment functions may be tested by
+// substituting any other LookupFn.
+type LookupFn func(reference string, key interface{}, val interface{}) (bool, error)
+
+// EnrichFunc enriches an item read by a parser using reference data. The item is the pointer
+// given to the parser's Next method. Use EnrichFuncOf to receive items as their own type.
+type EnrichFunc func(item interface{}, lookup LookupFn) error
+
+// EnrichFuncOf adapts an enrichment function of items of type T into an EnrichFunc. The adapted
+// function returns an error for items that are not of type T.
+func EnrichFuncOf[T any](enrich func(item *T, lookup LookupFn) error) EnrichFunc {
+	return func(item interface{}, lookup LookupFn) error {
+		typedItem, ok := item.(*T)
+		if !ok {
+			return fmt.Errorf("enrichment of %T cannot be applied to %T", typedItem, item)
+		}
+		return enrich(typedItem, lookup)
+	}
+}
+
+type enrichment struct {
+	enrich EnrichFunc
+	lookup LookupFn
+}
+
+// Enrich applies an enrichment function to each subsequent item read by the parser, after it is
+// unmarshaled. Enrichments are applied in the order added, and an error from an enrichment is
+// returned by the parser.
+func (parser *QueryParser) Enrich(lookup LookupFn, enrich EnrichFunc) *QueryParser {
+	parser.enrichments = append(parser.enrichments, enrichment{enrich: enrich, lookup: lookup})
+	return parser
+}
+
+// Enrich applies an enrichment function to each subsequent item read by the parser, after it is
+// unmarshaled. Enrichments are applied in the order added, and an error from an enrichment is
+// returned by the parser.
+func (parser *ScanParser) Enrich(lookup LookupFn, enrich EnrichFunc) *ScanParser {
+	parser.enrichments = append(parser.enrichments, enrichment{enrich: enrich, lookup: lookup})
+	return parser
+}
+
+// Enrich applies an enrichment function to each subsequent item read by the parser, after it is
+// unmarshaled. Enrichments are applied in the order added, and an error from an enrichment is
+// returned by the parser.
+func (p *QueryParserOf[T]) Enrich(lookup LookupFn,
+	enrich func(item *T, lookup LookupFn) error) *QueryParserOf[T] {
+
+	p.parser.Enrich(lookup, EnrichFuncOf(enrich))
+	return p
+}
+
+func applyEnrichments(enrichments []enrichment, item interface{}) error {
+	for _, e := range enrichments {
+		if err := e.enrich(item, e.lookup); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReferenceSet holds small reference tables in memory for enrichment of parser results. A
+// reference set is safe for concurrent use, so that data may be reloaded while it is in use.
+type ReferenceSet struct {
+	mu         sync.RWMutex
+	references map[string]*referenceData
+}
+
+type referenceData struct {
+	table *Table
+	items map[string]map[string]*dynamodb.AttributeValue
+}
+
+// NewReferenceSet instantiates an empty reference set.
+func NewReferenceSet() *ReferenceSet {
+	return &ReferenceSet{
+		references: map[string]*referenceData{},
+	}
+}
+
+// ErrReferenceNotLoaded is returned when looking up an item in reference data that has not been
+// loaded into a reference set.
+type ErrReferenceNotLoaded struct {
+	Reference string
+}
+
+func (e ErrReferenceNotLoaded) Error() string {
+	return fmt.Sprintf("reference data \"%s\" has not been loaded", e.Reference)
+}
+
+// Load reads all items of a table into memory as the named reference data, indexed by the value
+// of keyAttribute. Items without a string, number or binary value for the attribute are skipped,
+// and items sharing a key value replace earlier items. Previously loaded data of the same name is
+// replaced once loading succeeds. Loading scans the entire table, so it is intended for small
+// tables that change infrequently.
+func (set *ReferenceSet) Load(ctx context.Context, name string, table *Table,
+	keyAttribute string) error {
+
+	parser, err := table.Scan(ctx, NewScan())
+	if err != nil {
+		return err
+	}
+	defer parser.Close()
+
+	reference := &referenceData{
+		table: table,
+		items: map[string]map[string]*dynamodb.AttributeValue{},
+	}
+	for {
+		storedItem, err := parser.nextStoredItem(ctx)
+		if _, parsingComplete := err.(ErrParsingComplete); parsingComplete {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		item, err := table.itemFromStore(storedItem)
+		if err != nil {
+			return err
+		}
+		table.stripRestrictedAttributes(ctx, item)
+
+		if key, ok := referenceKey(item[keyAttribute]); ok {
+			reference.items[key] = item
+		}
+	}
+
+	table.logger.Printf("loaded %d items of reference data \"%s\" from table \"%s\"\n",
+		len(reference.items), name, table.Name)
+
+	set.mu.Lock()
+	set.references[name] = reference
+	set.mu.Unlock()
+	return nil
+}
+
+// Lookup looks up the item with the specified key value in the named reference data and
+// unmarshals it into val, which must be a non-nil pointer. The return value is false if no item
+// has the key value.
+func (set *ReferenceSet) Lookup(reference string, key interface{}, val interface{}) (bool, error) {
+	set.mu.RLock()
+	data, found := set.references[reference]
+	set.mu.RUnlock()
+	if !found {
+		return false, ErrReferenceNotLoaded{Reference: reference}
+	}
+
+	av, err := dynamodbattribute.Marshal(key)
+	if err != nil {
+		return false, err
+	}
+	referenceKey, ok := referenceKey(av)
+	if !ok {
+		return false, fmt.Errorf("reference key must be a string, number or binary value")
+	}
+
+	item, found := data.items[referenceKey]
+	if !found {
+		return false, nil
+	}
+	return true, data.table.unmarshalItem(item, val)
+}
+
+// Len returns the number of items in the named reference data.
+func (set *ReferenceSet) Len(reference string) int {
+	set.mu.RLock()
+	defer set.mu.RUnlock()
+	if data, found := set.references[reference]; found {
+		return len(data.items)
+	}
+	return 0
+}
+
+// referenceKey returns the value of a key attribute as a map key. Values of different types are
+// kept distinct.
+func referenceKey(av *dynamodb.AttributeValue) (string, bool) {
+	switch {
+	case av == nil:
+		return "", false
+	case av.S != nil:
+		return "S:" + aws.StringValue(av.S), true
+	case av.N != nil:
+		return "N:" + aws.StringValue(av.N), true
+	case av.B != nil:
+		return "B:" + string(av.B), true
+	}
+	return "", false
+}
@@ -0,0 +1,97 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+// ErrConcurrentAppend is returned by AppendEvent when an event has already been appended to the
+// stream with the same sequence number, such as by a concurrent writer.
+type ErrConcurrentAppend struct {
+	TableName string
+	StreamKey string
+	Sequence  int64
+}
+
+func (e ErrConcurrentAppend) Error() string {
+	return fmt.Sprintf("event %d already exists in stream \"%s\" of table \"%s\"",
+		e.Sequence, e.StreamKey, e.TableName)
+}
+
+// AppendEvent appends an event to an event stream stored in the table. The table must have a
+// composite primary key with a number sort key. The event is written with the table's partition
+// key set to streamKey and sort key set to expectedSeq+1, where expectedSeq is the sequence number
+// of the last event known to the caller, or 0 for a new stream. ErrConcurrentAppend is returned if
+// an event with that sequence number already exists, in which case the caller should replay the
+// stream and retry.
+func (table *Table) AppendEvent(ctx context.Context, streamKey string, expectedSeq int64,
+	event interface{}) error {
+
+	if err := table.loadIndexMetadata(ctx); err != nil {
+		return err
+	}
+	primaryIndex := table.allIndexes()[tablePrimaryIndexName]
+	if !primaryIndex.IsComposite {
+		err := fmt.Errorf("event streams require a sort key on table \"%s\"", table.Name)
+		table.logger.Printf("error: %s\n", err.Error())
+		return err
+	}
+
+	attrMap, err := table.encodeItem(event)
+	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
+		return err
+	}
+
+	if err := table.checkProtectedAttributes(attrMap); err != nil {
+		return err
+	}
+
+	sequence := expectedSeq + 1
+	attrMap[primaryIndex.PartitionKey] = &dynamodb.AttributeValue{S: aws.String(streamKey)}
+	attrMap[primaryIndex.SortKey] = &dynamodb.AttributeValue{
+		N: aws.String(fmt.Sprint(sequence)),
+	}
+
+	condition := expression.AttributeNotExists(expression.Name(primaryIndex.SortKey))
+	err = table.putItem(ctx, attrMap, &condition, AuditOperationPut)
+	if awsErr, isAWSErr := err.(awserr.Error); isAWSErr &&
+		awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+
+		err = ErrConcurrentAppend{
+			TableName: table.Name,
+			StreamKey: streamKey,
+			Sequence:  sequence,
+		}
+		table.logger.Printf("error: %s\n", err.Error())
+	}
+
+	return err
+}
+
+// ReadEvents returns a parser over the events of a stream appended with AppendEvent, in order of
+// sequence number, starting after the sequence number afterSeq. Use 0 to replay the entire stream.
+func (table *Table) ReadEvents(ctx context.Context, streamKey string,
+	afterSeq int64) (*QueryParser, error) {
+
+	if err := table.loadIndexMetadata(ctx); err != nil {
+		return nil, err
+	}
+	primaryIndex := table.allIndexes()[tablePrimaryIndexName]
+	if !primaryIndex.IsComposite {
+		err := fmt.Errorf("event streams require a sort key on table \"%s\"", table.Name)
+		table.logger.Printf("error: %s\n", err.Error())
+		return nil, err
+	}
+
+	expr := NewQuery(primaryIndex.PartitionKey).Equals(streamKey).
+		And(primaryIndex.SortKey).GreaterThan(afterSeq).
+		OrderAscending(primaryIndex.SortKey)
+
+	return table.Query(ctx, expr)
+}
@@ -0,0 +1,235 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"sync"
+)
+
+// defaultExecutorConcurrency is the number of tasks run concurrently by an executor by default.
+const defaultExecutorConcurrency = 8
+
+// ExecutorOptions configures an Executor.
+type ExecutorOptions struct {
+	// Concurrency is the maximum number of tasks run at once. Defaults to 8.
+	Concurrency int
+
+	// CapacityPerSecond is the capacity units per second shared by all tasks. Tasks are not started
+	// while the capacity consumed by requests of executor tables exceeds the budget. Zero is
+	// unlimited.
+	CapacityPerSecond float64
+
+	// MaxRetries is the number of times a task failing with a throttling error is retried with
+	// exponential backoff. Defaults to 8, and a negative value disables retries.
+	MaxRetries int
+
+	// StatsEmitter receives the stats of requests made by executor tables. Defaults to the stats
+	// emitter of the client.
+	StatsEmitter StatsEmitter
+}
+
+// ExecutorTask is an independent unit of work run by an Executor, such as a query or write.
+type ExecutorTask func(ctx context.Context) error
+
+// ExecutorResult is the outcome of a task run by an Executor.
+type ExecutorResult struct {
+	// Task is the index of the task, in the order submitted.
+	Task int
+
+	// Attempts is the number of times the task was run, or zero if it was not run.
+	Attempts int
+
+	Err error
+}
+
+// Executor runs many independent tasks with bounded concurrency, a shared capacity budget, and a
+// shared retry policy for throttled tasks. Results are delivered as tasks complete.
+type Executor struct {
+	client *Client
+	opts   ExecutorOptions
+
+	// limiter spaces tasks to stay within the capacity budget, counting capacity once consumed
+	limiter *rateLimiter
+
+	mu     sync.Mutex
+	queue  []queuedTask
+	next   int
+	wake   chan struct{}
+	closed chan struct{}
+	once   sync.Once
+}
+
+type queuedTask struct {
+	index int
+	task  ExecutorTask
+}
+
+// NewExecutor creates a new Executor for tasks on tables of the client.
+func (client *Client) NewExecutor(opts ExecutorOptions) *Executor {
+	if opts.Concurrency < 1 {
+		opts.Concurrency = defaultExecutorConcurrency
+	}
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = batchMaxRetries
+	}
+	if opts.StatsEmitter == nil {
+		opts.StatsEmitter = client.statsEmitter
+	}
+
+	return &Executor{
+		client:  client,
+		opts:    opts,
+		limiter: newRateLimiter(opts.CapacityPerSecond),
+		wake:    make(chan struct{}, 1),
+		closed:  make(chan struct{}),
+	}
+}
+
+// Table instantiates a table of the executor's client whose consumed capacity is counted against
+// the executor's capacity budget. Tasks should use executor tables for the budget to apply.
+func (executor *Executor) Table(tableName string) *Table {
+	return executor.client.Table(tableName).WithStatsEmitter(executor)
+}
+
+// EmitStats counts the consumed capacity of a request against the capacity budget and forwards
+// the stats to the executor's stats emitter, if any.
+func (executor *Executor) EmitStats(stats OperationStats) {
+	executor.limiter.reserveN(stats.ConsumedCapacity)
+	if executor.opts.StatsEmitter != nil {
+		executor.opts.StatsEmitter.EmitStats(stats)
+	}
+}
+
+// Submit adds a task to be run and returns its index. Tasks may be submitted before or while the
+// executor is running, until the executor is closed.
+func (executor *Executor) Submit(task ExecutorTask) int {
+	executor.mu.Lock()
+	index := executor.next
+	executor.next++
+	executor.queue = append(executor.queue, queuedTask{index: index, task: task})
+	executor.mu.Unlock()
+
+	executor.notify()
+	return index
+}
+
+// Close indicates that no more tasks will be submitted. The results channel returned by Run is
+// closed once all submitted tasks have completed.
+func (executor *Executor) Close() {
+	executor.once.Do(func() {
+		close(executor.closed)
+	})
+}
+
+// Run starts running submitted tasks and returns a channel receiving the result of each task as it
+// completes. The channel is closed once the executor is closed and all tasks have completed, or
+// once ctx is canceled. If ctx is canceled, queued tasks are completed with the context error
+// without being run. The results channel must be drained for tasks to continue to be run.
+func (executor *Executor) Run(ctx context.Context) <-chan ExecutorResult {
+	results := make(chan ExecutorResult)
+
+	var wg sync.WaitGroup
+	for i := 0; i < executor.opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			executor.work(ctx, results)
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
+
+// work runs queued tasks until the executor is closed and no tasks remain. Once ctx is canceled,
+// queued tasks are completed with the context error without being run.
+func (executor *Executor) work(ctx context.Context, results chan<- ExecutorResult) {
+	for {
+		queued, found := executor.dequeue()
+		if found {
+			results <- executor.runTask(ctx, queued)
+			continue
+		}
+
+		select {
+		case <-executor.wake:
+		case <-ctx.Done():
+			for {
+				queued, found := executor.dequeue()
+				if !found {
+					return
+				}
+				results <- ExecutorResult{Task: queued.index, Err: ctx.Err()}
+			}
+		case <-executor.closed:
+			// tasks may have been submitted just before closing
+			if queued, found := executor.dequeue(); found {
+				results <- executor.runTask(ctx, queued)
+				continue
+			}
+			return
+		}
+	}
+}
+
+func (executor *Executor) dequeue() (queuedTask, bool) {
+	executor.mu.Lock()
+	defer executor.mu.Unlock()
+
+	if len(executor.queue) == 0 {
+		return queuedTask{}, false
+	}
+	queued := executor.queue[0]
+	executor.queue = executor.queue[1:]
+
+	// pass the wake up along to another worker while tasks remain
+	if len(executor.queue) > 0 {
+		executor.notify()
+	}
+	return queued, true
+}
+
+func (executor *Executor) notify() {
+	select {
+	case executor.wake <- struct{}{}:
+	default:
+	}
+}
+
+// runTask runs a task within the capacity budget, retrying throttled attempts with backoff.
+func (executor *Executor) runTask(ctx context.Context, queued queuedTask) ExecutorResult {
+	result := ExecutorResult{Task: queued.index}
+	logger := executor.client.getLogger()
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			logger.Printf("retrying throttled task %d\n", queued.index)
+			if err := sleepBackoff(ctx, attempt); err != nil {
+				result.Err = err
+				return result
+			}
+		}
+
+		if err := ctx.Err(); err != nil {
+			result.Err = err
+			return result
+		}
+		// wait for capacity consumed by earlier requests to be within the budget
+		if err := executor.limiter.waitN(ctx, 0); err != nil {
+			result.Err = err
+			return result
+		}
+
+		result.Attempts++
+		result.Err = queued.task(ctx)
+		if !isThrottleError(result.Err) || attempt >= executor.opts.MaxRetries {
+			if result.Err != nil {
+				logger.Printf("error: %s\n", result.Err.Error())
+			}
+			return result
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestExecutorConcurrentSubmissions(t *testing.T) {
+	cases := []struct {
+		name   string
+		cancel bool
+	}{
+		{name: "all tasks run"},
+		{name: "canceled while running", cancel: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			const concurrency, submitters, tasksPerSubmitter = 4, 4, 16
+
+			fake := newFakeDynamoDB("items", "id", "")
+			fake.putItems(stringItem(map[string]string{"id": "a"}))
+			executor := NewClient(fake).NewExecutor(ExecutorOptions{Concurrency: concurrency})
+			table := executor.Table("items")
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var running, maxRunning int32
+			task := func(ctx context.Context) error {
+				current := atomic.AddInt32(&running, 1)
+				defer atomic.AddInt32(&running, -1)
+				for {
+					observed := atomic.LoadInt32(&maxRunning)
+					if current <= observed ||
+						atomic.CompareAndSwapInt32(&maxRunning, observed, current) {
+						break
+					}
+				}
+				var item map[string]string
+				err := table.Get(ctx, map[string]string{"id": "a"}, &item)
+				time.Sleep(time.Millisecond)
+				return err
+			}
+
+			results := executor.Run(ctx)
+
+			// tasks are submitted from several goroutines while the executor runs
+			var wg sync.WaitGroup
+			for i := 0; i < submitters; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for j := 0; j < tasksPerSubmitter; j++ {
+						executor.Submit(task)
+					}
+				}()
+			}
+			go func() {
+				wg.Wait()
+				executor.Close()
+			}()
+
+			seen := map[int]bool{}
+			for result := range results {
+				if seen[result.Task] {
+					t.Errorf("task %d completed more than once", result.Task)
+				}
+				seen[result.Task] = true
+
+				if tc.cancel && len(seen) == concurrency {
+					cancel()
+				} else if !tc.cancel && result.Err != nil {
+					t.Errorf("unexpected error of task %d: %s", result.Task, result.Err)
+				}
+			}
+
+			if !tc.cancel && len(seen) != submitters*tasksPerSubmitter {
+				t.Errorf("expected %d results, got %d", submitters*tasksPerSubmitter, len(seen))
+			}
+			if maxRunning > concurrency {
+				t.Errorf("expected at most %d tasks running, got %d", concurrency, maxRunning)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+// Exists returns true if an item with the specified key exists in the table. The key may be a
+// struct or map containing the table's primary key attributes. Only key attributes are read.
+// Soft-deleted and expired items are reported as not existing when those features are enabled.
+// Exists uses a consistent read if consistent reads are the table's default.
+func (table *Table) Exists(ctx context.Context, key interface{}) (bool, error) {
+	keyMap, err := table.marshalStoredKey(ctx, key)
+	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
+		return false, err
+	}
+
+	// project only attributes needed to determine existence
+	projectionNames := table.allIndexes()[tablePrimaryIndexName].getKeys()
+	if table.softDeleteAttribute != "" {
+		projectionNames = append(projectionNames, table.softDeleteAttribute)
+	}
+	if table.excludeExpiredItems {
+		if err := table.loadTTLMetadata(ctx); err != nil {
+			return false, err
+		}
+		if table.ttlAttribute != "" {
+			projectionNames = append(projectionNames, table.ttlAttribute)
+		}
+	}
+
+	names := []expression.NameBuilder{}
+	for _, name := range projectionNames {
+		names = append(names, expression.Name(name))
+	}
+	dbExpr, err := expression.NewBuilder().
+		WithProjection(expression.NamesList(names[0], names[1:]...)).Build()
+	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
+		return false, err
+	}
+
+	getInput := &dynamodb.GetItemInput{
+		TableName:                aws.String(table.Name),
+		Key:                      keyMap,
+		ProjectionExpression:     dbExpr.Projection(),
+		ExpressionAttributeNames: dbExpr.Names(),
+		ReturnConsumedCapacity:   table.returnConsumedCapacity(),
+	}
+
+	readClient := table.baseClient
+	if table.consistentReads {
+		getInput.ConsistentRead = aws.Bool(true)
+	} else {
+		readClient = table.readClient(ctx)
+	}
+
+	start := time.Now()
+	getOutput, err := readClient.GetItemWithContext(ctx, getInput)
+
+	stats := OperationStats{
+		Operation: "GetItem",
+		Latency:   time.Since(start),
+		Err:       err,
+	}
+	if getOutput != nil {
+		stats.ConsumedCapacity = consumedCapacityUnits(getOutput.ConsumedCapacity)
+		if len(getOutput.Item) > 0 {
+			stats.Items = 1
+		}
+	}
+	table.emitStats(ctx, stats)
+
+	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
+		return false, err
+	}
+
+	item := getOutput.Item
+	return len(item) > 0 && !table.isSoftDeleted(item) && !table.isExpired(item), nil
+}
+
+// QueryExists returns true if the query returns at least one item. Only key and condition
+// attributes are read.
+func (table *Table) QueryExists(ctx context.Context, expr *QueryExpr) (bool, error) {
+	if err := table.loadIndexMetadata(ctx); err != nil {
+		return false, err
+	}
+
+	// the table's primary key is projected by all indexes, including for raw key conditions
+	existsExpr := *expr
+	existsExpr.attributesSpecified = true
+	attributes := newNameSet(table.allIndexes()[tablePrimaryIndexName].getKeys()...)
+	attributes.Insert(expr.filterGroupAttributes()...)
+	for key := range expr.filters {
+		attributes.Insert(key)
+	}
+	existsExpr.attributes = attributes.Names()
+	sort.Strings(existsExpr.attributes)
+
+	parser, err := table.queryWithPageSize(ctx, &existsExpr, 1)
+	if err != nil {
+		return false, err
+	}
+	defer parser.Close()
+
+	var item map[string]interface{}
+	err = parser.Next(ctx, &item)
+	if _, parsingComplete := err.(ErrParsingComplete); parsingComplete {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (table *Table) isSoftDeleted(item map[string]*dynamodb.AttributeValue) bool {
+	if table.softDeleteAttribute == "" {
+		return false
+	}
+	_, found := item[table.softDeleteAttribute]
+	return found
+}
+
+// isExpired returns true if the item's time to live has passed. Time to live metadata must
+// already be loaded.
+func (table *Table) isExpired(item map[string]*dynamodb.AttributeValue) bool {
+	if !table.excludeExpiredItems || table.ttlAttribute == "" {
+		return false
+	}
+
+	av, found := item[table.ttlAttribute]
+	if !found || av.N == nil {
+		return false
+	}
+
+	expiresAt, err := strconv.ParseFloat(*av.N, 64)
+	if err != nil {
+		return false
+	}
+
+	return int64(expiresAt) <= time.Now().Unix()
+}
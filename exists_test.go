@@ -0,0 +1,95 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+func TestQueryExists(t *testing.T) {
+	keyCondition := func() *QueryExpr {
+		return NewQueryWithKeyCondition(expression.Key("id").Equal(expression.Value("a")), "")
+	}
+
+	matching := []map[string]*dynamodb.AttributeValue{
+		stringItem(map[string]string{"id": "a", "ts": "1"}),
+	}
+
+	cases := []struct {
+		name       string
+		expr       *QueryExpr
+		queryItems []map[string]*dynamodb.AttributeValue
+		expect     bool
+	}{
+		{
+			name:       "key condition with matching item",
+			expr:       keyCondition(),
+			queryItems: matching,
+			expect:     true,
+		},
+		{
+			name: "key condition without matching items",
+			expr: keyCondition(),
+		},
+		{
+			name:       "conditions with matching item",
+			expr:       NewQuery("id").Equals("a").And("status").Equals("open"),
+			queryItems: matching,
+			expect:     true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := newFakeDynamoDB("items", "id", "ts")
+			fake.queryItems = tc.queryItems
+			table := newFakeTable(fake)
+
+			exists, err := table.QueryExists(context.Background(), tc.expr)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			} else if exists != tc.expect {
+				t.Errorf("expected %t, got %t", tc.expect, exists)
+			}
+
+			if len(fake.queryInputs) != 1 {
+				t.Fatalf("expected 1 query, got %d", len(fake.queryInputs))
+			} else if fake.queryInputs[0].ProjectionExpression == nil {
+				t.Errorf("expected query to project only key and condition attributes")
+			}
+		})
+	}
+}
+
+func TestExistsReadConsistency(t *testing.T) {
+	cases := []struct {
+		name            string
+		consistentReads bool
+	}{
+		{name: "eventually consistent default", consistentReads: false},
+		{name: "consistent default", consistentReads: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := newFakeDynamoDB("items", "id", "")
+			fake.putItems(stringItem(map[string]string{"id": "a"}))
+			table := newFakeTable(fake).WithConsistentReads(tc.consistentReads)
+
+			exists, err := table.Exists(context.Background(), map[string]string{"id": "a"})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			} else if !exists {
+				t.Errorf("expected item to exist")
+			}
+
+			consistent := aws.BoolValue(fake.getInputs[0].ConsistentRead)
+			if consistent != tc.consistentReads {
+				t.Errorf("expected consistent read %t, got %t", tc.consistentReads, consistent)
+			}
+		})
+	}
+}
@@ -0,0 +1,326 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// ExportType is the column type of an exported attribute.
+type ExportType int
+
+// Column types of exported attributes, corresponding to the Arrow types of the same names.
+const (
+	ExportString ExportType = iota
+	ExportInt64
+	ExportFloat64
+	ExportBool
+	ExportBinary
+)
+
+func (t ExportType) String() string {
+	switch t {
+	case ExportString:
+		return "string"
+	case ExportInt64:
+		return "int64"
+	case ExportFloat64:
+		return "float64"
+	case ExportBool:
+		return "bool"
+	case ExportBinary:
+		return "binary"
+	}
+	return "unknown"
+}
+
+func (t ExportType) arrowType() arrow.DataType {
+	switch t {
+	case ExportInt64:
+		return arrow.PrimitiveTypes.Int64
+	case ExportFloat64:
+		return arrow.PrimitiveTypes.Float64
+	case ExportBool:
+		return arrow.FixedWidthTypes.Boolean
+	case ExportBinary:
+		return arrow.BinaryTypes.Binary
+	}
+	return arrow.BinaryTypes.String
+}
+
+// ExportField declares a column of an export schema. Name is the top-level attribute exported to
+// the column. Items missing the attribute or with a null value are exported as null if the field
+// is nullable, and are otherwise rejected.
+type ExportField struct {
+	Name     string
+	Type     ExportType
+	Nullable bool
+}
+
+// ExportSchema declares the columns of exported record batches.
+type ExportSchema struct {
+	Fields []ExportField
+}
+
+// ArrowSchema returns the Arrow schema of record batches exported with the schema.
+func (schema ExportSchema) ArrowSchema() *arrow.Schema {
+	fields := make([]arrow.Field, len(schema.Fields))
+	for i, field := range schema.Fields {
+		fields[i] = arrow.Field{
+			Name:     field.Name,
+			Type:     field.Type.arrowType(),
+			Nullable: field.Nullable,
+		}
+	}
+	return arrow.NewSchema(fields, nil)
+}
+
+// RecordBatchWriter receives Arrow record batches from an Exporter. The record is released once
+// WriteBatch returns, so it must be retained to be used afterwards.
+type RecordBatchWriter interface {
+	WriteBatch(ctx context.Context, record arrow.Record) error
+}
+
+// ArrowWriter writes record batches as an Arrow IPC stream.
+type ArrowWriter struct {
+	writer *ipc.Writer
+}
+
+// NewArrowWriter instantiates a writer of record batches with the schema as an Arrow IPC stream
+// to w. The writer must be closed to end the stream.
+func NewArrowWriter(w io.Writer, schema ExportSchema) *ArrowWriter {
+	return &ArrowWriter{writer: ipc.NewWriter(w, ipc.WithSchema(schema.ArrowSchema()))}
+}
+
+// WriteBatch writes a record batch as a message of the stream.
+func (w *ArrowWriter) WriteBatch(ctx context.Context, record arrow.Record) error {
+	return w.writer.Write(record)
+}
+
+// Close ends the stream. The underlying writer is not closed.
+func (w *ArrowWriter) Close() error {
+	return w.writer.Close()
+}
+
+// ParquetWriter writes record batches as row groups of a Parquet file.
+type ParquetWriter struct {
+	writer *pqarrow.FileWriter
+}
+
+// NewParquetWriter instantiates a writer of record batches with the schema as a Parquet file
+// written to w. The writer must be closed to write the file footer.
+func NewParquetWriter(w io.Writer, schema ExportSchema) (*ParquetWriter, error) {
+	writer, err := pqarrow.NewFileWriter(schema.ArrowSchema(), w, nil,
+		pqarrow.DefaultWriterProps())
+	if err != nil {
+		return nil, err
+	}
+	return &ParquetWriter{writer: writer}, nil
+}
+
+// WriteBatch writes a record batch as a row group of the file.
+func (w *ParquetWriter) WriteBatch(ctx context.Context, record arrow.Record) error {
+	return w.writer.Write(record)
+}
+
+// Close writes the file footer and closes w if it is an io.Closer.
+func (w *ParquetWriter) Close() error {
+	return w.writer.Close()
+}
+
+const defaultExportBatchSize = 1024
+
+// Exporter converts items into Arrow record batches with a declared schema.
+type Exporter struct {
+	schema    ExportSchema
+	batchSize int
+	writer    RecordBatchWriter
+
+	buildErr error
+}
+
+// NewExporter instantiates an exporter writing record batches with the schema to writer, such as
+// an ArrowWriter or ParquetWriter.
+func NewExporter(schema ExportSchema, writer RecordBatchWriter) *Exporter {
+	return &Exporter{
+		schema:    schema,
+		batchSize: defaultExportBatchSize,
+		writer:    writer,
+	}
+}
+
+// WithBatchSize sets the maximum number of rows per record batch. The default is 1024. The rows
+// must be positive, and otherwise an error is returned when items are exported.
+func (exporter *Exporter) WithBatchSize(rows int) *Exporter {
+	if rows <= 0 {
+		exporter.buildErr = fmt.Errorf("export batch size must be positive, got %d", rows)
+		return exporter
+	}
+	exporter.batchSize = rows
+	return exporter
+}
+
+// ErrExportSchemaMismatch is returned when an exported item's attribute does not match the type
+// declared by the export schema.
+type ErrExportSchemaMismatch struct {
+	Attribute string
+	Type      ExportType
+}
+
+func (e ErrExportSchemaMismatch) Error() string {
+	return fmt.Sprintf("attribute \"%s\" cannot be exported as %s", e.Attribute, e.Type)
+}
+
+// ExportQuery exports all remaining items of a query parser, returning the number of rows
+// exported. Attribute values are read directly from the query results, so that numbers retain
+// their full precision, and the parser's table-level transformations and attribute read policy
+// are applied. A final partial batch is written once the parser is exhausted.
+func (exporter *Exporter) ExportQuery(ctx context.Context, parser *QueryParser) (int64, error) {
+	if exporter.buildErr != nil {
+		parser.expr.logger.Printf("error: %s\n", exporter.buildErr.Error())
+		return 0, exporter.buildErr
+	}
+
+	builder := array.NewRecordBuilder(memory.DefaultAllocator, exporter.schema.ArrowSchema())
+	defer builder.Release()
+
+	var rows int64
+	batchRows := 0
+	for {
+		storedItem, err := parser.nextStoredItem(ctx)
+		if _, complete := err.(ErrParsingComplete); complete {
+			break
+		} else if err != nil {
+			return rows, err
+		} else if parser.table.isCounterItem(storedItem) {
+			continue
+		}
+
+		item, err := parser.table.itemFromStore(storedItem)
+		if err != nil {
+			return rows, err
+		}
+		parser.table.stripRestrictedAttributes(ctx, item)
+
+		values, err := exporter.rowValues(item)
+		if err != nil {
+			parser.expr.logger.Printf("error: %s\n", err.Error())
+			return rows, err
+		}
+		appendRow(builder, values)
+		batchRows++
+
+		if batchRows == exporter.batchSize {
+			if err := exporter.writeBatch(ctx, builder); err != nil {
+				return rows, err
+			}
+			rows += int64(batchRows)
+			batchRows = 0
+		}
+	}
+
+	if batchRows > 0 {
+		if err := exporter.writeBatch(ctx, builder); err != nil {
+			return rows, err
+		}
+		rows += int64(batchRows)
+	}
+
+	return rows, nil
+}
+
+// writeBatch writes the rows appended to the builder as a record batch, resetting the builder.
+func (exporter *Exporter) writeBatch(ctx context.Context, builder *array.RecordBuilder) error {
+	record := builder.NewRecord()
+	defer record.Release()
+	return exporter.writer.WriteBatch(ctx, record)
+}
+
+// rowValues converts an item to the values of a row, so that an item not matching the schema is
+// rejected before any of its values are appended.
+func (exporter *Exporter) rowValues(
+	item map[string]*dynamodb.AttributeValue) ([]interface{}, error) {
+
+	values := make([]interface{}, len(exporter.schema.Fields))
+	for i, field := range exporter.schema.Fields {
+		value, err := exportValue(field, item[field.Name])
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// appendRow appends the values of a row to the builder's columns, with nil values as nulls.
+func appendRow(builder *array.RecordBuilder, values []interface{}) {
+	for i, value := range values {
+		field := builder.Field(i)
+		if value == nil {
+			field.AppendNull()
+			continue
+		}
+
+		switch b := field.(type) {
+		case *array.StringBuilder:
+			b.Append(value.(string))
+		case *array.Int64Builder:
+			b.Append(value.(int64))
+		case *array.Float64Builder:
+			b.Append(value.(float64))
+		case *array.BooleanBuilder:
+			b.Append(value.(bool))
+		case *array.BinaryBuilder:
+			b.Append(value.([]byte))
+		}
+	}
+}
+
+// exportValue converts an attribute value to the field's column type, or nil for a null value.
+func exportValue(field ExportField, av *dynamodb.AttributeValue) (interface{}, error) {
+	mismatch := ErrExportSchemaMismatch{Attribute: field.Name, Type: field.Type}
+
+	if av == nil || aws.BoolValue(av.NULL) {
+		if !field.Nullable {
+			return nil, mismatch
+		}
+		return nil, nil
+	}
+
+	switch field.Type {
+	case ExportString:
+		if av.S != nil {
+			return *av.S, nil
+		}
+	case ExportInt64:
+		if av.N != nil {
+			if value, err := strconv.ParseInt(*av.N, 10, 64); err == nil {
+				return value, nil
+			}
+		}
+	case ExportFloat64:
+		if av.N != nil {
+			if value, err := strconv.ParseFloat(*av.N, 64); err == nil {
+				return value, nil
+			}
+		}
+	case ExportBool:
+		if av.BOOL != nil {
+			return *av.BOOL, nil
+		}
+	case ExportBinary:
+		if av.B != nil {
+			return av.B, nil
+		}
+	}
+	return nil, mismatch
+}
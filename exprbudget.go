@@ -0,0 +1,189 @@
+package dynamodbfriend
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DynamoDB limits on expressions.
+const (
+	maxExpressionBytes     = 4096
+	maxExpressionOperators = 300
+	maxInOperands          = 100
+)
+
+// maxProjectionAttributes is the maximum number of attributes of a projection expression, matching
+// the limit on attributes projected into secondary indexes.
+const maxProjectionAttributes = 100
+
+var (
+	expressionOperatorPattern = regexp.MustCompile(
+		`\b(AND|OR|NOT|BETWEEN|IN)\b|<>|<=|>=|=|<|>|` +
+			`\b(attribute_exists|attribute_not_exists|attribute_type|begins_with|contains|size|` +
+			`if_not_exists|list_append)\(`)
+	expressionBetweenPattern = regexp.MustCompile(`\bBETWEEN\b`)
+	expressionInPattern      = regexp.MustCompile(`\bIN\s*\(([^)]*)\)`)
+)
+
+// ErrExpressionLimitExceeded is returned when a built expression exceeds a DynamoDB limit on
+// expressions, before the request is sent. Condition is the offending condition of the expression,
+// with attribute names resolved, or empty if the limit applies to the expression as a whole.
+type ErrExpressionLimitExceeded struct {
+	TableName  string
+	Expression string
+	Limit      string
+	Max        int
+	Actual     int
+	Condition  string
+}
+
+func (e ErrExpressionLimitExceeded) Error() string {
+	msg := fmt.Sprintf("%s expression on table \"%s\" has %d %s, exceeding the limit of %d",
+		e.Expression, e.TableName, e.Actual, e.Limit, e.Max)
+	if e.Condition != "" {
+		msg += fmt.Sprintf("; offending condition: %s", e.Condition)
+	}
+	return msg
+}
+
+// expressionPart is a single built expression of a request, such as a filter or projection.
+type expressionPart struct {
+	kind       string
+	expression *string
+}
+
+// validateExpressions checks built expressions of a request against DynamoDB limits on expression
+// size, number of operators and functions, number of IN operands, and number of projected
+// attributes. Names resolves expression attribute name placeholders for error reporting.
+func (table *Table) validateExpressions(names map[string]*string, parts ...expressionPart) error {
+	for _, part := range parts {
+		if part.expression == nil {
+			continue
+		}
+		expr := *part.expression
+
+		exceeded := func(limit string, max, actual int, condition string) error {
+			err := ErrExpressionLimitExceeded{
+				TableName:  table.Name,
+				Expression: part.kind,
+				Limit:      limit,
+				Max:        max,
+				Actual:     actual,
+			}
+			if condition != "" {
+				err.Condition = table.describeExpression(&condition, names, nil)
+			}
+			table.logger.Printf("error: %s\n", err.Error())
+			return err
+		}
+
+		if part.kind == "projection" {
+			attributes := len(strings.Split(expr, ","))
+			if attributes > maxProjectionAttributes {
+				return exceeded("attributes", maxProjectionAttributes, attributes, "")
+			}
+			if len(expr) > maxExpressionBytes {
+				return exceeded("bytes", maxExpressionBytes, len(expr), "")
+			}
+			continue
+		}
+
+		conditions := splitConditions(expr)
+
+		if len(expr) > maxExpressionBytes {
+			return exceeded("bytes", maxExpressionBytes, len(expr),
+				largestCondition(conditions, func(condition string) int {
+					return len(condition)
+				}))
+		}
+
+		if operators := expressionOperators(expr); operators > maxExpressionOperators {
+			return exceeded("operators and functions", maxExpressionOperators, operators,
+				largestCondition(conditions, expressionOperators))
+		}
+
+		for _, condition := range conditions {
+			for _, match := range expressionInPattern.FindAllStringSubmatch(condition, -1) {
+				operands := len(strings.Split(match[1], ","))
+				if operands > maxInOperands {
+					return exceeded("IN operands", maxInOperands, operands, condition)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// expressionOperators returns the number of operators and functions of an expression.
+func expressionOperators(expr string) int {
+	// the AND of a BETWEEN operator is not a separate operator
+	return len(expressionOperatorPattern.FindAllString(expr, -1)) -
+		len(expressionBetweenPattern.FindAllString(expr, -1))
+}
+
+// splitConditions splits an expression into its top-level conditions, which are joined by AND or
+// OR outside of parentheses. Enclosing parentheses of each condition are removed.
+func splitConditions(expr string) []string {
+	conditions := []string{}
+	depth, start, betweens := 0, 0, 0
+	for i := 0; i < len(expr); i++ {
+		switch expr[i] {
+		case '(':
+			depth++
+			continue
+		case ')':
+			depth--
+			continue
+		}
+		if depth > 0 {
+			continue
+		}
+
+		rest := expr[i:]
+		switch {
+		case strings.HasPrefix(rest, " BETWEEN "):
+			betweens++
+		case strings.HasPrefix(rest, " AND ") && betweens > 0:
+			// the AND of a BETWEEN operator does not join conditions
+			betweens--
+		case strings.HasPrefix(rest, " AND "), strings.HasPrefix(rest, " OR "):
+			conditions = append(conditions, trimParentheses(expr[start:i]))
+			i += strings.Index(rest[1:], " ") + 1
+			start = i + 1
+		}
+	}
+	return append(conditions, trimParentheses(expr[start:]))
+}
+
+// trimParentheses removes parentheses enclosing the whole condition.
+func trimParentheses(condition string) string {
+	condition = strings.TrimSpace(condition)
+	for strings.HasPrefix(condition, "(") && strings.HasSuffix(condition, ")") {
+		depth := 0
+		for i := 0; i < len(condition)-1; i++ {
+			if condition[i] == '(' {
+				depth++
+			} else if condition[i] == ')' {
+				depth--
+			}
+			// the opening parenthesis closes before the end of the condition
+			if depth == 0 {
+				return condition
+			}
+		}
+		condition = strings.TrimSpace(condition[1 : len(condition)-1])
+	}
+	return condition
+}
+
+// largestCondition returns the condition with the greatest size.
+func largestCondition(conditions []string, size func(string) int) string {
+	largest, largestSize := "", -1
+	for _, condition := range conditions {
+		if conditionSize := size(condition); conditionSize > largestSize {
+			largest, largestSize = condition, conditionSize
+		}
+	}
+	return largest
+}
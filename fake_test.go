@@ -0,0 +1,320 @@
+package dynamodbfriend
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// fakeDynamoDB is an in-memory DynamoDB of a single table for tests. Items are stored by primary
+// key, queries return canned items, and the inputs of all requests are recorded. Condition
+// expressions are not evaluated, except that conditional writes of missing items fail.
+type fakeDynamoDB struct {
+	dynamodbiface.DynamoDBAPI
+
+	description *dynamodb.TableDescription
+
+	mutex sync.Mutex
+	items map[string]map[string]*dynamodb.AttributeValue
+
+	// queryItems are returned by every query
+	queryItems []map[string]*dynamodb.AttributeValue
+
+	getInputs      []*dynamodb.GetItemInput
+	putInputs      []*dynamodb.PutItemInput
+	updateInputs   []*dynamodb.UpdateItemInput
+	deleteInputs   []*dynamodb.DeleteItemInput
+	queryInputs    []*dynamodb.QueryInput
+	scanInputs     []*dynamodb.ScanInput
+	batchGetInputs []*dynamodb.BatchGetItemInput
+
+	batchWriteInputs []*dynamodb.BatchWriteItemInput
+
+	transactWriteInputs []*dynamodb.TransactWriteItemsInput
+	transactGetInputs   []*dynamodb.TransactGetItemsInput
+
+	// transactWriteErr is returned by every transactional write, if set
+	transactWriteErr error
+
+	// afterBatchWrite is called after each batch write, if set
+	afterBatchWrite func()
+}
+
+// fakeIndex describes a secondary index of a fake table. Empty projected attributes project only
+// keys.
+type fakeIndex struct {
+	name         string
+	partitionKey string
+	sortKey      string
+	projectAll   bool
+	projected    []string
+}
+
+// newFakeDynamoDB creates a fake DynamoDB with a table of the name and string primary key, and
+// global secondary indexes with string keys.
+func newFakeDynamoDB(tableName, partitionKey, sortKey string,
+	indexes ...fakeIndex) *fakeDynamoDB {
+
+	definitions := map[string]bool{}
+	keySchema := func(partitionKey, sortKey string) []*dynamodb.KeySchemaElement {
+		schema := []*dynamodb.KeySchemaElement{{
+			AttributeName: aws.String(partitionKey),
+			KeyType:       aws.String(dynamodb.KeyTypeHash),
+		}}
+		definitions[partitionKey] = true
+		if sortKey != "" {
+			schema = append(schema, &dynamodb.KeySchemaElement{
+				AttributeName: aws.String(sortKey),
+				KeyType:       aws.String(dynamodb.KeyTypeRange),
+			})
+			definitions[sortKey] = true
+		}
+		return schema
+	}
+
+	description := &dynamodb.TableDescription{
+		TableName: aws.String(tableName),
+		ItemCount: aws.Int64(0),
+		KeySchema: keySchema(partitionKey, sortKey),
+	}
+	for _, index := range indexes {
+		projection := &dynamodb.Projection{ProjectionType: aws.String(dynamodb.ProjectionTypeAll)}
+		if !index.projectAll && len(index.projected) == 0 {
+			projection.ProjectionType = aws.String(dynamodb.ProjectionTypeKeysOnly)
+		} else if !index.projectAll {
+			projection.ProjectionType = aws.String(dynamodb.ProjectionTypeInclude)
+			projection.NonKeyAttributes = aws.StringSlice(index.projected)
+		}
+		description.GlobalSecondaryIndexes = append(description.GlobalSecondaryIndexes,
+			&dynamodb.GlobalSecondaryIndexDescription{
+				IndexName:  aws.String(index.name),
+				ItemCount:  aws.Int64(0),
+				KeySchema:  keySchema(index.partitionKey, index.sortKey),
+				Projection: projection,
+			})
+	}
+	for name := range definitions {
+		description.AttributeDefinitions = append(description.AttributeDefinitions,
+			&dynamodb.AttributeDefinition{
+				AttributeName: aws.String(name),
+				AttributeType: aws.String(dynamodb.ScalarAttributeTypeS),
+			})
+	}
+
+	return &fakeDynamoDB{
+		description: description,
+		items:       map[string]map[string]*dynamodb.AttributeValue{},
+	}
+}
+
+// keyOf returns the storage key of the item's primary key.
+func (fake *fakeDynamoDB) keyOf(item map[string]*dynamodb.AttributeValue) string {
+	key := map[string]*dynamodb.AttributeValue{}
+	for _, element := range fake.description.KeySchema {
+		key[*element.AttributeName] = item[*element.AttributeName]
+	}
+	return itemCacheKey(*fake.description.TableName, key)
+}
+
+// putItems stores items directly, without recording a request.
+func (fake *fakeDynamoDB) putItems(items ...map[string]*dynamodb.AttributeValue) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	for _, item := range items {
+		fake.items[fake.keyOf(item)] = item
+	}
+}
+
+func conditionalCheckFailed() error {
+	return awserr.New(dynamodb.ErrCodeConditionalCheckFailedException,
+		"The conditional request failed", nil)
+}
+
+func (fake *fakeDynamoDB) DescribeTableWithContext(ctx aws.Context,
+	input *dynamodb.DescribeTableInput, opts ...request.Option) (*dynamodb.DescribeTableOutput,
+	error) {
+
+	return &dynamodb.DescribeTableOutput{Table: fake.description}, nil
+}
+
+func (fake *fakeDynamoDB) GetItemWithContext(ctx aws.Context, input *dynamodb.GetItemInput,
+	opts ...request.Option) (*dynamodb.GetItemOutput, error) {
+
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	fake.getInputs = append(fake.getInputs, input)
+	return &dynamodb.GetItemOutput{Item: fake.items[fake.keyOf(input.Key)]}, nil
+}
+
+func (fake *fakeDynamoDB) PutItemWithContext(ctx aws.Context, input *dynamodb.PutItemInput,
+	opts ...request.Option) (*dynamodb.PutItemOutput, error) {
+
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	fake.putInputs = append(fake.putInputs, input)
+	key := fake.keyOf(input.Item)
+	if _, found := fake.items[key]; !found && input.ConditionExpression != nil {
+		return nil, conditionalCheckFailed()
+	}
+	fake.items[key] = input.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (fake *fakeDynamoDB) UpdateItemWithContext(ctx aws.Context, input *dynamodb.UpdateItemInput,
+	opts ...request.Option) (*dynamodb.UpdateItemOutput, error) {
+
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	fake.updateInputs = append(fake.updateInputs, input)
+	if _, found := fake.items[fake.keyOf(input.Key)]; !found && input.ConditionExpression != nil {
+		return nil, conditionalCheckFailed()
+	}
+	return &dynamodb.UpdateItemOutput{}, nil
+}
+
+func (fake *fakeDynamoDB) DeleteItemWithContext(ctx aws.Context, input *dynamodb.DeleteItemInput,
+	opts ...request.Option) (*dynamodb.DeleteItemOutput, error) {
+
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	fake.deleteInputs = append(fake.deleteInputs, input)
+	key := fake.keyOf(input.Key)
+	if _, found := fake.items[key]; !found && input.ConditionExpression != nil {
+		return nil, conditionalCheckFailed()
+	}
+	delete(fake.items, key)
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func (fake *fakeDynamoDB) QueryWithContext(ctx aws.Context, input *dynamodb.QueryInput,
+	opts ...request.Option) (*dynamodb.QueryOutput, error) {
+
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	fake.queryInputs = append(fake.queryInputs, input)
+	return &dynamodb.QueryOutput{
+		Items:        fake.queryItems,
+		Count:        aws.Int64(int64(len(fake.queryItems))),
+		ScannedCount: aws.Int64(int64(len(fake.queryItems))),
+	}, nil
+}
+
+// ScanWithContext returns all stored items in a single page, ordered by primary key.
+func (fake *fakeDynamoDB) ScanWithContext(ctx aws.Context, input *dynamodb.ScanInput,
+	opts ...request.Option) (*dynamodb.ScanOutput, error) {
+
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	fake.scanInputs = append(fake.scanInputs, input)
+
+	keys := make([]string, 0, len(fake.items))
+	for key := range fake.items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	items := make([]map[string]*dynamodb.AttributeValue, len(keys))
+	for i, key := range keys {
+		items[i] = fake.items[key]
+	}
+	return &dynamodb.ScanOutput{
+		Items:        items,
+		Count:        aws.Int64(int64(len(items))),
+		ScannedCount: aws.Int64(int64(len(items))),
+	}, nil
+}
+
+// BatchGetItemWithContext returns the stored items of the keys in reverse order of the keys, since
+// DynamoDB does not return items in the order of the keys.
+func (fake *fakeDynamoDB) BatchGetItemWithContext(ctx aws.Context,
+	input *dynamodb.BatchGetItemInput, opts ...request.Option) (*dynamodb.BatchGetItemOutput,
+	error) {
+
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	fake.batchGetInputs = append(fake.batchGetInputs, input)
+
+	responses := map[string][]map[string]*dynamodb.AttributeValue{}
+	for tableName, keysAndAttributes := range input.RequestItems {
+		keys := keysAndAttributes.Keys
+		for i := len(keys) - 1; i >= 0; i-- {
+			if item, found := fake.items[fake.keyOf(keys[i])]; found {
+				responses[tableName] = append(responses[tableName], item)
+			}
+		}
+	}
+	return &dynamodb.BatchGetItemOutput{Responses: responses}, nil
+}
+
+// BatchWriteItemWithContext makes all puts and deletes of the request.
+func (fake *fakeDynamoDB) BatchWriteItemWithContext(ctx aws.Context,
+	input *dynamodb.BatchWriteItemInput, opts ...request.Option) (*dynamodb.BatchWriteItemOutput,
+	error) {
+
+	fake.mutex.Lock()
+	fake.batchWriteInputs = append(fake.batchWriteInputs, input)
+	for _, requests := range input.RequestItems {
+		for _, request := range requests {
+			if request.PutRequest != nil {
+				fake.items[fake.keyOf(request.PutRequest.Item)] = request.PutRequest.Item
+			} else if request.DeleteRequest != nil {
+				delete(fake.items, fake.keyOf(request.DeleteRequest.Key))
+			}
+		}
+	}
+	fake.mutex.Unlock()
+
+	if fake.afterBatchWrite != nil {
+		fake.afterBatchWrite()
+	}
+	return &dynamodb.BatchWriteItemOutput{}, nil
+}
+
+// TransactWriteItemsWithContext records the transaction without making its writes.
+func (fake *fakeDynamoDB) TransactWriteItemsWithContext(ctx aws.Context,
+	input *dynamodb.TransactWriteItemsInput, opts ...request.Option) (
+	*dynamodb.TransactWriteItemsOutput, error) {
+
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	fake.transactWriteInputs = append(fake.transactWriteInputs, input)
+	if fake.transactWriteErr != nil {
+		return nil, fake.transactWriteErr
+	}
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
+
+// TransactGetItemsWithContext returns the stored items of the gets in order of the gets.
+func (fake *fakeDynamoDB) TransactGetItemsWithContext(ctx aws.Context,
+	input *dynamodb.TransactGetItemsInput, opts ...request.Option) (
+	*dynamodb.TransactGetItemsOutput, error) {
+
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	fake.transactGetInputs = append(fake.transactGetInputs, input)
+
+	responses := make([]*dynamodb.ItemResponse, len(input.TransactItems))
+	for i, item := range input.TransactItems {
+		responses[i] = &dynamodb.ItemResponse{Item: fake.items[fake.keyOf(item.Get.Key)]}
+	}
+	return &dynamodb.TransactGetItemsOutput{Responses: responses}, nil
+}
+
+// newFakeTable returns a table of a client of the fake.
+func newFakeTable(fake *fakeDynamoDB) *Table {
+	return NewClient(fake).Table(*fake.description.TableName)
+}
+
+// stringItem returns an item of string attributes.
+func stringItem(attributes map[string]string) map[string]*dynamodb.AttributeValue {
+	item := map[string]*dynamodb.AttributeValue{}
+	for name, value := range attributes {
+		item[name] = &dynamodb.AttributeValue{S: aws.String(value)}
+	}
+	return item
+}
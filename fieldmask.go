@@ -0,0 +1,122 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// AttributeReadPolicy decides whether the caller identified by the context may read a restricted
+// attribute.
+type AttributeReadPolicy func(ctx context.Context, attribute string) bool
+
+type attributeGrantsKey struct{}
+
+// GrantAttributeAccess returns a copy of the context granting its holder read access to the named
+// restricted attributes, as checked by the default attribute read policy.
+func GrantAttributeAccess(ctx context.Context, attributes ...string) context.Context {
+	grants := newNameSet(attributes...)
+	if parent, found := ctx.Value(attributeGrantsKey{}).(*nameSet); found {
+		grants.Insert(parent.Names()...)
+	}
+	return context.WithValue(ctx, attributeGrantsKey{}, grants)
+}
+
+func contextGrantsAttribute(ctx context.Context, attribute string) bool {
+	grants, found := ctx.Value(attributeGrantsKey{}).(*nameSet)
+	return found && grants.Contains(attribute)
+}
+
+// WithRestrictedAttributes restricts reads of the named attributes, such as attributes containing
+// personal information. Restricted attributes are stripped from items read from the table unless
+// allowed by the table's attribute read policy. By default, access must be granted to the read's
+// context with GrantAttributeAccess.
+func (table *Table) WithRestrictedAttributes(attributes ...string) *Table {
+	if table.restrictedAttributes == nil {
+		table.restrictedAttributes = newNameSet()
+	}
+	table.restrictedAttributes.Insert(attributes...)
+	return table
+}
+
+// WithAttributeReadPolicy sets the policy deciding access to restricted attributes, replacing the
+// default policy of access granted with GrantAttributeAccess.
+func (table *Table) WithAttributeReadPolicy(policy AttributeReadPolicy) *Table {
+	table.attributeReadPolicy = policy
+	return table
+}
+
+// WithProtectedAttributes protects the named attributes from writes. Put fails with
+// ErrProtectedAttribute for items including a protected attribute, which may only be written
+// with PutProtected.
+func (table *Table) WithProtectedAttributes(attributes ...string) *Table {
+	if table.protectedAttributes == nil {
+		table.protectedAttributes = newNameSet()
+	}
+	table.protectedAttributes.Insert(attributes...)
+	return table
+}
+
+// PutProtected puts an item into the table like Put, but permits writes of protected attributes.
+func (table *Table) PutProtected(ctx context.Context, item interface{}) error {
+	attrMap, err := table.encodeItem(item)
+	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
+		return err
+	}
+
+	if table.versionAttribute != "" {
+		return table.putVersioned(ctx, item, attrMap, nil)
+	}
+
+	return table.putItem(ctx, attrMap, nil, AuditOperationPut)
+}
+
+// ErrProtectedAttribute is returned when a write includes a protected attribute outside of
+// PutProtected.
+type ErrProtectedAttribute struct {
+	TableName string
+	Attribute string
+}
+
+func (e ErrProtectedAttribute) Error() string {
+	return fmt.Sprintf("attribute \"%s\" of table \"%s\" is protected", e.Attribute, e.TableName)
+}
+
+// checkProtectedAttributes returns ErrProtectedAttribute if the item includes a protected
+// attribute.
+func (table *Table) checkProtectedAttributes(item map[string]*dynamodb.AttributeValue) error {
+	if table.protectedAttributes == nil {
+		return nil
+	}
+
+	for _, attribute := range table.protectedAttributes.Names() {
+		if _, found := item[attribute]; found {
+			err := ErrProtectedAttribute{TableName: table.Name, Attribute: attribute}
+			table.logger.Printf("error: %s\n", err.Error())
+			return err
+		}
+	}
+	return nil
+}
+
+// stripRestrictedAttributes removes restricted attributes the caller may not read from the item.
+func (table *Table) stripRestrictedAttributes(ctx context.Context,
+	item map[string]*dynamodb.AttributeValue) {
+
+	if table.restrictedAttributes == nil {
+		return
+	}
+
+	policy := table.attributeReadPolicy
+	if policy == nil {
+		policy = contextGrantsAttribute
+	}
+
+	for _, attribute := range table.restrictedAttributes.Names() {
+		if _, found := item[attribute]; found && !policy(ctx, attribute) {
+			delete(item, attribute)
+		}
+	}
+}
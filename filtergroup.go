@@ -0,0 +1,202 @@
+package dynamodbfriend
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+// FilterGroup is a group of filter conditions built with the same conditionals as query
+// expressions. Conditions of a group made with Or are met if any condition is met, and conditions
+// of a group made with And are met if all conditions are met. Groups may be nested. Conditions of
+// filter groups are always applied as filter conditions and are never used for key conditions.
+type FilterGroup struct {
+	any     bool
+	entries []filterGroupEntry
+
+	buildErr error
+}
+
+// filterGroupEntry is either a filter on the named attribute or a nested group.
+type filterGroupEntry struct {
+	name   string
+	filter queryFilter
+	group  *FilterGroup
+}
+
+// FilterGroupKey is a partially-formed condition of a filter group.
+//
+// To add the condition to the group, the key part must be followed by a conditional.
+type FilterGroupKey = ConditionKey[*FilterGroup]
+
+func newFilterGroup(any bool, build func(g *FilterGroup)) *FilterGroup {
+	group := &FilterGroup{any: any}
+	if build != nil {
+		build(group)
+	}
+	return group
+}
+
+// Or extends a query with a group of conditions of which at least one must be met, such as
+// expr.Or(func(g *FilterGroup) { g.Where("a").Equals(1).Where("b").Equals(2) }).
+func (expr *QueryExpr) Or(build func(g *FilterGroup)) *QueryExpr {
+	group := newFilterGroup(true, build)
+	if _, err := group.condition(); err != nil {
+		expr.logger.Printf("error: %s\n", err.Error())
+		expr.buildErr = err
+		return expr
+	}
+	expr.filterGroups = append(expr.filterGroups, group)
+	return expr
+}
+
+// Or extends a scan with a group of conditions of which at least one must be met.
+func (expr *ScanExpr) Or(build func(g *FilterGroup)) *ScanExpr {
+	group := newFilterGroup(true, build)
+	if _, err := group.condition(); err != nil {
+		expr.logger.Printf("error: %s\n", err.Error())
+		expr.buildErr = err
+		return expr
+	}
+	expr.filterGroups = append(expr.filterGroups, group)
+	return expr
+}
+
+// Where begins a condition on an attribute within the group.
+func (g *FilterGroup) Where(key string) *FilterGroupKey {
+	return &FilterGroupKey{
+		key: key,
+		add: g.addFilter,
+		fail: func(err error) *FilterGroup {
+			g.buildErr = err
+			return g
+		},
+	}
+}
+
+// Or nests a group of conditions of which at least one must be met.
+func (g *FilterGroup) Or(build func(g *FilterGroup)) *FilterGroup {
+	g.entries = append(g.entries, filterGroupEntry{group: newFilterGroup(true, build)})
+	return g
+}
+
+// And nests a group of conditions which must all be met.
+func (g *FilterGroup) And(build func(g *FilterGroup)) *FilterGroup {
+	g.entries = append(g.entries, filterGroupEntry{group: newFilterGroup(false, build)})
+	return g
+}
+
+func (g *FilterGroup) addFilter(v queryFilter) *FilterGroup {
+	g.entries = append(g.entries, filterGroupEntry{name: v.Key(), filter: v})
+	return g
+}
+
+// condition returns the group as a single filter condition.
+func (g *FilterGroup) condition() (expression.ConditionBuilder, error) {
+	if g.buildErr != nil {
+		return expression.ConditionBuilder{}, g.buildErr
+	} else if len(g.entries) == 0 {
+		return expression.ConditionBuilder{}, fmt.Errorf("filter group has no conditions")
+	}
+
+	conditions := []expression.ConditionBuilder{}
+	for _, entry := range g.entries {
+		if entry.group != nil {
+			condition, err := entry.group.condition()
+			if err != nil {
+				return expression.ConditionBuilder{}, err
+			}
+			conditions = append(conditions, condition)
+		} else {
+			conditions = append(conditions, entry.filter.Condition(expression.Name(entry.name)))
+		}
+	}
+
+	if len(conditions) == 1 {
+		return conditions[0], nil
+	} else if g.any {
+		return expression.Or(conditions[0], conditions[1], conditions[2:]...), nil
+	}
+	return expression.And(conditions[0], conditions[1], conditions[2:]...), nil
+}
+
+// attributeNames returns the names of all attributes with conditions in the group.
+func (g *FilterGroup) attributeNames() []string {
+	names := []string{}
+	for _, entry := range g.entries {
+		if entry.group != nil {
+			names = append(names, entry.group.attributeNames()...)
+		} else {
+			names = append(names, entry.name)
+		}
+	}
+	return names
+}
+
+// renamed returns a copy of the group with attribute names replaced by rename.
+func (g *FilterGroup) renamed(rename func(name string) string) *FilterGroup {
+	renamed := &FilterGroup{any: g.any, buildErr: g.buildErr}
+	for _, entry := range g.entries {
+		if entry.group != nil {
+			entry.group = entry.group.renamed(rename)
+		} else {
+			entry.name = rename(entry.name)
+		}
+		renamed.entries = append(renamed.entries, entry)
+	}
+	return renamed
+}
+
+// shape returns a string identifying the structure of the group, independent of condition values.
+func (g *FilterGroup) shape() string {
+	parts := []string{}
+	for _, entry := range g.entries {
+		if entry.group != nil {
+			parts = append(parts, entry.group.shape())
+		} else {
+			parts = append(parts, fmt.Sprintf("%q:%s", entry.name, entry.filter.Op()))
+		}
+	}
+
+	op := "and"
+	if g.any {
+		op = "or"
+	}
+	return op + "(" + strings.Join(parts, ",") + ")"
+}
+
+// filterGroupConditions returns the conditions of all filter groups.
+func filterGroupConditions(groups []*FilterGroup) ([]expression.ConditionBuilder, error) {
+	conditions := []expression.ConditionBuilder{}
+	for _, group := range groups {
+		condition, err := group.condition()
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, condition)
+	}
+	return conditions, nil
+}
+
+// renamedFilterGroups returns copies of the filter groups with attribute names replaced by rename.
+func renamedFilterGroups(groups []*FilterGroup, rename func(name string) string) []*FilterGroup {
+	if len(groups) == 0 {
+		return groups
+	}
+	renamed := make([]*FilterGroup, len(groups))
+	for i, group := range groups {
+		renamed[i] = group.renamed(rename)
+	}
+	return renamed
+}
+
+// filterGroupAttributes returns the names of all attributes with conditions in filter groups of
+// the expression.
+func (expr *QueryExpr) filterGroupAttributes() []string {
+	names := []string{}
+	for _, group := range expr.filterGroups {
+		names = append(names, group.attributeNames()...)
+	}
+	return names
+}
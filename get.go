@@ -0,0 +1,186 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Get reads the item with the specified key into val. The key may be a struct or map containing
+// the table's primary key attributes, and val must be a non-nil pointer. ErrItemNotFound is
+// returned if no item exists with the key. Soft-deleted and expired items are reported as not
+// found when those features are enabled. Get uses a consistent read if consistent reads are the
+// table's default.
+func (table *Table) Get(ctx context.Context, key, val interface{}) error {
+	return table.get(ctx, key, val, table.consistentReads)
+}
+
+// GetConsistent reads the item with the specified key into val like Get, but always uses a
+// consistent read. Consistent reads bypass the item cache and replica reads.
+func (table *Table) GetConsistent(ctx context.Context, key, val interface{}) error {
+	return table.get(ctx, key, val, true)
+}
+
+func (table *Table) get(ctx context.Context, key, val interface{}, consistent bool) error {
+	keyMap, err := table.marshalStoredKey(ctx, key)
+	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
+		return err
+	}
+
+	if table.excludeExpiredItems {
+		if err := table.loadTTLMetadata(ctx); err != nil {
+			return err
+		}
+	}
+
+	storedItem, err := table.getCachedItem(ctx, keyMap, consistent)
+	if err != nil {
+		return err
+	} else if storedItem == nil || table.isSoftDeleted(storedItem) || table.isExpired(storedItem) {
+		err := ErrItemNotFound{TableName: table.Name}
+		table.logger.Printf("error: %s\n", err.Error())
+		return err
+	}
+
+	item, err := table.itemFromStore(storedItem)
+	if err != nil {
+		return err
+	}
+
+	table.stripRestrictedAttributes(ctx, item)
+
+	return table.unmarshalItem(item, val)
+}
+
+// getCachedItem reads the item with the key as stored in the table, through the item cache and
+// request coalescing, if applicable. A nil item is returned if no item exists with the key.
+func (table *Table) getCachedItem(ctx context.Context, key map[string]*dynamodb.AttributeValue,
+	consistent bool) (map[string]*dynamodb.AttributeValue, error) {
+
+	cacheKey := itemCacheKey(table.Name, key)
+	useCache := table.itemCache != nil && !consistent
+
+	// serve item from cache, if applicable
+	if useCache {
+		if item, found := table.itemCache.GetItem(cacheKey); found {
+			table.logger.Printf("item served from cache\n")
+			return item, nil
+		}
+	}
+
+	var item map[string]*dynamodb.AttributeValue
+	var err error
+	if table.requestCoalescing != nil {
+		// share read with concurrent identical reads
+		var result interface{}
+		var shared bool
+		coalesceKey := fmt.Sprintf("GetItem|%t|%s", consistent, cacheKey)
+		result, err, shared = table.requestCoalescing.Do(coalesceKey,
+			func() (interface{}, error) {
+				return table.getStoredItem(ctx, key, consistent)
+			})
+		if shared {
+			table.logger.Printf("item read shared with concurrent identical read\n")
+		}
+		if err == nil {
+			item = result.(map[string]*dynamodb.AttributeValue)
+		}
+	} else {
+		item, err = table.getStoredItem(ctx, key, consistent)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if useCache {
+		if item != nil {
+			table.itemCache.SetItem(cacheKey, item, table.itemCacheTTL)
+		} else if table.negativeItemCacheTTL > 0 {
+			table.itemCache.SetItem(cacheKey, nil, table.negativeItemCacheTTL)
+		}
+	}
+
+	return item, nil
+}
+
+// getStoredItem reads the item with the key as stored in the table. A nil item is returned if no
+// item exists with the key. Eventually consistent reads may be served by a replica.
+func (table *Table) getStoredItem(ctx context.Context, key map[string]*dynamodb.AttributeValue,
+	consistent bool) (map[string]*dynamodb.AttributeValue, error) {
+
+	getInput := &dynamodb.GetItemInput{
+		TableName:              aws.String(table.Name),
+		Key:                    key,
+		ReturnConsumedCapacity: table.returnConsumedCapacity(),
+	}
+
+	readClient := table.baseClient
+	if consistent {
+		getInput.ConsistentRead = aws.Bool(true)
+	} else {
+		readClient = table.readClient(ctx)
+	}
+
+	start := time.Now()
+	getOutput, err := readClient.GetItemWithContext(ctx, getInput)
+
+	stats := OperationStats{
+		Operation: "GetItem",
+		Latency:   time.Since(start),
+		Err:       err,
+	}
+	if getOutput != nil {
+		stats.ConsumedCapacity = consumedCapacityUnits(getOutput.ConsumedCapacity)
+		if len(getOutput.Item) > 0 {
+			stats.Items = 1
+		}
+	}
+	table.emitStats(ctx, stats)
+
+	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
+		return nil, err
+	} else if len(getOutput.Item) == 0 {
+		return nil, nil
+	}
+
+	return getOutput.Item, nil
+}
+
+// marshalStoredKey marshals a key as given by callers and returns it as stored in the table. Only
+// the table's primary key attributes are kept, as a key may be given as a struct carrying other
+// attributes.
+func (table *Table) marshalStoredKey(ctx context.Context,
+	key interface{}) (map[string]*dynamodb.AttributeValue, error) {
+
+	keyMap, err := table.marshalItem(key)
+	if err != nil {
+		return nil, err
+	}
+	keyMap, err = table.storedKey(ctx, keyMap)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := table.loadIndexMetadata(ctx); err != nil {
+		return nil, err
+	}
+	return table.primaryKeyOf(keyMap), nil
+}
+
+// storedKey returns a key as stored in the table from a key as given by callers. The key is left
+// unmodified.
+func (table *Table) storedKey(ctx context.Context,
+	key map[string]*dynamodb.AttributeValue) (map[string]*dynamodb.AttributeValue, error) {
+
+	storedKey := copyItem(key)
+	table.applyAliases(storedKey)
+	if err := table.applyTenantPrefix(ctx, storedKey); err != nil {
+		return nil, err
+	}
+	return storedKey, nil
+}
@@ -0,0 +1,119 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// GetManyMode selects how GetMany reads items, trading consistency for latency and cost.
+type GetManyMode int
+
+const (
+	// GetManySnapshotPreferred reads items as a consistent snapshot in a single transaction when
+	// there are at most 100 distinct keys, and otherwise falls back to batch reads. This is the
+	// default.
+	GetManySnapshotPreferred GetManyMode = iota
+	// GetManySnapshotRequired reads items as a consistent snapshot in a single transaction, and
+	// returns ErrTransactionTooLarge if there are more than 100 distinct keys.
+	GetManySnapshotRequired
+	// GetManyBatch reads items with BatchGet, which costs less than a transaction but may observe
+	// each item at a different point in time.
+	GetManyBatch
+)
+
+// GetMany reads the items with the specified keys into out, as with BatchGet, using the mode to
+// choose between a transactional snapshot read and batch reads. Snapshot reads return all items as
+// of the same point in time, at twice the read capacity of batch reads, and do not use the item
+// cache. Found items are appended to out in the order of their keys.
+func (table *Table) GetMany(ctx context.Context, keys interface{}, out interface{},
+	mode GetManyMode) error {
+
+	if mode == GetManyBatch {
+		return table.BatchGet(ctx, keys, out)
+	}
+
+	keysValue := reflect.ValueOf(keys)
+	outValue := reflect.ValueOf(out)
+	if keysValue.Kind() != reflect.Slice {
+		err := fmt.Errorf("keys must be a slice")
+		table.logger.Printf("error: %s\n", err.Error())
+		return err
+	} else if outValue.Kind() != reflect.Ptr || outValue.IsNil() ||
+		outValue.Elem().Kind() != reflect.Slice {
+		err := fmt.Errorf("out must be a non-nil pointer to a slice")
+		table.logger.Printf("error: %s\n", err.Error())
+		return err
+	}
+
+	if err := table.loadIndexMetadata(ctx); err != nil {
+		return err
+	}
+
+	// resolve distinct keys, as a transaction may not read the same item twice
+	keyIndexes := make([]int, keysValue.Len())
+	distinctIndexes := map[string]int{}
+	distinctKeys := []interface{}{}
+	for i := 0; i < keysValue.Len(); i++ {
+		key := keysValue.Index(i).Interface()
+		keyMap, err := table.marshalStoredKey(ctx, key)
+		if err != nil {
+			table.logger.Printf("error: %s\n", err.Error())
+			return err
+		}
+
+		// counter items are never returned, so they are not read
+		if table.isCounterItem(keyMap) {
+			keyIndexes[i] = -1
+			continue
+		}
+
+		cacheKey := itemCacheKey(table.Name, table.primaryKeyOf(keyMap))
+		index, seen := distinctIndexes[cacheKey]
+		if !seen {
+			index = len(distinctKeys)
+			distinctIndexes[cacheKey] = index
+			distinctKeys = append(distinctKeys, key)
+		}
+		keyIndexes[i] = index
+	}
+
+	if len(distinctKeys) > maxTransactionItems {
+		if mode == GetManySnapshotRequired {
+			err := ErrTransactionTooLarge{Items: len(distinctKeys)}
+			table.logger.Printf("error: %s\n", err.Error())
+			return err
+		}
+		table.logger.Printf("reading %d keys with batch reads, exceeding the snapshot limit of %d\n",
+			len(distinctKeys), maxTransactionItems)
+		return table.BatchGet(ctx, keys, out)
+	}
+
+	if len(distinctKeys) == 0 {
+		return nil
+	}
+
+	// read through the table's DynamoDB client so that the table's timeouts and scheduling apply
+	tg := table.client.NewTransactGet()
+	tg.base = table.baseClient
+	sliceValue := outValue.Elem()
+	elemType := sliceValue.Type().Elem()
+	itemPtrs := make([]reflect.Value, len(distinctKeys))
+	for i, key := range distinctKeys {
+		itemPtrs[i] = reflect.New(elemType)
+		tg.Get(table, key, itemPtrs[i].Interface())
+	}
+
+	if err := tg.Run(ctx); err != nil {
+		return err
+	}
+
+	// append found items in order of their keys
+	for _, index := range keyIndexes {
+		if index >= 0 && tg.Found(index) {
+			sliceValue.Set(reflect.Append(sliceValue, itemPtrs[index].Elem()))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestGetMany(t *testing.T) {
+	manyKeys := []map[string]string{}
+	for i := 0; i <= maxTransactionItems; i++ {
+		manyKeys = append(manyKeys, map[string]string{"id": fmt.Sprintf("item%d", i)})
+	}
+
+	cases := []struct {
+		name string
+		keys []map[string]string
+		mode GetManyMode
+
+		expectIDs          []string
+		expectErr          error
+		expectTransactions int
+		expectBatches      int
+	}{
+		{
+			name:               "snapshot of duplicate and missing keys",
+			keys:               []map[string]string{{"id": "b"}, {"id": "a"}, {"id": "b"}, {"id": "c"}},
+			mode:               GetManySnapshotPreferred,
+			expectIDs:          []string{"b", "a", "b"},
+			expectTransactions: 1,
+		},
+		{
+			name:          "batch reads",
+			keys:          []map[string]string{{"id": "b"}, {"id": "a"}, {"id": "c"}},
+			mode:          GetManyBatch,
+			expectIDs:     []string{"b", "a"},
+			expectBatches: 1,
+		},
+		{
+			name:          "snapshot preferred beyond transaction limit",
+			keys:          append([]map[string]string{{"id": "b"}, {"id": "a"}}, manyKeys...),
+			mode:          GetManySnapshotPreferred,
+			expectIDs:     []string{"b", "a"},
+			expectBatches: 2,
+		},
+		{
+			name:      "snapshot required beyond transaction limit",
+			keys:      manyKeys,
+			mode:      GetManySnapshotRequired,
+			expectErr: ErrTransactionTooLarge{Items: maxTransactionItems + 1},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := newFakeDynamoDB("items", "id", "")
+			fake.putItems(
+				stringItem(map[string]string{"id": "a"}),
+				stringItem(map[string]string{"id": "b"}),
+			)
+			table := newFakeTable(fake)
+
+			out := []map[string]string{}
+			err := table.GetMany(context.Background(), tc.keys, &out, tc.mode)
+			if !reflect.DeepEqual(err, tc.expectErr) {
+				t.Fatalf("expected error %v, got %v", tc.expectErr, err)
+			}
+
+			ids := []string{}
+			for _, item := range out {
+				ids = append(ids, item["id"])
+			}
+			if tc.expectIDs == nil {
+				tc.expectIDs = []string{}
+			}
+			if !reflect.DeepEqual(ids, tc.expectIDs) {
+				t.Errorf("expected items %v, got %v", tc.expectIDs, ids)
+			}
+
+			if len(fake.transactGetInputs) != tc.expectTransactions {
+				t.Errorf("expected %d transactions, got %d", tc.expectTransactions,
+					len(fake.transactGetInputs))
+			} else if tc.expectTransactions > 0 {
+				// a transaction may not read the same item twice
+				if reads := len(fake.transactGetInputs[0].TransactItems); reads != 3 {
+					t.Errorf("expected 3 distinct reads, got %d", reads)
+				}
+			}
+			if len(fake.batchGetInputs) != tc.expectBatches {
+				t.Errorf("expected %d batch reads, got %d", tc.expectBatches,
+					len(fake.batchGetInputs))
+			}
+		})
+	}
+}
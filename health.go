@@ -0,0 +1,161 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+const healthCheckPrefix = "HEALTHCHECK#"
+
+// HealthStatus is the outcome of a health check.
+type HealthStatus string
+
+// Health statuses reported by HealthCheck, from best to worst.
+const (
+	HealthStatusHealthy   HealthStatus = "Healthy"
+	HealthStatusDegraded  HealthStatus = "Degraded"
+	HealthStatusUnhealthy HealthStatus = "Unhealthy"
+)
+
+// HealthCheckOptions configures a health check of a table.
+type HealthCheckOptions struct {
+	// LatencyThreshold is the latency above which an operation is reported as degraded. A zero
+	// threshold never reports degraded operations.
+	LatencyThreshold time.Duration
+
+	// CanaryWrite additionally writes and deletes a canary item to check write availability. The
+	// canary item has all primary key attributes set to "HEALTHCHECK#<CanaryID>", so the table's
+	// primary key attributes must be strings or binary. Canary writes are audited and emit stats
+	// like other writes.
+	CanaryWrite bool
+
+	// CanaryID identifies the canary item, such as by host, so that concurrent health checks do not
+	// write the same item.
+	CanaryID string
+}
+
+// HealthCheckOperation is the result of a single operation of a health check.
+type HealthCheckOperation struct {
+	Operation string
+	Status    HealthStatus
+	Latency   time.Duration
+	Err       error
+}
+
+// HealthCheckResult is the result of a health check. The status is the worst status of all
+// operations.
+type HealthCheckResult struct {
+	TableName  string
+	Status     HealthStatus
+	Operations []HealthCheckOperation
+}
+
+// HealthCheck performs a lightweight read of the table, and optionally a write and delete of a
+// canary item, reporting the status and latency of each operation, such as for readiness probes.
+// Failed operations are reported in the result rather than returned as an error.
+func (table *Table) HealthCheck(ctx context.Context, opts HealthCheckOptions) *HealthCheckResult {
+	result := &HealthCheckResult{
+		TableName: table.Name,
+		Status:    HealthStatusHealthy,
+	}
+
+	check := func(operation string, fn func() error) bool {
+		start := time.Now()
+		err := fn()
+		latency := time.Since(start)
+
+		status := HealthStatusHealthy
+		if err != nil {
+			status = HealthStatusUnhealthy
+		} else if opts.LatencyThreshold > 0 && latency > opts.LatencyThreshold {
+			status = HealthStatusDegraded
+		}
+
+		result.Operations = append(result.Operations, HealthCheckOperation{
+			Operation: operation,
+			Status:    status,
+			Latency:   latency,
+			Err:       err,
+		})
+		if status == HealthStatusUnhealthy ||
+			(status == HealthStatusDegraded && result.Status == HealthStatusHealthy) {
+			result.Status = status
+		}
+		return err == nil
+	}
+
+	var canaryKey, key map[string]*dynamodb.AttributeValue
+	ok := check("Metadata", func() error {
+		if err := table.loadIndexMetadata(ctx); err != nil {
+			return err
+		}
+		canaryKey = table.healthCheckKey(healthCheckPrefix + opts.CanaryID)
+		key = copyItem(canaryKey)
+		return table.applyTenantPrefix(ctx, key)
+	})
+	if !ok {
+		return result
+	}
+
+	check("GetItem", func() error {
+		_, err := table.getStoredItem(ctx, key, false)
+		return err
+	})
+
+	if opts.CanaryWrite {
+		ok := check("PutItem", func() error {
+			for name, av := range canaryKey {
+				if av.N != nil {
+					err := fmt.Errorf("canary write requires string or binary primary key "+
+						"attributes, but \"%s\" is a number", name)
+					table.logger.Printf("error: %s\n", err.Error())
+					return err
+				}
+			}
+
+			// the tenant prefix is applied to the canary item on write
+			item := copyItem(canaryKey)
+			item["checkedAt"] = &dynamodb.AttributeValue{
+				N: aws.String(strconv.FormatInt(time.Now().Unix(), 10)),
+			}
+			return table.putItem(ctx, item, nil, AuditOperationPut)
+		})
+		if ok {
+			check("DeleteItem", func() error {
+				return table.deleteItem(ctx, key, nil)
+			})
+		}
+	}
+
+	if result.Status != HealthStatusHealthy {
+		table.logger.Printf("health check of table \"%s\" reported status \"%s\"\n",
+			table.Name, result.Status)
+	}
+
+	return result
+}
+
+// healthCheckKey returns the key of the health check item, without a tenant prefix, with each
+// primary key attribute of the table's key type. Number attributes are set to zero, which is only
+// suitable for reads. Index metadata must already be loaded.
+func (table *Table) healthCheckKey(id string) map[string]*dynamodb.AttributeValue {
+	index := table.allIndexes()[tablePrimaryIndexName]
+
+	key := map[string]*dynamodb.AttributeValue{}
+	for _, keyName := range index.getKeys() {
+		switch index.keyType(keyName) {
+		case dynamodb.ScalarAttributeTypeN:
+			key[keyName] = &dynamodb.AttributeValue{N: aws.String("0")}
+		case dynamodb.ScalarAttributeTypeB:
+			key[keyName] = &dynamodb.AttributeValue{B: []byte(id)}
+		default:
+			key[keyName] = &dynamodb.AttributeValue{S: aws.String(id)}
+		}
+	}
+	return key
+}
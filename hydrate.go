@@ -0,0 +1,143 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// AllowHydration allows the query to use indexes that do not project the queried attributes, such
+// as KEYS_ONLY indexes, by querying the index for the keys of matching items and then reading the
+// full items from the table with concurrent BatchGetItem requests. Such indexes are otherwise only
+// used when no index projects the queried attributes. Conditions of the query must only reference
+// attributes projected by the index, since they are evaluated by the index query, and queries with
+// conditions given by WithFilter are never hydrated.
+func (expr *QueryExpr) AllowHydration() *QueryExpr {
+	expr.hydrationAllowed = true
+	return expr
+}
+
+// indexProjectsQuery returns true if the index projects all attributes returned by the query.
+func (table *Table) indexProjectsQuery(expr *QueryExpr, index *tableIndex) bool {
+	if index.IncludesAllAttributes {
+		return true
+	} else if !expr.attributesSpecified {
+		return false
+	}
+	for _, attribute := range expr.attributes {
+		if _, found := index.AttributeSet[attribute]; !found {
+			return false
+		}
+	}
+	return true
+}
+
+// indexHydratable returns true if the query may be executed on the index by reading the keys of
+// matching items from the index and the items themselves from the table.
+func (table *Table) indexHydratable(expr *QueryExpr, index *tableIndex) bool {
+	// conditions of raw filters cannot be checked against the index projection
+	if index.Name == tablePrimaryIndexName || len(expr.additionalConditions) > 0 {
+		return false
+	} else if index.IncludesAllAttributes {
+		return true
+	}
+
+	// conditions are evaluated by the index query, so their attributes must be projected
+	for key := range expr.filters {
+		if _, found := index.AttributeSet[key]; !found {
+			return false
+		}
+	}
+	return true
+}
+
+// hydratedExpr returns the expression marked for hydration, if applicable.
+func hydratedExpr(expr *QueryExpr, hydrate bool) *QueryExpr {
+	if !hydrate || expr.hydrate {
+		return expr
+	}
+	hydrated := *expr
+	hydrated.hydrate = true
+	return &hydrated
+}
+
+// keysOnlyExpr returns a copy of a hydrated expression selecting only the keys of the table and
+// the index, which are read from the index before items are read from the table.
+func (table *Table) keysOnlyExpr(expr *QueryExpr, index *tableIndex) *QueryExpr {
+	keys := newNameSet(table.allIndexes()[tablePrimaryIndexName].getKeys()...)
+	keys.Insert(index.getKeys()...)
+
+	keysOnly := *expr
+	keysOnly.attributesSpecified = true
+	keysOnly.attributes = keys.Names()
+	sort.Strings(keysOnly.attributes)
+	return &keysOnly
+}
+
+// hydrateItems reads the items with the keys of items read from an index, in chunks read
+// concurrently. Items are returned in the order of the index items, and items no longer in the
+// table are omitted, as are soft-deleted and expired items when those features are enabled.
+func (parser *QueryParser) hydrateItems(ctx context.Context,
+	indexItems []map[string]*dynamodb.AttributeValue) ([]map[string]*dynamodb.AttributeValue, error) {
+
+	table := parser.table
+	keys := make([]map[string]*dynamodb.AttributeValue, len(indexItems))
+	for i, indexItem := range indexItems {
+		keys[i] = table.primaryKeyOf(indexItem)
+	}
+
+	chunks := (len(keys) + batchGetChunkSize - 1) / batchGetChunkSize
+	chunkItems := make([][]map[string]*dynamodb.AttributeValue, chunks)
+	err := runSegments(ctx, chunks, func(ctx context.Context, chunk int) error {
+		end := (chunk + 1) * batchGetChunkSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+		items, err := table.batchGetChunk(ctx, keys[chunk*batchGetChunkSize:end],
+			parser.expr.consistentRead)
+		chunkItems[chunk] = items
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	storedItems := map[string]map[string]*dynamodb.AttributeValue{}
+	for _, items := range chunkItems {
+		for _, item := range items {
+			storedItems[itemCacheKey(table.Name, table.primaryKeyOf(item))] = item
+		}
+	}
+
+	items := make([]map[string]*dynamodb.AttributeValue, 0, len(keys))
+	for _, key := range keys {
+		item, found := storedItems[itemCacheKey(table.Name, key)]
+		if !found || table.isSoftDeleted(item) || table.isExpired(item) {
+			continue
+		}
+		items = append(items, parser.selectedAttributesOf(item))
+	}
+
+	if omitted := len(keys) - len(items); omitted > 0 {
+		parser.expr.logger.Printf("%d index items omitted from hydrated items\n", omitted)
+	}
+
+	return items, nil
+}
+
+// selectedAttributesOf returns the attributes of an item selected by the query, if specified.
+func (parser *QueryParser) selectedAttributesOf(
+	item map[string]*dynamodb.AttributeValue) map[string]*dynamodb.AttributeValue {
+
+	if !parser.expr.attributesSpecified {
+		return item
+	}
+	selected := map[string]*dynamodb.AttributeValue{}
+	for _, attribute := range parser.expr.attributes {
+		if av, found := item[attribute]; found {
+			selected[attribute] = av
+		}
+	}
+	return selected
+}
@@ -0,0 +1,106 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+type hydratedItem struct {
+	ID        string `dynamodbav:"id"`
+	Status    string `dynamodbav:"status"`
+	Name      string `dynamodbav:"name"`
+	DeletedAt int64  `dynamodbav:"deletedAt,omitempty"`
+}
+
+func TestHydratedQuery(t *testing.T) {
+	stored := func(id, name string) map[string]*dynamodb.AttributeValue {
+		return stringItem(map[string]string{"id": id, "status": "open", "name": name})
+	}
+	indexItem := func(id string) map[string]*dynamodb.AttributeValue {
+		return stringItem(map[string]string{"id": id, "status": "open"})
+	}
+	softDeleted := stored("c", "deleted")
+	softDeleted["deletedAt"] = &dynamodb.AttributeValue{N: aws.String("1700000000")}
+
+	cases := []struct {
+		name        string
+		softDelete  bool
+		stored      []map[string]*dynamodb.AttributeValue
+		indexItems  []map[string]*dynamodb.AttributeValue
+		expectNames []string
+	}{
+		{
+			name:        "items in index order",
+			stored:      []map[string]*dynamodb.AttributeValue{stored("a", "A"), stored("b", "B")},
+			indexItems:  []map[string]*dynamodb.AttributeValue{indexItem("b"), indexItem("a")},
+			expectNames: []string{"B", "A"},
+		},
+		{
+			name:   "items no longer in table are omitted",
+			stored: []map[string]*dynamodb.AttributeValue{stored("a", "A"), stored("c", "C")},
+			indexItems: []map[string]*dynamodb.AttributeValue{
+				indexItem("c"), indexItem("b"), indexItem("a"),
+			},
+			expectNames: []string{"C", "A"},
+		},
+		{
+			name:        "soft-deleted items are omitted",
+			softDelete:  true,
+			stored:      []map[string]*dynamodb.AttributeValue{stored("a", "A"), softDeleted},
+			indexItems:  []map[string]*dynamodb.AttributeValue{indexItem("c"), indexItem("a")},
+			expectNames: []string{"A"},
+		},
+		{
+			name:        "no items remain",
+			stored:      []map[string]*dynamodb.AttributeValue{},
+			indexItems:  []map[string]*dynamodb.AttributeValue{indexItem("a")},
+			expectNames: []string{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := newFakeDynamoDB("items", "id", "", fakeIndex{
+				name:         "byStatus",
+				partitionKey: "status",
+			})
+			fake.putItems(tc.stored...)
+			fake.queryItems = tc.indexItems
+			table := newFakeTable(fake)
+			if tc.softDelete {
+				table.WithSoftDelete("deletedAt")
+			}
+
+			parser, err := table.Query(context.Background(), NewQuery("status").Equals("open"))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			items := []hydratedItem{}
+			if err := parser.All(context.Background(), &items); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			names := []string{}
+			for _, item := range items {
+				names = append(names, item.Name)
+			}
+			if !reflect.DeepEqual(names, tc.expectNames) {
+				t.Errorf("expected items %v, got %v", tc.expectNames, names)
+			}
+
+			// only keys are queried from the index
+			if len(fake.queryInputs) != 1 {
+				t.Fatalf("expected 1 query, got %d", len(fake.queryInputs))
+			} else if index := aws.StringValue(fake.queryInputs[0].IndexName); index != "byStatus" {
+				t.Errorf("expected query of index byStatus, got %q", index)
+			} else if fake.queryInputs[0].ProjectionExpression == nil {
+				t.Errorf("expected keys-only projection of the index query")
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+// IndexIntegrityReport summarizes a check of a global secondary index against its base table.
+// Keys are reported as stored in the table.
+type IndexIntegrityReport struct {
+	IndexName string
+
+	// Scanned is the number of index entries checked.
+	Scanned int
+
+	// Orphaned are the primary keys of index entries with no item in the base table.
+	Orphaned []map[string]*dynamodb.AttributeValue
+
+	// Inconsistent are index entries whose index key attributes differ from the base item.
+	Inconsistent []IndexInconsistency
+}
+
+// IndexInconsistency is an index entry whose index key attributes differ from its base item.
+type IndexInconsistency struct {
+	// Key is the primary key of the base item.
+	Key map[string]*dynamodb.AttributeValue
+
+	// Attributes are the names of index key attributes that differ, including attributes that
+	// are missing from the base item.
+	Attributes []string
+}
+
+// CheckIndexIntegrity scans the keys of the named global secondary index and cross-checks each
+// entry against the base table with strongly consistent batch reads, reporting index entries that
+// have no base item or whose index keys differ from the base item. Global secondary indexes are
+// eventually consistent, so entries of recent writes may be reported until the index catches up;
+// reported entries should be rechecked before acting on them.
+func (table *Table) CheckIndexIntegrity(ctx context.Context,
+	indexName string) (*IndexIntegrityReport, error) {
+
+	if err := table.loadIndexMetadata(ctx); err != nil {
+		return nil, err
+	}
+
+	index, found := table.allIndexes()[indexName]
+	if !found || indexName == tablePrimaryIndexName {
+		err := fmt.Errorf("global secondary index \"%s\" not found in table \"%s\"",
+			indexName, table.Name)
+		table.logger.Printf("error: %s\n", err.Error())
+		return nil, err
+	}
+	primaryKeys := table.allIndexes()[tablePrimaryIndexName].getKeys()
+	indexKeys := index.getKeys()
+
+	// project only the keys of the base table and index
+	projectedNames := newNameSet(primaryKeys[0])
+	projection := expression.NamesList(expression.Name(primaryKeys[0]))
+	for _, name := range append(primaryKeys[1:], indexKeys...) {
+		if !projectedNames.Contains(name) {
+			projectedNames.Insert(name)
+			projection = projection.AddNames(expression.Name(name))
+		}
+	}
+	dbExpr, err := expression.NewBuilder().WithProjection(projection).Build()
+	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
+		return nil, err
+	}
+
+	scanInput := &dynamodb.ScanInput{
+		TableName:                aws.String(table.Name),
+		IndexName:                aws.String(indexName),
+		ProjectionExpression:     dbExpr.Projection(),
+		ExpressionAttributeNames: dbExpr.Names(),
+	}
+
+	report := &IndexIntegrityReport{IndexName: indexName}
+	for {
+		scanInput.ReturnConsumedCapacity = table.returnConsumedCapacity()
+
+		start := time.Now()
+		scanOutput, err := table.baseClient.ScanWithContext(ctx, scanInput)
+
+		stats := OperationStats{
+			Operation: "Scan",
+			Latency:   time.Since(start),
+			Err:       err,
+		}
+		if scanOutput != nil {
+			stats.ConsumedCapacity = consumedCapacityUnits(scanOutput.ConsumedCapacity)
+			stats.Items = len(scanOutput.Items)
+		}
+		table.emitStats(ctx, stats)
+
+		if err != nil {
+			table.logger.Printf("error: %s\n", err.Error())
+			return nil, err
+		}
+
+		scanOutput.Items = table.withoutCounterItems(scanOutput.Items)
+		for i := 0; i < len(scanOutput.Items); i += batchGetChunkSize {
+			end := i + batchGetChunkSize
+			if end > len(scanOutput.Items) {
+				end = len(scanOutput.Items)
+			}
+			err := table.checkIndexEntries(ctx, scanOutput.Items[i:end], indexKeys, report)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if len(scanOutput.LastEvaluatedKey) == 0 {
+			return report, nil
+		}
+		scanInput.ExclusiveStartKey = scanOutput.LastEvaluatedKey
+	}
+}
+
+// checkIndexEntries cross-checks up to 100 index entries against their base items.
+func (table *Table) checkIndexEntries(ctx context.Context,
+	entries []map[string]*dynamodb.AttributeValue, indexKeys []string,
+	report *IndexIntegrityReport) error {
+
+	keys := make([]map[string]*dynamodb.AttributeValue, len(entries))
+	for i, entry := range entries {
+		keys[i] = table.primaryKeyOf(entry)
+	}
+
+	items, err := table.batchGetChunk(ctx, keys, true)
+	if err != nil {
+		return err
+	}
+
+	baseItems := map[string]map[string]*dynamodb.AttributeValue{}
+	for _, item := range items {
+		baseItems[itemCacheKey(table.Name, table.primaryKeyOf(item))] = item
+	}
+
+	for i, entry := range entries {
+		report.Scanned++
+
+		item, found := baseItems[itemCacheKey(table.Name, keys[i])]
+		if !found {
+			report.Orphaned = append(report.Orphaned, keys[i])
+			continue
+		}
+
+		differing := []string{}
+		for _, name := range indexKeys {
+			if !attributeValuesEqual(entry[name], item[name]) {
+				differing = append(differing, name)
+			}
+		}
+		if len(differing) > 0 {
+			report.Inconsistent = append(report.Inconsistent, IndexInconsistency{
+				Key:        keys[i],
+				Attributes: differing,
+			})
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// IndexStatus describes the creation progress of a global secondary index.
+type IndexStatus struct {
+	IndexName string
+
+	// Status is the index status reported by DynamoDB, such as "CREATING" or "ACTIVE".
+	Status string
+
+	// Backfilling is true while existing table items are being added to the index.
+	Backfilling bool
+
+	// Progress approximates the fraction of table items added to the index, from 0 to 1. Item
+	// counts are only updated by DynamoDB periodically, so progress may lag.
+	Progress float64
+
+	// Queryable is true once the index is active and backfilling has completed.
+	Queryable bool
+
+	// Err is set by WatchIndexBackfill if the status could not be retrieved.
+	Err error
+}
+
+// IndexBackfillStatus returns the creation progress of the named global secondary index.
+func (table *Table) IndexBackfillStatus(ctx context.Context, indexName string) (*IndexStatus, error) {
+	describeInfo, err := table.baseClient.DescribeTableWithContext(ctx,
+		&dynamodb.DescribeTableInput{
+			TableName: aws.String(table.Name),
+		})
+	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
+		return nil, err
+	}
+
+	tableDescription := describeInfo.Table
+	for _, indexDescription := range tableDescription.GlobalSecondaryIndexes {
+		if aws.StringValue(indexDescription.IndexName) != indexName {
+			continue
+		}
+
+		status := &IndexStatus{
+			IndexName:   indexName,
+			Status:      aws.StringValue(indexDescription.IndexStatus),
+			Backfilling: aws.BoolValue(indexDescription.Backfilling),
+		}
+		status.Queryable = status.Status == dynamodb.IndexStatusActive && !status.Backfilling
+
+		tableItemCount := aws.Int64Value(tableDescription.ItemCount)
+		indexItemCount := aws.Int64Value(indexDescription.ItemCount)
+		if status.Queryable || tableItemCount == 0 {
+			status.Progress = 1
+		} else {
+			status.Progress = float64(indexItemCount) / float64(tableItemCount)
+			if status.Progress > 1 {
+				status.Progress = 1
+			}
+		}
+
+		return status, nil
+	}
+
+	err = fmt.Errorf("global secondary index \"%s\" not found in table \"%s\"",
+		indexName, table.Name)
+	table.logger.Printf("error: %s\n", err.Error())
+	return nil, err
+}
+
+// WatchIndexBackfill polls the creation progress of the named global secondary index at the
+// specified interval and emits each change in status on the returned channel. The channel is
+// closed once the index is queryable, once the context is cancelled, or after emitting a status
+// with Err set. When the index becomes queryable, the table's index metadata is refreshed on the
+// next query so that the new index may be chosen.
+func (table *Table) WatchIndexBackfill(ctx context.Context, indexName string,
+	interval time.Duration) <-chan IndexStatus {
+
+	statuses := make(chan IndexStatus)
+
+	go func() {
+		defer close(statuses)
+
+		var lastStatus *IndexStatus
+		for {
+			status, err := table.IndexBackfillStatus(ctx, indexName)
+			if err != nil {
+				status = &IndexStatus{IndexName: indexName, Err: err}
+			}
+
+			if lastStatus == nil || *status != *lastStatus {
+				select {
+				case statuses <- *status:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if status.Err != nil {
+				return
+			} else if status.Queryable {
+				table.logger.Printf("index \"%s\" of table \"%s\" is queryable\n",
+					indexName, table.Name)
+				atomic.StoreInt32(&table.indexMetadata.stale, 1)
+				return
+			}
+			lastStatus = status
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+		}
+	}()
+
+	return statuses
+}
@@ -0,0 +1,30 @@
+package dynamodbfriend
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams/dynamodbstreamsiface"
+)
+
+// ItemInvalidator is an interface for discarding cached state about an item. Keys are given as
+// stored in the table.
+type ItemInvalidator interface {
+	InvalidateItem(tableName string, key map[string]*dynamodb.AttributeValue)
+}
+
+// InvalidateFromStream consumes the table's stream and invalidates each item written to the table
+// by any writer, keeping caches in multiple processes coherent. InvalidateFromStream blocks until
+// the context is cancelled or reading the stream fails.
+func (table *Table) InvalidateFromStream(ctx context.Context,
+	streams dynamodbstreamsiface.DynamoDBStreamsAPI, invalidator ItemInvalidator) error {
+
+	return table.ConsumeStream(ctx, streams,
+		func(_ context.Context, record *dynamodbstreams.Record) error {
+			if record.Dynamodb != nil {
+				invalidator.InvalidateItem(table.Name, record.Dynamodb.Keys)
+			}
+			return nil
+		})
+}
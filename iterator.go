@@ -0,0 +1,49 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"io"
+	"iter"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Iterator is implemented by all item readers in this package, such as QueryParser. Next
+// unmarshals the next item into val, which must be a non-nil pointer, and returns
+// ErrParsingComplete once no items remain. Close signals that no further items will be requested.
+type Iterator interface {
+	Next(ctx context.Context, val interface{}) error
+	io.Closer
+}
+
+var _ Iterator = (*QueryParser)(nil)
+
+// Items returns the remaining items of the query as a sequence that may be ranged over. Items are
+// given as attribute maps, with all table-level transformations reversed. Iteration ends after the
+// last item or after the first error is yielded.
+func (parser *QueryParser) Items(
+	ctx context.Context) iter.Seq2[map[string]*dynamodb.AttributeValue, error] {
+
+	return func(yield func(map[string]*dynamodb.AttributeValue, error) bool) {
+		for {
+			storedItem, err := parser.nextStoredItem(ctx)
+			if _, parsingComplete := err.(ErrParsingComplete); parsingComplete {
+				return
+			} else if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			item, err := parser.table.itemFromStore(storedItem)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			parser.table.stripRestrictedAttributes(ctx, item)
+
+			if !yield(item, nil) {
+				return
+			}
+		}
+	}
+}
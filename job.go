@@ -0,0 +1,305 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+// writeCapacityUnitSize is the item size covered by a single write capacity unit.
+const writeCapacityUnitSize = 1024
+
+// JobTransform transforms a single item of a job. The item is given with all table-level
+// transformations reversed, as returned by reads. The transform returns the updated item and
+// whether it should be written. Since a restarted job may transform an item again, transforms
+// should be idempotent.
+type JobTransform func(ctx context.Context,
+	item map[string]*dynamodb.AttributeValue) (map[string]*dynamodb.AttributeValue, bool, error)
+
+// JobProgress reports the progress of a running job. ItemsSkipped counts transformed items of
+// unversioned tables that were not written because they have too many attributes to check that
+// they are unchanged since they were scanned.
+type JobProgress struct {
+	ItemsScanned     int64
+	ItemsMatched     int64
+	ItemsUpdated     int64
+	ItemsSkipped     int64
+	ConsumedCapacity float64
+}
+
+// Job is a resumable job that transforms all items of a table matching a filter, such as for
+// anonymization or attribute backfills.
+type Job struct {
+	name             string
+	table            *Table
+	filter           *expression.ConditionBuilder
+	transform        JobTransform
+	concurrency      int
+	capacityBudget   float64
+	checkpoints      CheckpointStore
+	progressReporter func(JobProgress)
+}
+
+// NewJob instantiates a named job applying the transform to all items of the table. The job scans
+// with a concurrency of 1 and no capacity budget by default.
+func (table *Table) NewJob(name string, transform JobTransform) *Job {
+	return &Job{
+		name:        name,
+		table:       table,
+		transform:   transform,
+		concurrency: 1,
+	}
+}
+
+// WithFilter restricts the job to items matching the filter, using attribute names as stored in
+// the table.
+func (job *Job) WithFilter(filter expression.ConditionBuilder) *Job {
+	job.filter = &filter
+	return job
+}
+
+// WithConcurrency sets the number of table segments processed in parallel. A job resumed from
+// checkpoints keeps the concurrency it was started with until it completes.
+func (job *Job) WithConcurrency(concurrency int) *Job {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	job.concurrency = concurrency
+	return job
+}
+
+// WithCapacityBudget limits the capacity units consumed per second by the job across all segments.
+// Reads are charged by the capacity consumed by each scan page, and writes are charged by the
+// size of each written item.
+func (job *Job) WithCapacityBudget(unitsPerSecond float64) *Job {
+	job.capacityBudget = unitsPerSecond
+	return job
+}
+
+// WithCheckpoints saves progress of each segment in the checkpoint store, so that the job can be
+// safely restarted after an interruption and resumes where it left off. Checkpoints are keyed by
+// the job name and segment.
+func (job *Job) WithCheckpoints(checkpoints CheckpointStore) *Job {
+	job.checkpoints = checkpoints
+	return job
+}
+
+// WithProgressReporter sets a function called with the job's cumulative progress after each page
+// of items is processed. The reporter may be called concurrently from multiple segments.
+func (job *Job) WithProgressReporter(reporter func(JobProgress)) *Job {
+	job.progressReporter = reporter
+	return job
+}
+
+// Run executes the job to completion, returning its final progress. Items are only written if
+// they are unchanged since they were scanned, so that concurrent writes are not overwritten and
+// items deleted while the job runs are not recreated.
+func (job *Job) Run(ctx context.Context) (JobProgress, error) {
+	table := job.table
+	progress := JobProgress{}
+
+	if err := table.loadIndexMetadata(ctx); err != nil {
+		return progress, err
+	}
+
+	segments, err := job.resumedSegments(ctx)
+	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
+		return progress, err
+	}
+
+	// schedule job requests behind interactive traffic unless otherwise specified
+	if _, found := ctx.Value(priorityKey{}).(Priority); !found {
+		ctx = WithPriority(ctx, PriorityBatch)
+	}
+
+	table.logger.Printf("starting job \"%s\" on table \"%s\"\n", job.name, table.Name)
+
+	limiter := newRateLimiter(job.capacityBudget)
+	var capacityMu sync.Mutex
+
+	err = runSegments(ctx, segments, func(ctx context.Context, segment int) error {
+		return job.runSegment(ctx, segment, segments, limiter, &progress, &capacityMu)
+	})
+	if err != nil {
+		return progress, err
+	}
+
+	// reset the segment count once all segments are complete
+	if job.checkpoints != nil {
+		if err := job.checkpoints.SaveCheckpoint(ctx, job.name, ""); err != nil {
+			table.logger.Printf("error: %s\n", err.Error())
+			return progress, err
+		}
+	}
+
+	table.logger.Printf("job \"%s\" on table \"%s\" updated %d of %d items scanned\n",
+		job.name, table.Name, progress.ItemsUpdated, progress.ItemsScanned)
+
+	return progress, nil
+}
+
+// resumedSegments returns the number of segments scanned by the job. A job resumed from
+// checkpoints keeps the segment count it was started with, since scan cursors of a segment are
+// only valid for the same segment count.
+func (job *Job) resumedSegments(ctx context.Context) (int, error) {
+	if job.checkpoints == nil {
+		return job.concurrency, nil
+	}
+
+	saved, found, err := job.checkpoints.LoadCheckpoint(ctx, job.name)
+	if err != nil {
+		return 0, err
+	} else if !found || saved == "" {
+		return job.concurrency, job.checkpoints.SaveCheckpoint(ctx, job.name,
+			strconv.Itoa(job.concurrency))
+	}
+
+	segments, err := strconv.Atoi(saved)
+	if err != nil || segments < 1 {
+		return 0, fmt.Errorf("job \"%s\" has invalid segment count checkpoint \"%s\"",
+			job.name, saved)
+	}
+	if segments != job.concurrency {
+		job.table.logger.Printf("resuming job \"%s\" with its original concurrency of %d\n",
+			job.name, segments)
+	}
+	return segments, nil
+}
+
+func (job *Job) runSegment(ctx context.Context, segment, segments int, limiter *rateLimiter,
+	progress *JobProgress, capacityMu *sync.Mutex) error {
+
+	table := job.table
+
+	addCapacity := func(units float64) {
+		capacityMu.Lock()
+		progress.ConsumedCapacity += units
+		capacityMu.Unlock()
+	}
+
+	scan := segmentScan{
+		segment:       segment,
+		totalSegments: segments,
+		filter:        job.filter,
+		checkpoints:   job.checkpoints,
+		checkpointID:  fmt.Sprintf("%s#%d", job.name, segment),
+	}
+
+	return table.scanSegment(ctx, scan, func(ctx context.Context, page *dynamodb.ScanOutput) error {
+		readUnits := consumedCapacityUnits(page.ConsumedCapacity)
+		addCapacity(readUnits)
+		if err := limiter.waitN(ctx, readUnits); err != nil {
+			return err
+		}
+
+		atomic.AddInt64(&progress.ItemsScanned, aws.Int64Value(page.ScannedCount))
+		atomic.AddInt64(&progress.ItemsMatched, int64(len(page.Items)))
+
+		for _, storedItem := range page.Items {
+			item, err := table.itemFromStore(storedItem)
+			if err != nil {
+				return err
+			}
+
+			updatedItem, write, err := job.transform(ctx, item)
+			if err != nil {
+				table.logger.Printf("error: %s\n", err.Error())
+				return err
+			} else if !write {
+				continue
+			}
+
+			writeUnits := float64((itemSize(updatedItem) + writeCapacityUnitSize - 1) /
+				writeCapacityUnitSize)
+			if err := limiter.waitN(ctx, writeUnits); err != nil {
+				return err
+			}
+
+			unchanged, checkable := table.unchangedCondition(storedItem)
+			if !checkable {
+				table.logger.Printf("skipping item with too many attributes to check for changes\n")
+				atomic.AddInt64(&progress.ItemsSkipped, 1)
+				continue
+			}
+
+			// the write conflicts with concurrent versioned writes of the item
+			if _, err := table.incrementItemVersion(updatedItem); err != nil {
+				return err
+			}
+
+			err = table.putItem(ctx, updatedItem, &unchanged, AuditOperationPut)
+			if awsErr, isAWSErr := err.(awserr.Error); isAWSErr &&
+				awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+				// item changed or deleted since it was scanned
+				table.logger.Printf("skipping item changed since it was scanned\n")
+				continue
+			} else if err != nil {
+				return err
+			}
+			addCapacity(writeUnits)
+			atomic.AddInt64(&progress.ItemsUpdated, 1)
+		}
+
+		if job.progressReporter != nil {
+			capacityMu.Lock()
+			snapshot := JobProgress{
+				ItemsScanned:     atomic.LoadInt64(&progress.ItemsScanned),
+				ItemsMatched:     atomic.LoadInt64(&progress.ItemsMatched),
+				ItemsUpdated:     atomic.LoadInt64(&progress.ItemsUpdated),
+				ItemsSkipped:     atomic.LoadInt64(&progress.ItemsSkipped),
+				ConsumedCapacity: progress.ConsumedCapacity,
+			}
+			capacityMu.Unlock()
+			job.progressReporter(snapshot)
+		}
+
+		return nil
+	})
+}
+
+// maxUnchangedAttributes is the maximum number of attributes compared by an unchanged condition,
+// which keeps the condition within DynamoDB limits on expression size and operators.
+const maxUnchangedAttributes = 100
+
+// unchangedCondition returns a condition that an item still exists with the image it was read
+// with, as stored in the table. Only the version is compared on versioned tables. Otherwise the
+// values of all attributes of the image are compared, so attributes added to the item since it was
+// read are not detected, and false is returned if the image has more than maxUnchangedAttributes
+// attributes.
+func (table *Table) unchangedCondition(
+	storedItem map[string]*dynamodb.AttributeValue) (expression.ConditionBuilder, bool) {
+
+	partitionKey := table.allIndexes()[tablePrimaryIndexName].PartitionKey
+	condition := expression.AttributeExists(expression.Name(partitionKey))
+
+	if table.versionAttribute != "" {
+		versionName := expression.Name(table.storedName(table.versionAttribute))
+		if av, found := storedItem[table.storedName(table.versionAttribute)]; found {
+			return condition.And(versionName.Equal(expression.Value(av))), true
+		}
+		return condition.And(expression.AttributeNotExists(versionName)), true
+	}
+
+	if len(storedItem) > maxUnchangedAttributes {
+		return condition, false
+	}
+
+	names := make([]string, 0, len(storedItem))
+	for name := range storedItem {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		condition = condition.And(expression.Name(name).Equal(expression.Value(storedItem[name])))
+	}
+	return condition, true
+}
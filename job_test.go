@@ -0,0 +1,50 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestUnchangedConditionBounded(t *testing.T) {
+	cases := []struct {
+		name            string
+		attributes      int
+		versioned       bool
+		expectCheckable bool
+	}{
+		{name: "few attributes", attributes: 3, expectCheckable: true},
+		{name: "bounded attributes", attributes: maxUnchangedAttributes, expectCheckable: true},
+		{name: "too many attributes", attributes: maxUnchangedAttributes + 1},
+		{
+			name:            "too many attributes of versioned table",
+			attributes:      maxUnchangedAttributes + 1,
+			versioned:       true,
+			expectCheckable: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			table := newFakeTable(newFakeDynamoDB("items", "id", ""))
+			if tc.versioned {
+				table = table.WithVersionAttribute("version")
+			}
+			if err := table.loadIndexMetadata(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			item := map[string]*dynamodb.AttributeValue{}
+			for i := 0; i < tc.attributes; i++ {
+				item[fmt.Sprintf("a%d", i)] = &dynamodb.AttributeValue{S: aws.String("v")}
+			}
+
+			if _, checkable := table.unchangedCondition(item); checkable != tc.expectCheckable {
+				t.Errorf("expected checkable %t, got %t", tc.expectCheckable, checkable)
+			}
+		})
+	}
+}
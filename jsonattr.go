@@ -0,0 +1,145 @@
+package dynamodbfriend
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// jsonAttribute configures an attribute stored as an encoded JSON document.
+type jsonAttribute struct {
+	name     string
+	asBinary bool
+}
+
+// WithJSONAttribute stores the named attribute of items as a single JSON string attribute rather
+// than a nested map, such as for schemaless payloads alongside typed key attributes. The attribute
+// is encoded on write and decoded on read using encoding/json, so the value's json struct tags
+// apply within the document. The attribute must be a field of item structs, or a value of item
+// maps.
+func (table *Table) WithJSONAttribute(name string) *Table {
+	table.jsonAttributes = append(table.jsonAttributes, jsonAttribute{name: name})
+	return table
+}
+
+// WithBinaryJSONAttribute is like WithJSONAttribute, but stores the JSON document as a binary
+// attribute.
+func (table *Table) WithBinaryJSONAttribute(name string) *Table {
+	table.jsonAttributes = append(table.jsonAttributes, jsonAttribute{name: name, asBinary: true})
+	return table
+}
+
+// encodeJSONAttributes replaces the marshaled JSON attributes of an item with encoded JSON
+// documents of the corresponding item values.
+func (table *Table) encodeJSONAttributes(item interface{},
+	attrMap map[string]*dynamodb.AttributeValue) error {
+
+	for _, attribute := range table.jsonAttributes {
+		value, found := valueOfAttribute(reflect.ValueOf(item), attribute.name)
+		if !found {
+			continue
+		}
+
+		encoded, err := json.Marshal(value.Interface())
+		if err != nil {
+			return err
+		}
+
+		if attribute.asBinary {
+			attrMap[attribute.name] = &dynamodb.AttributeValue{B: encoded}
+		} else {
+			attrMap[attribute.name] = &dynamodb.AttributeValue{S: aws.String(string(encoded))}
+		}
+	}
+	return nil
+}
+
+// decodeJSONAttributes removes encoded JSON attributes from the item, returning the encoded
+// documents by attribute name, so that the remaining attributes may be unmarshaled. The item is
+// modified.
+func (table *Table) decodeJSONAttributes(
+	attrMap map[string]*dynamodb.AttributeValue) map[string][]byte {
+
+	documents := map[string][]byte{}
+	for _, attribute := range table.jsonAttributes {
+		av, found := attrMap[attribute.name]
+		if !found {
+			continue
+		}
+		switch {
+		case av.S != nil:
+			documents[attribute.name] = []byte(*av.S)
+		case av.B != nil:
+			documents[attribute.name] = av.B
+		default:
+			continue
+		}
+		delete(attrMap, attribute.name)
+	}
+	return documents
+}
+
+// unmarshalJSONDocuments decodes JSON documents into the corresponding values of an unmarshaled
+// item. The val must be a non-nil pointer.
+func unmarshalJSONDocuments(documents map[string][]byte, val interface{}) error {
+	v := reflect.ValueOf(val).Elem()
+	for name, document := range documents {
+		if v.Kind() == reflect.Map {
+			if v.IsNil() {
+				v.Set(reflect.MakeMap(v.Type()))
+			}
+			decoded := reflect.New(v.Type().Elem())
+			if err := json.Unmarshal(document, decoded.Interface()); err != nil {
+				return err
+			}
+			v.SetMapIndex(reflect.ValueOf(name).Convert(v.Type().Key()), decoded.Elem())
+			continue
+		}
+
+		field, found := valueOfAttribute(v, name)
+		if !found || !field.CanAddr() {
+			continue
+		}
+		if err := json.Unmarshal(document, field.Addr().Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// valueOfAttribute finds the value of an item struct or map corresponding to the named attribute.
+func valueOfAttribute(v reflect.Value, name string) (reflect.Value, bool) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}, false
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return reflect.Value{}, false
+		}
+		value := v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+		return value, value.IsValid()
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			fieldName, inline, skip := attributeNameOfField(t.Field(i))
+			if skip {
+				continue
+			} else if inline {
+				if value, found := valueOfAttribute(v.Field(i), name); found {
+					return value, true
+				}
+			} else if fieldName == name {
+				return v.Field(i), true
+			}
+		}
+	}
+
+	return reflect.Value{}, false
+}
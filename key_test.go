@@ -0,0 +1,80 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+type structKeyItem struct {
+	ID    string `dynamodbav:"id"`
+	Sort  string `dynamodbav:"sort"`
+	Name  string `dynamodbav:"name"`
+	Count int    `dynamodbav:"count"`
+}
+
+func TestStructKeySendsOnlyPrimaryKey(t *testing.T) {
+	key := structKeyItem{ID: "a", Sort: "1", Name: "not a key", Count: 3}
+	expectedKey := stringItem(map[string]string{"id": "a", "sort": "1"})
+
+	cases := []struct {
+		name string
+		call func(ctx context.Context, table *Table) error
+		sent func(fake *fakeDynamoDB) map[string]*dynamodb.AttributeValue
+	}{
+		{
+			name: "Get",
+			call: func(ctx context.Context, table *Table) error {
+				var item structKeyItem
+				return table.Get(ctx, key, &item)
+			},
+			sent: func(fake *fakeDynamoDB) map[string]*dynamodb.AttributeValue {
+				return fake.getInputs[0].Key
+			},
+		},
+		{
+			name: "Update",
+			call: func(ctx context.Context, table *Table) error {
+				return table.Update(ctx, key, NewUpdate().Set("name", "updated"))
+			},
+			sent: func(fake *fakeDynamoDB) map[string]*dynamodb.AttributeValue {
+				return fake.updateInputs[0].Key
+			},
+		},
+		{
+			name: "Delete",
+			call: func(ctx context.Context, table *Table) error {
+				return table.Delete(ctx, key)
+			},
+			sent: func(fake *fakeDynamoDB) map[string]*dynamodb.AttributeValue {
+				return fake.deleteInputs[0].Key
+			},
+		},
+		{
+			name: "DeleteIf",
+			call: func(ctx context.Context, table *Table) error {
+				return table.DeleteIf(ctx, key, NewDelete().Where("count").GreaterThan(1))
+			},
+			sent: func(fake *fakeDynamoDB) map[string]*dynamodb.AttributeValue {
+				return fake.deleteInputs[0].Key
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := newFakeDynamoDB("items", "id", "sort")
+			fake.putItems(stringItem(map[string]string{"id": "a", "sort": "1", "name": "stored"}))
+			table := newFakeTable(fake)
+
+			if err := tc.call(context.Background(), table); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if sent := tc.sent(fake); !reflect.DeepEqual(sent, expectedKey) {
+				t.Errorf("expected key %v, got %v", expectedKey, sent)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package dynamodbfriend
+
+import (
+	"fmt"
+	"strings"
+)
+
+// KeyTemplate describes a hierarchical key composed of components joined by a delimiter, such as
+// "ORDER#{year}#{month}#{day}". Components in braces are placeholders for values, and all other
+// components are literals that must be given as written.
+type KeyTemplate struct {
+	template   string
+	delimiter  string
+	components []string
+
+	// encodings of placeholder components, by placeholder
+	encodings map[string]KeyComponentEncoding
+	buildErr  error
+}
+
+// ErrKeyTemplateMismatch is returned when key components do not match a key template.
+type ErrKeyTemplateMismatch struct {
+	Template string
+	Reason   string
+}
+
+func (e ErrKeyTemplateMismatch) Error() string {
+	return fmt.Sprintf("key components do not match template \"%s\": %s", e.Template, e.Reason)
+}
+
+// ParseKeyTemplate parses a key template with components separated by delimiter.
+func ParseKeyTemplate(template, delimiter string) (*KeyTemplate, error) {
+	if delimiter == "" {
+		return nil, fmt.Errorf("key template delimiter must not be empty")
+	}
+
+	components := strings.Split(template, delimiter)
+	for _, component := range components {
+		if component == "" {
+			return nil, fmt.Errorf("key template \"%s\" has an empty component", template)
+		}
+	}
+
+	return &KeyTemplate{
+		template:   template,
+		delimiter:  delimiter,
+		components: components,
+		encodings:  map[string]KeyComponentEncoding{},
+	}, nil
+}
+
+// WithEncoding sets the encoding of values of the placeholder, such as "{seq}", for keys made with
+// KeyOf and PrefixOf and decoded with Values. If the template has no such placeholder, the error is
+// returned when keys are made or decoded.
+func (t *KeyTemplate) WithEncoding(placeholder string,
+	encoding KeyComponentEncoding) *KeyTemplate {
+
+	if !t.hasPlaceholder(placeholder) {
+		t.buildErr = t.mismatch("template has no placeholder %s", placeholder)
+		return t
+	}
+	t.encodings[placeholder] = encoding
+	return t
+}
+
+// Key returns the key with all components of the template.
+func (t *KeyTemplate) Key(components ...string) (string, error) {
+	if len(components) != len(t.components) {
+		return "", t.mismatch("template has %d components, got %d",
+			len(t.components), len(components))
+	}
+	return t.join(components)
+}
+
+// Prefix returns a prefix of keys beginning with the given leading components of the template,
+// for use with a begins with condition. The prefix of a partial key ends with the delimiter, so
+// that a prefix of "ORDER", "2024", "06" does not also match keys with a month of "061".
+func (t *KeyTemplate) Prefix(components ...string) (string, error) {
+	if len(components) == 0 || len(components) > len(t.components) {
+		return "", t.mismatch("prefix must have between 1 and %d components, got %d",
+			len(t.components), len(components))
+	}
+
+	prefix, err := t.join(components)
+	if err != nil {
+		return "", err
+	}
+	if len(components) < len(t.components) {
+		prefix += t.delimiter
+	}
+	return prefix, nil
+}
+
+// KeyOf returns the key with values of all components of the template. Values of placeholders with
+// an encoding are encoded with it, and all other values must be strings.
+func (t *KeyTemplate) KeyOf(values ...interface{}) (string, error) {
+	components, err := t.encode(values)
+	if err != nil {
+		return "", err
+	}
+	return t.Key(components...)
+}
+
+// PrefixOf returns a prefix of keys beginning with values of the given leading components of the
+// template, as with Prefix. Values are encoded as with KeyOf.
+func (t *KeyTemplate) PrefixOf(values ...interface{}) (string, error) {
+	components, err := t.encode(values)
+	if err != nil {
+		return "", err
+	}
+	return t.Prefix(components...)
+}
+
+// Values splits a key made from the template into the values of its components. Values of
+// placeholders with an encoding are decoded with it, and all other values are strings.
+func (t *KeyTemplate) Values(key string) ([]interface{}, error) {
+	if t.buildErr != nil {
+		return nil, t.buildErr
+	}
+
+	components := strings.Split(key, t.delimiter)
+	if len(components) != len(t.components) {
+		return nil, t.mismatch("template has %d components, key \"%s\" has %d",
+			len(t.components), key, len(components))
+	}
+	if _, err := t.join(components); err != nil {
+		return nil, err
+	}
+
+	values := make([]interface{}, len(components))
+	for i, component := range components {
+		encoding, found := t.encodings[t.components[i]]
+		if !found {
+			values[i] = component
+			continue
+		}
+		value, err := encoding.DecodeComponent(component)
+		if err != nil {
+			return nil, t.mismatch("component %s: %s", t.components[i], err.Error())
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// encode converts values of leading components of the template to strings.
+func (t *KeyTemplate) encode(values []interface{}) ([]string, error) {
+	if t.buildErr != nil {
+		return nil, t.buildErr
+	} else if len(values) > len(t.components) {
+		return nil, t.mismatch("template has %d components, got %d",
+			len(t.components), len(values))
+	}
+
+	components := make([]string, len(values))
+	for i, value := range values {
+		templateComponent := t.components[i]
+		if encoding, found := t.encodings[templateComponent]; found {
+			component, err := encoding.EncodeComponent(value)
+			if err != nil {
+				return nil, t.mismatch("component %s: %s", templateComponent, err.Error())
+			}
+			components[i] = component
+		} else if component, isString := value.(string); isString {
+			components[i] = component
+		} else {
+			return nil, t.mismatch("component %s has no encoding for value of type %T",
+				templateComponent, value)
+		}
+	}
+	return components, nil
+}
+
+func (t *KeyTemplate) hasPlaceholder(placeholder string) bool {
+	if !isPlaceholder(placeholder) {
+		return false
+	}
+	for _, component := range t.components {
+		if component == placeholder {
+			return true
+		}
+	}
+	return false
+}
+
+func isPlaceholder(component string) bool {
+	return strings.HasPrefix(component, "{") && strings.HasSuffix(component, "}")
+}
+
+// join validates components against the leading components of the template and joins them.
+func (t *KeyTemplate) join(components []string) (string, error) {
+	for i, component := range components {
+		templateComponent := t.components[i]
+
+		switch {
+		case !isPlaceholder(templateComponent) && component != templateComponent:
+			return "", t.mismatch("component %d must be \"%s\", got \"%s\"",
+				i, templateComponent, component)
+		case component == "":
+			return "", t.mismatch("component %s must not be empty", templateComponent)
+		case strings.Contains(component, t.delimiter):
+			return "", t.mismatch("component %s value \"%s\" contains delimiter \"%s\"",
+				templateComponent, component, t.delimiter)
+		}
+	}
+	return strings.Join(components, t.delimiter), nil
+}
+
+func (t *KeyTemplate) mismatch(format string, args ...interface{}) error {
+	return ErrKeyTemplateMismatch{
+		Template: t.template,
+		Reason:   fmt.Sprintf(format, args...),
+	}
+}
+
+// BeginsWithComponents is a conditional expression where the value associated with a query key
+// must begin with the prefix of a hierarchical key template given by its leading components. If
+// the components do not match the template, the error is returned when the query is made.
+func (k *QueryExprKey) BeginsWithComponents(template *KeyTemplate,
+	components ...string) *QueryExpr {
+
+	prefix, err := template.Prefix(components...)
+	if err != nil {
+		k.expr.logger.Printf("error: %s\n", err.Error())
+		k.expr.buildErr = err
+		return k.expr
+	}
+	return k.BeginsWith(prefix)
+}
+
+// BeginsWithValues is a conditional expression where the value associated with a query key must
+// begin with the prefix of a hierarchical key template given by values of its leading components,
+// encoded as with KeyTemplate.PrefixOf. If the values do not match the template, the error is
+// returned when the query is made.
+func (k *QueryExprKey) BeginsWithValues(template *KeyTemplate,
+	values ...interface{}) *QueryExpr {
+
+	prefix, err := template.PrefixOf(values...)
+	if err != nil {
+		k.expr.logger.Printf("error: %s\n", err.Error())
+		k.expr.buildErr = err
+		return k.expr
+	}
+	return k.BeginsWith(prefix)
+}
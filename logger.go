@@ -1,10 +1,31 @@
 package dynamodbfriend
 
-// Logger is an interface used by dynamodbfriend for all logging.
+import "log"
+
+// Logger is an interface used by dynamodbfriend for all logging. A *log.Logger from the standard
+// library may be used directly as a Logger.
 type Logger interface {
 	Printf(format string, v ...interface{})
 }
 
+var _ Logger = (*log.Logger)(nil)
+
+// LoggerFunc is an adapter allowing an ordinary printf-style function to be used as a Logger.
+type LoggerFunc func(format string, v ...interface{})
+
+// Printf calls f(format, v...).
+func (f LoggerFunc) Printf(format string, v ...interface{}) {
+	f(format, v...)
+}
+
+// NewStdLogger creates a Logger that writes to the standard library's default logger with the
+// specified prefix prepended to each message.
+func NewStdLogger(prefix string) Logger {
+	return LoggerFunc(func(format string, v ...interface{}) {
+		log.Printf(prefix+format, v...)
+	})
+}
+
 type nullLogger struct{}
 
 func (l nullLogger) Printf(_ string, _ ...interface{}) {}
@@ -0,0 +1,88 @@
+package dynamodbfriend
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// lruStore is a concurrency-safe store of expiring values that evicts the least recently used
+// values once its capacity is reached.
+type lruStore struct {
+	capacity int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+type lruEntry struct {
+	key     string
+	value   interface{}
+	expires time.Time
+}
+
+func newLRUStore(capacity int) *lruStore {
+	return &lruStore{
+		capacity: capacity,
+		entries:  map[string]*list.Element{},
+		order:    list.New(),
+	}
+}
+
+func (s *lruStore) Get(key string) (interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, found := s.entries[key]
+	if !found {
+		return nil, false
+	}
+
+	entry := element.Value.(*lruEntry)
+	if time.Now().After(entry.expires) {
+		s.removeElement(element)
+		return nil, false
+	}
+
+	s.order.MoveToFront(element)
+	return entry.value, true
+}
+
+func (s *lruStore) Set(key string, value interface{}, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := &lruEntry{
+		key:     key,
+		value:   value,
+		expires: time.Now().Add(ttl),
+	}
+
+	if element, found := s.entries[key]; found {
+		element.Value = entry
+		s.order.MoveToFront(element)
+		return
+	}
+
+	s.entries[key] = s.order.PushFront(entry)
+
+	// evict least recently used values beyond capacity
+	for s.order.Len() > s.capacity {
+		s.removeElement(s.order.Back())
+	}
+}
+
+func (s *lruStore) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, found := s.entries[key]; found {
+		s.removeElement(element)
+	}
+}
+
+func (s *lruStore) removeElement(element *list.Element) {
+	s.order.Remove(element)
+	delete(s.entries, element.Value.(*lruEntry).key)
+}
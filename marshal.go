@@ -0,0 +1,80 @@
+package dynamodbfriend
+
+import (
+	"reflect"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// marshalItem marshals an item to attribute values, applying proto and JSON attribute conversions
+// if enabled.
+func (table *Table) marshalItem(item interface{}) (map[string]*dynamodb.AttributeValue, error) {
+	attrMap, err := dynamodbattribute.MarshalMap(item)
+	if err != nil {
+		return nil, err
+	}
+
+	if table.protoSupport {
+		encodeProtoValues(reflect.ValueOf(item), &dynamodb.AttributeValue{M: attrMap})
+	}
+
+	if err := table.encodeJSONAttributes(item, attrMap); err != nil {
+		return nil, err
+	}
+	return attrMap, nil
+}
+
+// encodeItem encodes an item to be written to the table, using the newest codec registered for its
+// entity type if any, and otherwise marshaling it with marshalItem.
+func (table *Table) encodeItem(item interface{}) (map[string]*dynamodb.AttributeValue, error) {
+	if codecs := table.entityCodecsOf(item); codecs != nil {
+		return table.encodeWithCodec(codecs, item)
+	}
+	return table.marshalItem(item)
+}
+
+// unmarshalItem unmarshals attribute values into an item, reversing proto and JSON attribute
+// conversions if enabled. Items of entity types with registered codecs are decoded with the codec
+// named by the item. The attribute values are left unmodified.
+func (table *Table) unmarshalItem(attrMap map[string]*dynamodb.AttributeValue,
+	val interface{}) error {
+
+	return table.unmarshalItemChecked(attrMap, val, true)
+}
+
+// unmarshalItemChecked unmarshals attribute values into an item like unmarshalItem. Fields with no
+// attribute are only reported by strict unmarshaling if checkMissing is true, such as to skip
+// reporting fields not included in a selection of attributes.
+func (table *Table) unmarshalItemChecked(attrMap map[string]*dynamodb.AttributeValue,
+	val interface{}, checkMissing bool) error {
+
+	if codecs := table.entityCodecsOf(val); codecs != nil {
+		return table.decodeWithCodec(codecs, attrMap, val)
+	}
+
+	if err := table.checkStrictUnmarshal(attrMap, val, checkMissing); err != nil {
+		return err
+	}
+
+	var documents map[string][]byte
+	if len(table.jsonAttributes) > 0 {
+		attrMap = copyItem(attrMap)
+		documents = table.decodeJSONAttributes(attrMap)
+	}
+
+	if table.protoSupport {
+		attrMap = copyItem(attrMap)
+		av := &dynamodb.AttributeValue{M: attrMap}
+		if err := decodeProtoValues(reflect.TypeOf(val), av); err != nil {
+			return err
+		}
+		attrMap = av.M
+	}
+
+	if err := dynamodbattribute.UnmarshalMap(attrMap, val); err != nil {
+		return err
+	}
+
+	return unmarshalJSONDocuments(documents, val)
+}
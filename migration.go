@@ -0,0 +1,142 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+// Migration transforms an item from one schema version to the next.
+type Migration func(item map[string]*dynamodb.AttributeValue) (map[string]*dynamodb.AttributeValue, error)
+
+// WithSchemaVersion enables lazy schema migration on reads from the table. The version of each
+// item is stored in the named attribute, with items missing the attribute treated as version 0.
+// Items read with a version older than currentVersion are migrated in memory using migrations
+// registered with RegisterMigration.
+func (table *Table) WithSchemaVersion(versionAttribute string, currentVersion int) *Table {
+	table.schemaVersionAttribute = versionAttribute
+	table.currentSchemaVersion = currentVersion
+	return table
+}
+
+// RegisterMigration registers a migration from fromVersion to fromVersion+1.
+func (table *Table) RegisterMigration(fromVersion int, migration Migration) *Table {
+	if table.migrations == nil {
+		table.migrations = map[int]Migration{}
+	}
+	table.migrations[fromVersion] = migration
+	return table
+}
+
+// WithMigrationWriteBack sets whether migrated items are written back to the table
+// asynchronously. Write-backs are conditional on the stored item being unchanged since it was
+// read, as with the item transforms of a Job, and failures are logged.
+func (table *Table) WithMigrationWriteBack(writeBack bool) *Table {
+	table.migrationWriteBack = writeBack
+	return table
+}
+
+// migrateItem migrates an item read from the table to the current schema version. The item as
+// stored in the table is written back with the migrated item, if applicable.
+func (table *Table) migrateItem(item, storedItem map[string]*dynamodb.AttributeValue) (
+	map[string]*dynamodb.AttributeValue, error) {
+
+	if table.schemaVersionAttribute == "" {
+		return item, nil
+	}
+
+	originalVersion, err := table.itemSchemaVersion(item)
+	if err != nil {
+		return nil, err
+	}
+
+	version := originalVersion
+	for version < table.currentSchemaVersion {
+		migration, found := table.migrations[version]
+		if !found {
+			err := fmt.Errorf("no migration registered from schema version %d", version)
+			table.logger.Printf("error: %s\n", err.Error())
+			return nil, err
+		}
+
+		item, err = migration(item)
+		if err != nil {
+			table.logger.Printf("error: %s\n", err.Error())
+			return nil, err
+		}
+
+		version++
+		item[table.schemaVersionAttribute] = &dynamodb.AttributeValue{
+			N: aws.String(strconv.Itoa(version)),
+		}
+	}
+
+	if version != originalVersion {
+		table.logger.Printf("migrated item from schema version %d to %d\n",
+			originalVersion, version)
+		if table.migrationWriteBack {
+			table.writeBackMigratedItem(item, storedItem, originalVersion)
+		}
+	}
+
+	return item, nil
+}
+
+func (table *Table) itemSchemaVersion(item map[string]*dynamodb.AttributeValue) (int, error) {
+	av, found := item[table.schemaVersionAttribute]
+	if !found || av.N == nil {
+		return 0, nil
+	}
+
+	version, err := strconv.Atoi(*av.N)
+	if err != nil {
+		err = fmt.Errorf("invalid schema version \"%s\": %s", *av.N, err)
+		table.logger.Printf("error: %s\n", err.Error())
+		return 0, err
+	}
+
+	return version, nil
+}
+
+func (table *Table) writeBackMigratedItem(item, storedItem map[string]*dynamodb.AttributeValue,
+	readVersion int) {
+
+	// metadata is loaded before the write-back starts, so that it is not loaded concurrently with
+	// the caller's use of the table
+	if err := table.loadIndexMetadata(context.Background()); err != nil {
+		table.logger.Printf("error: migration write-back failed: %s\n", err.Error())
+		return
+	}
+
+	// copy item so that the write does not modify the item returned to the caller
+	writeItem := map[string]*dynamodb.AttributeValue{}
+	for k, v := range item {
+		writeItem[k] = v
+	}
+
+	// the migrated item may only replace the image it was migrated from
+	versionName := expression.Name(table.storedName(table.schemaVersionAttribute))
+	versionCondition := versionName.Equal(expression.Value(readVersion))
+	if readVersion == 0 {
+		versionCondition = expression.Or(expression.AttributeNotExists(versionName),
+			versionCondition)
+	}
+	unchanged, checkable := table.unchangedCondition(storedItem)
+	if !checkable {
+		table.logger.Printf("skipping migration write-back of item with too many attributes to " +
+			"check for changes\n")
+		return
+	}
+	condition := unchanged.And(versionCondition)
+
+	go func() {
+		err := table.putItem(context.Background(), writeItem, &condition, AuditOperationMigrate)
+		if err != nil {
+			table.logger.Printf("error: migration write-back failed: %s\n", err.Error())
+		}
+	}()
+}
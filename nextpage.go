@@ -0,0 +1,162 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// PageInfo describes a single page of results read by NextPage.
+type PageInfo struct {
+	// Count is the number of items returned from the page.
+	Count int
+
+	// ScannedCount is the number of items evaluated by DynamoDB to read the page, before any
+	// filter was applied.
+	ScannedCount int
+
+	// LastEvaluatedKey is the key, as stored in the table, after which the page ended. It is empty
+	// if the page was the last page of results.
+	LastEvaluatedKey map[string]*dynamodb.AttributeValue
+}
+
+// NextPage reads the remaining items of the next page of query results into items, which must be
+// a non-nil pointer to a slice, to which the items are appended. If items of the current page
+// remain buffered from calls to Next, those items are returned rather than a new page. Pages may
+// be empty when a filter matches none of their items. Pages of queries of multiple partition key
+// values are returned as they arrive from any partition. NextPage avoids the overhead of calling
+// Next for each item, and returns ErrParsingComplete under the same conditions as Next.
+func (parser *QueryParser) NextPage(ctx context.Context, items interface{}) (*PageInfo, error) {
+	sliceValue, elemType, err := pageSliceOf(items)
+	if err != nil {
+		parser.expr.logger.Printf("error: %s\n", err.Error())
+		return nil, err
+	}
+
+	parsingComplete := func(reason string) error {
+		err := ErrParsingComplete{reason: reason}
+		parser.expr.logger.Printf("%s\n", err)
+		return err
+	}
+
+	if parser.closed {
+		return nil, parsingComplete("parser has been closed")
+	} else if err := ctx.Err(); err != nil {
+		return nil, err
+	} else if parser.totalLimitReached() {
+		return nil, parsingComplete("total limit has been reached")
+	}
+
+	if parser.currentBufferIndex == len(parser.bufferedItems) {
+		if parser.allItemsParsed() {
+			return nil, parsingComplete("all items have been parsed")
+		} else if parser.maxPaginationReached() {
+			return nil, parsingComplete("max pagination has been reached")
+		}
+
+		if err := parser.fetchNextPage(ctx); err != nil {
+			return nil, err
+		}
+
+		// the partition queries may all complete without delivering another page
+		if parser.partitionInputs != nil && parser.allItemsParsed() {
+			return nil, parsingComplete("all items have been parsed")
+		}
+	}
+
+	storedItems := parser.bufferedItems[parser.currentBufferIndex:]
+	if parser.expr.totalLimitSpecified {
+		if remaining := parser.expr.totalLimit - parser.itemsReturned; len(storedItems) > remaining {
+			storedItems = storedItems[:remaining]
+		}
+	}
+	parser.currentBufferIndex += len(storedItems)
+	parser.itemsReturned += len(storedItems)
+
+	for _, storedItem := range storedItems {
+		itemPtr := reflect.New(elemType)
+		if err := parser.decodeStoredItem(ctx, storedItem, itemPtr.Interface()); err != nil {
+			return nil, err
+		}
+		sliceValue.Set(reflect.Append(sliceValue, itemPtr.Elem()))
+	}
+
+	return &PageInfo{
+		Count:            len(storedItems),
+		ScannedCount:     parser.pageScannedCount,
+		LastEvaluatedKey: parser.lastEvaluatedKey,
+	}, nil
+}
+
+// NextPage reads the remaining items of the next page of scan results into items, as with
+// QueryParser.NextPage. Pages of a parallel scan are returned as they arrive from any segment, and
+// the last evaluated key is that of the page's segment.
+func (parser *ScanParser) NextPage(ctx context.Context, items interface{}) (*PageInfo, error) {
+	sliceValue, elemType, err := pageSliceOf(items)
+	if err != nil {
+		parser.expr.logger.Printf("error: %s\n", err.Error())
+		return nil, err
+	}
+
+	parsingComplete := func(reason string) error {
+		err := ErrParsingComplete{reason: reason}
+		parser.expr.logger.Printf("%s\n", err)
+		return err
+	}
+
+	if parser.closed {
+		return nil, parsingComplete("parser has been closed")
+	} else if err := ctx.Err(); err != nil {
+		if parser.stopSegments != nil {
+			parser.stopSegments()
+		}
+		return nil, err
+	}
+
+	if parser.currentBufferIndex == len(parser.bufferedItems) {
+		if parser.allItemsParsed() {
+			return nil, parsingComplete("all items have been parsed")
+		} else if parser.maxPaginationReached() {
+			return nil, parsingComplete("max pagination has been reached")
+		}
+
+		if err := parser.fetchNextPage(ctx); err != nil {
+			return nil, err
+		}
+
+		// the segments of a parallel scan may all complete without delivering another page
+		if parser.currentBufferIndex == len(parser.bufferedItems) && parser.allItemsParsed() {
+			return nil, parsingComplete("all items have been parsed")
+		}
+	}
+
+	storedItems := parser.bufferedItems[parser.currentBufferIndex:]
+	parser.currentBufferIndex += len(storedItems)
+
+	for _, storedItem := range storedItems {
+		itemPtr := reflect.New(elemType)
+		if err := parser.decodeStoredItem(ctx, storedItem, itemPtr.Interface()); err != nil {
+			return nil, err
+		}
+		sliceValue.Set(reflect.Append(sliceValue, itemPtr.Elem()))
+	}
+
+	return &PageInfo{
+		Count:            len(storedItems),
+		ScannedCount:     parser.pageScannedCount,
+		LastEvaluatedKey: parser.pageLastEvaluatedKey,
+	}, nil
+}
+
+// pageSliceOf returns the slice pointed to by items and the type of its elements.
+func pageSliceOf(items interface{}) (reflect.Value, reflect.Type, error) {
+	itemsValue := reflect.ValueOf(items)
+	if itemsValue.Kind() != reflect.Ptr || itemsValue.IsNil() ||
+		itemsValue.Elem().Kind() != reflect.Slice {
+		return reflect.Value{}, nil, fmt.Errorf("items must be a non-nil pointer to a slice")
+	}
+	sliceValue := itemsValue.Elem()
+	return sliceValue, sliceValue.Type().Elem(), nil
+}
@@ -0,0 +1,165 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Page is a single page of query results returned by QueryPage.
+type Page[T any] struct {
+	// Items are the items of the page, in query order.
+	Items []T
+
+	// NextCursor is passed to QueryPage to read the following page. It is empty if there are no
+	// more items.
+	NextCursor string
+
+	// HasMore is true if more items follow this page.
+	HasMore bool
+
+	// TotalEstimate estimates the number of items from the start of this page to the end of the
+	// query. The estimate is exact when HasMore is false.
+	TotalEstimate int
+}
+
+// QueryPage reads a single page of up to pageSize items of type T from a query on the table,
+// starting after the item identified by cursor, such as for list endpoints of an HTTP API. An
+// empty cursor starts from the beginning of the query, and pageSize must be positive. Cursors are
+// opaque URL-safe strings and are only valid with the same query expression. One item beyond the
+// page is read to determine whether more items follow.
+func QueryPage[T any](ctx context.Context, table *Table, expr *QueryExpr, cursor string,
+	pageSize int) (*Page[T], error) {
+
+	if pageSize <= 0 {
+		err := fmt.Errorf("page size must be positive, got %d", pageSize)
+		table.logger.Printf("error: %s\n", err.Error())
+		return nil, err
+	}
+
+	parser, err := table.Query(ctx, expr)
+	if err != nil {
+		return nil, err
+	}
+	defer parser.Close()
+
+	if parser.partitionInputs != nil {
+		return nil, parser.errPartitionCursor()
+	}
+
+	if cursor != "" {
+		startKey, err := decodeCursor(cursor)
+		if err != nil {
+			parser.expr.logger.Printf("error: %s\n", err.Error())
+			return nil, err
+		}
+		parser.startKey = startKey
+		parser.lastEvaluatedKey = startKey
+	}
+
+	// over-fetch by one item to determine whether more items follow
+	if parser.queryInput.Limit == nil && parser.queryInput.FilterExpression == nil {
+		parser.queryInput.Limit = aws.Int64(int64(pageSize + 1))
+	}
+
+	page := &Page[T]{Items: make([]T, 0, pageSize)}
+	var lastStoredItem map[string]*dynamodb.AttributeValue
+	for count := 0; count <= pageSize; count++ {
+		var item T
+		err := parser.Next(ctx, &item)
+		if _, parsingComplete := err.(ErrParsingComplete); parsingComplete {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		if count == pageSize {
+			page.HasMore = true
+			break
+		}
+
+		page.Items = append(page.Items, item)
+		lastStoredItem = parser.bufferedItems[parser.currentBufferIndex-1]
+	}
+
+	if page.HasMore {
+		page.NextCursor, err = encodeCursor(parser.cursorKeyOf(lastStoredItem))
+		if err != nil {
+			return nil, err
+		}
+		page.TotalEstimate = len(page.Items) + 1 + parser.EstimatedRemaining()
+	} else {
+		page.TotalEstimate = len(page.Items)
+	}
+
+	return page, nil
+}
+
+// Cursor returns a cursor identifying the position of the parser after the last item returned by
+// Next, which may be passed to QueryExpr.StartFrom to resume the query in a later request without
+// holding the parser in memory. Cursors are opaque URL-safe strings. An empty cursor is returned
+// once no items remain. Cursors are not supported by queries of multiple partition key values.
+func (parser *QueryParser) Cursor() (string, error) {
+	if parser.partitionInputs != nil {
+		return "", parser.errPartitionCursor()
+	} else if parser.closed {
+		return "", nil
+	}
+
+	var key map[string]*dynamodb.AttributeValue
+	if parser.currentBufferIndex > 0 {
+		key = parser.cursorKeyOf(parser.bufferedItems[parser.currentBufferIndex-1])
+	} else {
+		// no item of the current page has been returned, so resume from the start of the page
+		key = parser.queryInput.ExclusiveStartKey
+	}
+
+	noneBuffered := parser.currentBufferIndex == len(parser.bufferedItems)
+	if (noneBuffered && parser.allItemsParsed()) || len(key) == 0 {
+		return "", nil
+	}
+
+	cursor, err := encodeCursor(key)
+	if err != nil {
+		parser.expr.logger.Printf("error: %s\n", err.Error())
+		return "", err
+	}
+	return cursor, nil
+}
+
+// cursorKeyOf returns the key identifying a stored item's position in the queried index, which
+// includes the keys of both the index and the table.
+func (parser *QueryParser) cursorKeyOf(
+	storedItem map[string]*dynamodb.AttributeValue) map[string]*dynamodb.AttributeValue {
+
+	key := parser.table.primaryKeyOf(storedItem)
+	for _, keyName := range parser.index.getKeys() {
+		key[keyName] = storedItem[keyName]
+	}
+	return key
+}
+
+func encodeCursor(key map[string]*dynamodb.AttributeValue) (string, error) {
+	encoded, err := json.Marshal(key)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(encoded), nil
+}
+
+func decodeCursor(cursor string) (map[string]*dynamodb.AttributeValue, error) {
+	encoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor{Cursor: cursor}
+	}
+
+	key := map[string]*dynamodb.AttributeValue{}
+	if err := json.Unmarshal(encoded, &key); err != nil || len(key) == 0 {
+		return nil, ErrInvalidCursor{Cursor: cursor}
+	}
+	return key, nil
+}
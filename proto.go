@@ -0,0 +1,256 @@
+package dynamodbfriend
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Protobuf well-known types are detected by package path and method set, so that proto support
+// does not require a dependency on the protobuf module.
+const (
+	protoTimestampPkgPath = "google.golang.org/protobuf/types/known/timestamppb"
+	protoWrappersPkgPath  = "google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// protoTimestampLayout is a fixed-width RFC 3339 layout, so that stored timestamps sort in time
+// order when used as sort keys.
+const protoTimestampLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+// WithProtoSupport sets whether protobuf message fields of items are converted to natural
+// attribute values when written to and read from this table. With proto support enabled,
+// timestamppb.Timestamp values are stored as RFC 3339 strings in UTC, wrapperspb values are
+// stored as their wrapped value, and enum values are stored by name. Other message types are
+// stored as maps of their exported fields, as without proto support.
+func (table *Table) WithProtoSupport(enabled bool) *Table {
+	table.protoSupport = enabled
+	return table
+}
+
+func isProtoTimestamp(t reflect.Type) bool {
+	return t.Kind() == reflect.Ptr && t.Elem().PkgPath() == protoTimestampPkgPath &&
+		t.Elem().Name() == "Timestamp"
+}
+
+func isProtoWrapper(t reflect.Type) bool {
+	return t.Kind() == reflect.Ptr && t.Elem().PkgPath() == protoWrappersPkgPath &&
+		strings.HasSuffix(t.Elem().Name(), "Value")
+}
+
+func isProtoEnum(t reflect.Type) bool {
+	if t.Kind() != reflect.Int32 {
+		return false
+	}
+	for _, method := range []string{"Descriptor", "Number", "String", "Type"} {
+		if _, found := t.MethodByName(method); !found {
+			return false
+		}
+	}
+	return true
+}
+
+// encodeProtoValues replaces the marshaled forms of proto values within v with their natural
+// attribute values.
+func encodeProtoValues(v reflect.Value, av *dynamodb.AttributeValue) {
+	if !v.IsValid() || av == nil || aws.BoolValue(av.NULL) {
+		return
+	}
+
+	t := v.Type()
+	switch {
+	case isProtoTimestamp(t):
+		if v.IsNil() {
+			return
+		}
+		asTime := v.MethodByName("AsTime").Call(nil)[0].Interface().(time.Time)
+		*av = dynamodb.AttributeValue{S: aws.String(asTime.UTC().Format(protoTimestampLayout))}
+		return
+	case isProtoWrapper(t):
+		if v.IsNil() || av.M["Value"] == nil {
+			return
+		}
+		*av = *av.M["Value"]
+		return
+	case isProtoEnum(t):
+		*av = dynamodb.AttributeValue{S: aws.String(fmt.Sprint(v.Interface()))}
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			encodeProtoValues(v.Elem(), av)
+		}
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, inline, skip := attributeNameOfField(field)
+			if skip {
+				continue
+			} else if inline {
+				encodeProtoValues(v.Field(i), av)
+			} else {
+				encodeProtoValues(v.Field(i), av.M[name])
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len() && i < len(av.L); i++ {
+			encodeProtoValues(v.Index(i), av.L[i])
+		}
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return
+		}
+		for _, key := range v.MapKeys() {
+			encodeProtoValues(v.MapIndex(key), av.M[key.String()])
+		}
+	}
+}
+
+// decodeProtoValues replaces the natural attribute values of proto values within type t with
+// the forms expected by the unmarshaler. Attribute values are replaced rather than modified.
+func decodeProtoValues(t reflect.Type, av *dynamodb.AttributeValue) error {
+	if av == nil || aws.BoolValue(av.NULL) {
+		return nil
+	}
+
+	switch {
+	case isProtoTimestamp(t):
+		if av.S == nil {
+			return nil
+		}
+		parsed, err := time.Parse(time.RFC3339Nano, *av.S)
+		if err != nil {
+			return err
+		}
+		*av = dynamodb.AttributeValue{M: map[string]*dynamodb.AttributeValue{
+			"Seconds": {N: aws.String(strconv.FormatInt(parsed.Unix(), 10))},
+			"Nanos":   {N: aws.String(strconv.Itoa(parsed.Nanosecond()))},
+		}}
+		return nil
+	case isProtoWrapper(t):
+		wrapped := *av
+		*av = dynamodb.AttributeValue{M: map[string]*dynamodb.AttributeValue{"Value": &wrapped}}
+		return nil
+	case isProtoEnum(t):
+		if av.S == nil {
+			return nil
+		}
+		number, err := protoEnumNumber(t, *av.S)
+		if err != nil {
+			return err
+		}
+		*av = dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(number, 10))}
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return decodeProtoValues(t.Elem(), av)
+	case reflect.Struct:
+		if av.M == nil {
+			return nil
+		}
+		av.M = copyItem(av.M)
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, inline, skip := attributeNameOfField(field)
+			if skip {
+				continue
+			} else if inline {
+				if err := decodeProtoValues(field.Type, av); err != nil {
+					return err
+				}
+			} else if fieldAV, found := av.M[name]; found {
+				fieldAV = copyAttributeValue(fieldAV)
+				if err := decodeProtoValues(field.Type, fieldAV); err != nil {
+					return err
+				}
+				av.M[name] = fieldAV
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		list := make([]*dynamodb.AttributeValue, len(av.L))
+		for i, elemAV := range av.L {
+			list[i] = copyAttributeValue(elemAV)
+			if err := decodeProtoValues(t.Elem(), list[i]); err != nil {
+				return err
+			}
+		}
+		av.L = list
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String || av.M == nil {
+			return nil
+		}
+		av.M = copyItem(av.M)
+		for key, elemAV := range av.M {
+			elemAV = copyAttributeValue(elemAV)
+			if err := decodeProtoValues(t.Elem(), elemAV); err != nil {
+				return err
+			}
+			av.M[key] = elemAV
+		}
+	}
+
+	return nil
+}
+
+// protoEnumNumber looks up the number of an enum value by name using the enum's descriptor.
+func protoEnumNumber(t reflect.Type, name string) (int64, error) {
+	values := reflect.Zero(t).MethodByName("Descriptor").Call(nil)[0].
+		MethodByName("Values").Call(nil)[0]
+	byName := values.MethodByName("ByName")
+	valueDescriptor := byName.Call([]reflect.Value{
+		reflect.ValueOf(name).Convert(byName.Type().In(0)),
+	})[0]
+	if valueDescriptor.IsNil() {
+		return 0, fmt.Errorf("unknown value \"%s\" of enum %s", name, t)
+	}
+	return valueDescriptor.MethodByName("Number").Call(nil)[0].Int(), nil
+}
+
+// attributeNameOfField returns the attribute name of a struct field following the tag rules of
+// the dynamodbattribute package. Inline is true for embedded structs whose fields are marshaled
+// into the parent item, and skip is true for fields that are not marshaled.
+func attributeNameOfField(field reflect.StructField) (name string, inline, skip bool) {
+	tag := field.Tag.Get("dynamodbav")
+	if tag == "" {
+		tag = field.Tag.Get("json")
+	}
+	name = strings.Split(tag, ",")[0]
+	if name == "-" {
+		return "", false, true
+	}
+
+	if field.Anonymous && name == "" {
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() == reflect.Struct {
+			return "", true, false
+		}
+	}
+
+	if field.PkgPath != "" {
+		return "", false, true
+	}
+
+	if name == "" {
+		name = field.Name
+	}
+	return name, false, false
+}
+
+func copyAttributeValue(av *dynamodb.AttributeValue) *dynamodb.AttributeValue {
+	if av == nil {
+		return nil
+	}
+	copied := *av
+	return &copied
+}
@@ -2,23 +2,135 @@ package dynamodbfriend
 
 import (
 	"context"
+	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
-	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
 )
 
 // Put puts an item into the table. The item should have all attributes to be included in the
-// table item tagged with the "dynamodbav" struct tag.
+// table item tagged with the "dynamodbav" struct tag. An existing item with the same primary key
+// is overwritten; use PutIf for conditional writes.
 func (table *Table) Put(ctx context.Context, item interface{}) error {
-	attrMap, err := dynamodbattribute.MarshalMap(item)
+	attrMap, err := table.encodeItem(item)
 	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
 		return err
 	}
 
-	_, err = table.baseClient.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+	if err := table.checkProtectedAttributes(attrMap); err != nil {
+		return err
+	}
+
+	if table.versionAttribute != "" {
+		return table.putVersioned(ctx, item, attrMap, nil)
+	}
+
+	return table.putItem(ctx, attrMap, nil, AuditOperationPut)
+}
+
+func (table *Table) putItem(ctx context.Context, attrMap map[string]*dynamodb.AttributeValue,
+	condition *expression.ConditionBuilder, operation AuditOperation) error {
+
+	if err := table.prepareItemForWrite(ctx, attrMap); err != nil {
+		return err
+	}
+
+	putInput := &dynamodb.PutItemInput{
 		TableName: &table.Name,
 		Item:      attrMap,
-	})
+	}
+
+	// apply condition expression, if specified
+	if condition != nil {
+		dbExpr, err := expression.NewBuilder().WithCondition(*condition).Build()
+		if err != nil {
+			table.logger.Printf("error: %s\n", err.Error())
+			return err
+		}
+		putInput.ConditionExpression = dbExpr.Condition()
+		putInput.ExpressionAttributeNames = dbExpr.Names()
+		putInput.ExpressionAttributeValues = dbExpr.Values()
+
+		err = table.validateExpressions(dbExpr.Names(),
+			expressionPart{kind: "condition", expression: dbExpr.Condition()})
+		if err != nil {
+			return err
+		}
+	}
+
+	// primary key is needed for auditing, cache invalidation, and deduplication, if applicable
+	if table.auditor != nil || table.itemCache != nil || table.writeDedup != nil {
+		if err := table.loadIndexMetadata(ctx); err != nil {
+			return err
+		}
+	}
+	key := table.primaryKeyOf(attrMap)
+
+	// skip unconditional writes of unchanged items, if applicable
+	var imageHash string
+	if condition == nil {
+		var isDuplicate bool
+		isDuplicate, imageHash = table.isDuplicateWrite(key, attrMap)
+		if isDuplicate {
+			table.logger.Printf("skipping put of unchanged item in table \"%s\"\n", table.Name)
+			return nil
+		}
+	}
+
+	// request old image for auditing, if applicable
+	if table.auditor != nil {
+		putInput.ReturnValues = aws.String(dynamodb.ReturnValueAllOld)
+	}
+
+	putInput.ReturnConsumedCapacity = table.returnConsumedCapacity()
+
+	start := time.Now()
+	putOutput, err := table.baseClient.PutItemWithContext(ctx, putInput)
+
+	stats := OperationStats{
+		Operation: "PutItem",
+		Latency:   time.Since(start),
+		Items:     1,
+		Err:       err,
+	}
+	if putOutput != nil {
+		stats.ConsumedCapacity = consumedCapacityUnits(putOutput.ConsumedCapacity)
+	}
+	table.emitStats(ctx, stats)
+
+	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
+	} else {
+		table.invalidateItem(key)
+		table.recordWrite(key, imageHash)
+	}
+
+	event := AuditEvent{
+		Operation: operation,
+		Key:       key,
+		NewImage:  attrMap,
+		Err:       err,
+	}
+	if putOutput != nil {
+		event.OldImage = putOutput.Attributes
+	}
+	table.audit(ctx, event)
 
 	return err
 }
+
+// prepareItemForWrite applies all table-level transformations to an item before it is written.
+func (table *Table) prepareItemForWrite(ctx context.Context,
+	attrMap map[string]*dynamodb.AttributeValue) error {
+
+	if err := table.applyDerivedAttributes(attrMap); err != nil {
+		return err
+	}
+	table.applySparseAttributes(attrMap)
+
+	table.applyAliases(attrMap)
+
+	return table.applyTenantPrefix(ctx, attrMap)
+}
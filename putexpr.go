@@ -0,0 +1,103 @@
+package dynamodbfriend
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+// PutExpr is an expression of conditions that must be met for an item to be written with
+// Table.PutIf. Conditions are evaluated against the existing item with the same primary key.
+type PutExpr struct {
+	conditions writeConditions
+
+	loggerSpecified bool
+	logger          Logger
+}
+
+// PutExprKey is a partially-formed put expression.
+//
+// To make a fully-formed put expression, the key part must be followed by a conditional.
+type PutExprKey = ConditionKey[*PutExpr]
+
+// NewPut begins a new put expression.
+func NewPut() *PutExpr {
+	return &PutExpr{
+		logger: nullLogger{},
+	}
+}
+
+// IfNotExists adds a condition that no item with the same primary key exists in the table. The
+// partition key is the name of the table's partition key attribute.
+func (expr *PutExpr) IfNotExists(partitionKey string) *PutExpr {
+	return expr.Where(partitionKey).NotExists()
+}
+
+// Where begins a condition on the named attribute of the existing item. Multiple conditions must
+// all be met.
+func (expr *PutExpr) Where(key string) *PutExprKey {
+	return newWriteConditionKey(expr, key, &expr.conditions, &expr.logger)
+}
+
+// WithCondition adds a condition that must be met by the existing item. Attribute names in the
+// condition are used as stored in the table, and are not translated by attribute aliases.
+func (expr *PutExpr) WithCondition(condition expression.ConditionBuilder) *PutExpr {
+	expr.conditions.raw = append(expr.conditions.raw, condition)
+	return expr
+}
+
+// WithLogger sets the logger used when putting with this expression. If not set, the table's
+// logger is used.
+func (expr *PutExpr) WithLogger(logger Logger) *PutExpr {
+	expr.loggerSpecified = true
+	expr.logger = logger
+	return expr
+}
+
+// condition returns the combined condition of the expression on attribute names as stored in the
+// table, or nil if the expression has no conditions.
+func (expr *PutExpr) condition(
+	storedName func(string) string) (*expression.ConditionBuilder, error) {
+
+	return expr.conditions.condition(storedName)
+}
+
+// PutIf puts an item into the table if all conditions of the put expression are met.
+// ErrConditionFailed is returned if the conditions are not met, such as when an item already
+// exists for a put with IfNotExists.
+func (table *Table) PutIf(ctx context.Context, item interface{}, expr *PutExpr) error {
+	// fall back to table logger if no logger is set on the expression
+	if !expr.loggerSpecified {
+		expr.logger = table.logger
+	}
+
+	attrMap, err := table.encodeItem(item)
+	if err != nil {
+		expr.logger.Printf("error: %s\n", err.Error())
+		return err
+	}
+
+	if err := table.checkProtectedAttributes(attrMap); err != nil {
+		return err
+	}
+
+	condition, err := expr.condition(table.storedName)
+	if err != nil {
+		return err
+	}
+	if table.versionAttribute != "" {
+		err = table.putVersioned(ctx, item, attrMap, condition)
+	} else {
+		err = table.putItem(ctx, attrMap, condition, AuditOperationPut)
+	}
+	if awsErr, isAWSErr := err.(awserr.Error); isAWSErr &&
+		awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+
+		err = ErrConditionFailed{TableName: table.Name}
+		expr.logger.Printf("error: %s\n", err.Error())
+	}
+
+	return err
+}
@@ -4,34 +4,247 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
 )
 
 // Query returns a new QueryParser that may be used to retrieve query results.
 func (table *Table) Query(ctx context.Context, expr *QueryExpr) (*QueryParser, error) {
+	// fall back to table logger if no logger is set on the expression
+	if !expr.loggerSpecified {
+		expr.logger = table.logger
+	}
+
 	if expr.buildErr != nil {
 		return nil, expr.buildErr
 	}
 
-	queryIndex, err := table.chooseIndex(ctx, expr)
+	expr, err := table.timeEncodedExpr(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	expr = table.aliasedExpr(expr)
+
+	expr, queryIndex, plan, err := table.chooseIndexWithPlanCache(ctx, expr)
 	if err != nil {
 		return nil, err
 	}
 
-	queryInput, err := expr.constructQueryInputGivenIndex(queryIndex)
+	opts, err := table.queryOptions(ctx, expr.includeDeleted)
 	if err != nil {
 		return nil, err
 	}
 
-	return &QueryParser{
-		table:         table,
-		expr:          expr,
-		queryInput:    queryInput,
-		bufferedItems: []map[string]*dynamodb.AttributeValue{},
-	}, nil
+	// query only keys from the index of a hydrated query, and read items from the table
+	inputExpr := expr
+	if expr.hydrate {
+		inputExpr = table.keysOnlyExpr(expr, queryIndex)
+	}
+
+	// query each value of a partition key IN condition separately
+	var partitionInputs []*dynamodb.QueryInput
+	partitionFilter, _ := expr.filterOn(queryIndex.PartitionKey)
+	if inValues, isIn := partitionFilter.(*inFilter); isIn &&
+		!expr.keyConditionSpecified {
+
+		partitionInputs, err = inputExpr.partitionQueryInputs(queryIndex, inValues, opts)
+		if err != nil {
+			return nil, err
+		}
+		expr.logger.Printf("querying %d values of partition key \"%s\" concurrently\n",
+			len(partitionInputs), queryIndex.PartitionKey)
+	}
+
+	var queryInput *dynamodb.QueryInput
+	if partitionInputs != nil {
+		queryInput = partitionInputs[0]
+	} else if queryInput, err = inputExpr.constructQueryInputGivenIndex(queryIndex,
+		opts); err != nil {
+		return nil, err
+	}
+
+	// inputs of a cached plan's shape are validated once, unless built differently
+	if plan == nil || !plan.validatedInput(queryInput) {
+		// the sort key condition of a raw key condition is not known
+		var sortKeyCondition *KeyConditionOp
+		if !expr.keyConditionSpecified {
+			op := expr.sortKeyConditionOf(queryIndex)
+			sortKeyCondition = &op
+		}
+		if err := table.checkQueryPattern(queryIndex.Name, sortKeyCondition); err != nil {
+			return nil, err
+		}
+
+		if err := table.validateQueryInput(queryInput); err != nil {
+			return nil, err
+		}
+
+		if plan != nil {
+			plan.template.Store(newQueryInputTemplate(queryInput))
+		}
+	}
+
+	expr.logger.Printf("query key condition: %s\n", table.describeExpression(
+		queryInput.KeyConditionExpression, queryInput.ExpressionAttributeNames,
+		queryInput.ExpressionAttributeValues))
+	if queryInput.FilterExpression != nil {
+		expr.logger.Printf("query filter: %s\n", table.describeExpression(
+			queryInput.FilterExpression, queryInput.ExpressionAttributeNames,
+			queryInput.ExpressionAttributeValues))
+	}
+
+	if expr.startKey != nil {
+		queryInput.ExclusiveStartKey = expr.startKey
+	}
+
+	parser := newQueryParser(table, queryIndex, expr, queryInput)
+
+	// consistent reads must be made against the primary region
+	if !expr.consistentRead {
+		parser.readClient = table.readClient(ctx)
+	}
+
+	if len(partitionInputs) > 1 {
+		parser.partitionCtx = ctx
+		parser.partitionInputs = partitionInputs
+	}
+
+	return parser, nil
+}
+
+// QueryRaw returns a new QueryParser for a hand-built query input. Index selection and
+// expression building are skipped, but results are paginated and unmarshaled as with Query. If the
+// input's table name is not set, the table's name is used. Raw query inputs are not supported
+// with tenant isolation, since their key conditions cannot be restricted to the tenant.
+func (table *Table) QueryRaw(ctx context.Context,
+	queryInput *dynamodb.QueryInput) (*QueryParser, error) {
+
+	if table.tenantPrefix() != "" {
+		err := fmt.Errorf("raw query inputs are not supported with tenant isolation")
+		table.logger.Printf("error: %s\n", err.Error())
+		return nil, err
+	}
+
+	if queryInput.TableName == nil {
+		queryInput.TableName = aws.String(table.Name)
+	}
+
+	if err := table.validateQueryInput(queryInput); err != nil {
+		return nil, err
+	}
+
+	expr := newQueryExpr()
+	expr.logger = table.logger
+
+	index := &tableIndex{
+		Name:      tablePrimaryIndexName,
+		TableName: aws.StringValue(queryInput.TableName),
+	}
+	if queryInput.IndexName != nil {
+		index.Name = *queryInput.IndexName
+	}
+	expr.logger.Printf("using raw query input on index: %s\n", index.Name)
+
+	if err := table.checkQueryPattern(index.Name, nil); err != nil {
+		return nil, err
+	}
+
+	return newQueryParser(table, index, expr, queryInput), nil
+}
+
+// validateQueryInput checks the expressions of a query input against DynamoDB limits.
+func (table *Table) validateQueryInput(queryInput *dynamodb.QueryInput) error {
+	return table.validateExpressions(queryInput.ExpressionAttributeNames,
+		expressionPart{kind: "key condition", expression: queryInput.KeyConditionExpression},
+		expressionPart{kind: "filter", expression: queryInput.FilterExpression},
+		expressionPart{kind: "projection", expression: queryInput.ProjectionExpression})
+}
+
+// tableQueryOptions holds table-level settings applied when constructing query inputs.
+type tableQueryOptions struct {
+	tenantPrefix         string
+	additionalConditions []expression.ConditionBuilder
+}
+
+func (table *Table) queryOptions(ctx context.Context,
+	includeDeleted bool) (tableQueryOptions, error) {
+
+	opts := tableQueryOptions{
+		tenantPrefix: table.tenantPrefix(),
+	}
+
+	// exclude soft-deleted items unless requested
+	if table.softDeleteAttribute != "" && !includeDeleted {
+		opts.additionalConditions = append(opts.additionalConditions,
+			expression.AttributeNotExists(expression.Name(table.softDeleteAttribute)))
+	}
+
+	// exclude expired items, if applicable
+	unexpiredCondition, err := table.unexpiredCondition(ctx)
+	if err != nil {
+		return opts, err
+	}
+	if unexpiredCondition != nil {
+		opts.additionalConditions = append(opts.additionalConditions, *unexpiredCondition)
+	}
+
+	return opts, nil
+}
+
+// chooseIndexWithReadPolicy chooses an index for the query, applying the table's default read
+// consistency if the expression does not specify read consistency. The returned expression should
+// be used for the query.
+func (table *Table) chooseIndexWithReadPolicy(ctx context.Context,
+	expr *QueryExpr) (*QueryExpr, *tableIndex, error) {
+
+	if table.consistentReads && !expr.consistentReadSpecified && !expr.keyConditionSpecified {
+		consistentExpr := *expr
+		consistentExpr.consistentRead = true
+
+		index, err := table.chooseIndex(ctx, &consistentExpr)
+		if err == nil {
+			return table.hydratedExprOn(&consistentExpr, index), index, nil
+		} else if _, noViableIndexes := err.(ErrNoViableIndexes); !noViableIndexes {
+			return nil, nil, err
+		}
+
+		expr.logger.Printf("warning: no viable index supports consistent read, " +
+			"downgrading query to eventually consistent read\n")
+	}
+
+	index, err := table.chooseIndex(ctx, expr)
+	if err != nil {
+		return nil, nil, err
+	}
+	return table.hydratedExprOn(expr, index), index, nil
+}
+
+// hydratedExprOn returns the expression marked for hydration if the chosen index does not project
+// the queried attributes.
+func (table *Table) hydratedExprOn(expr *QueryExpr, index *tableIndex) *QueryExpr {
+	if expr.keyConditionSpecified || table.indexProjectsQuery(expr, index) {
+		return expr
+	}
+	expr.logger.Printf("hydrating items of index \"%s\" from table\n", index.Name)
+	return hydratedExpr(expr, true)
 }
 
 func (table *Table) chooseIndex(ctx context.Context, expr *QueryExpr) (*tableIndex, error) {
+	// skip index selection if raw key condition is specified
+	if expr.keyConditionSpecified {
+		index := &tableIndex{
+			Name:      expr.keyConditionIndex,
+			TableName: table.Name,
+		}
+		if index.Name == "" {
+			index.Name = tablePrimaryIndexName
+		}
+		expr.logger.Printf("choosing index for query from raw key condition: %s\n", index.Name)
+		return index, nil
+	}
+
 	viableIndexNameSet, err := table.getViableQueryIndexes(ctx, expr)
 	if err != nil {
 		return nil, err
@@ -46,11 +259,11 @@ func (table *Table) chooseIndex(ctx context.Context, expr *QueryExpr) (*tableInd
 
 	priorityIndexNameSet := newNameSet()
 
-	getPriorityIndexesWithSortKeyOnFilterType := func(v interface{}) bool {
-		filterKeys := expr.getKeysOfFilterType(v)
+	getPriorityIndexesWithSortKeyOnFilterOp := func(op filterOp) bool {
+		filterKeys := expr.getKeysOfFilterOp(op)
 
 		for _, indexName := range viableIndexNameSet.Names() {
-			indexSortKey := table.allIndexes[indexName].SortKey
+			indexSortKey := table.allIndexes()[indexName].SortKey
 			if filterKeys.Contains(indexSortKey) {
 				priorityIndexNameSet.Insert(indexName)
 			}
@@ -64,9 +277,9 @@ func (table *Table) chooseIndex(ctx context.Context, expr *QueryExpr) (*tableInd
 	//	2) begins with
 	//	3) between
 	//	4) any viable index
-	priorityIndexesFound := getPriorityIndexesWithSortKeyOnFilterType(&equalsFilter{}) ||
-		getPriorityIndexesWithSortKeyOnFilterType(&beginsWithFilter{}) ||
-		getPriorityIndexesWithSortKeyOnFilterType(&betweenFilter{})
+	priorityIndexesFound := getPriorityIndexesWithSortKeyOnFilterOp(equalsOp) ||
+		getPriorityIndexesWithSortKeyOnFilterOp(beginsWithOp) ||
+		getPriorityIndexesWithSortKeyOnFilterOp(betweenOp)
 	if !priorityIndexesFound {
 		priorityIndexNameSet = viableIndexNameSet
 	}
@@ -76,15 +289,12 @@ func (table *Table) chooseIndex(ctx context.Context, expr *QueryExpr) (*tableInd
 	chosenIndexName := priorityIndexNameSet.Names()[0]
 	expr.logger.Printf("choosing index for query: %s\n", chosenIndexName)
 
-	return table.allIndexes[chosenIndexName], nil
+	return table.allIndexes()[chosenIndexName], nil
 }
 
 func (table *Table) getViableQueryIndexes(ctx context.Context, expr *QueryExpr) (*nameSet, error) {
-	// learn table indexes if not already known
-	if table.allIndexes == nil {
-		if err := table.fetchIndexMetadata(ctx); err != nil {
-			return nil, err
-		}
+	if err := table.loadIndexMetadata(ctx); err != nil {
+		return nil, err
 	}
 
 	viableIndexNameSet := table.indexNameSet()
@@ -93,7 +303,7 @@ func (table *Table) getViableQueryIndexes(ctx context.Context, expr *QueryExpr)
 
 	filterIndexNames := func(failedDescription string, validCondition func(index *tableIndex) bool) {
 		for _, indexName := range viableIndexNameSet.Names() {
-			index := table.allIndexes[indexName]
+			index := table.allIndexes()[indexName]
 			if !validCondition(index) {
 				var indexKeysStr string
 				if index.IsComposite {
@@ -111,12 +321,45 @@ func (table *Table) getViableQueryIndexes(ctx context.Context, expr *QueryExpr)
 		}
 	}
 
-	equalsFilterKeys := expr.getKeysOfFilterType(&equalsFilter{})
-	failedDescription := fmt.Sprintf("partition key not in equals filters: %s", equalsFilterKeys)
+	// inequality cannot be expressed in key conditions, so not equals filters never make an index
+	// viable and are always applied as filter conditions
+	if notEqualsFilterKeys := expr.getKeysOfFilterOp(notEqualsOp); !notEqualsFilterKeys.Empty() {
+		expr.logger.Printf("keys in not equals filters applied as filter conditions only: %s\n",
+			notEqualsFilterKeys)
+	}
+
+	// key conditions support a single condition per key, and DynamoDB does not allow filter
+	// conditions on index keys, so index keys may have at most one condition
+	multipleFilterKeys := expr.getKeysWithMultipleFilters()
+	if !multipleFilterKeys.Empty() {
+		failedDescription := fmt.Sprintf("index key has multiple conditions: %s",
+			multipleFilterKeys)
+		filterIndexNames(failedDescription, func(index *tableIndex) bool {
+			return !multipleFilterKeys.Contains(index.PartitionKey) &&
+				!(index.IsComposite && multipleFilterKeys.Contains(index.SortKey))
+		})
+	}
+
+	// a partition key in an IN filter is queried as one query per value
+	equalsFilterKeys := expr.getKeysOfFilterOp(equalsOp)
+	inFilterKeys := expr.getKeysOfFilterOp(inOp)
+	failedDescription := fmt.Sprintf("partition key not in equals or in filters: %s",
+		newNameSet(append(equalsFilterKeys.Names(), inFilterKeys.Names()...)...))
 	filterIndexNames(failedDescription, func(index *tableIndex) bool {
-		return equalsFilterKeys.Contains(index.PartitionKey)
+		return equalsFilterKeys.Contains(index.PartitionKey) ||
+			inFilterKeys.Contains(index.PartitionKey)
 	})
 
+	// prefer indexes queried with a single partition key value, if any
+	for _, indexName := range viableIndexNameSet.Names() {
+		if equalsFilterKeys.Contains(table.allIndexes()[indexName].PartitionKey) {
+			filterIndexNames("partition key in in filter", func(index *tableIndex) bool {
+				return equalsFilterKeys.Contains(index.PartitionKey)
+			})
+			break
+		}
+	}
+
 	// omit indexes that do not support consistent read, if applicable
 	if expr.consistentRead {
 		filterIndexNames("index does not support consistent read", func(index *tableIndex) bool {
@@ -133,19 +376,45 @@ func (table *Table) getViableQueryIndexes(ctx context.Context, expr *QueryExpr)
 		})
 	}
 
+	// omit indexes that do not project the attributes of filter groups, on which conditions would
+	// otherwise silently fail to match
+	if groupAttributes := expr.filterGroupAttributes(); len(groupAttributes) > 0 {
+		failedDescription := "index does not include all filter group attributes"
+		filterIndexNames(failedDescription, func(index *tableIndex) bool {
+			if index.IncludesAllAttributes {
+				return true
+			}
+			for _, attribute := range groupAttributes {
+				if _, found := index.AttributeSet[attribute]; !found {
+					return false
+				}
+			}
+			return true
+		})
+	}
+
+	// indexes not projecting the queried attributes may still be hydrated from the table
+	projectionCandidates := newNameSet(viableIndexNameSet.Names()...)
+
 	// omit indexes that do not include all requested attributes
+	rejections := []IndexRejection{}
 	if expr.attributesSpecified {
 		failedDescription := "index does not include all selected attributes"
 		filterIndexNames(failedDescription, func(index *tableIndex) bool {
 			if index.IncludesAllAttributes {
 				return true
 			}
+			missingAttributes := []string{}
 			for _, selectAttribute := range expr.attributes {
 				if _, found := index.AttributeSet[selectAttribute]; !found {
 					// missing queried attribute in index projection
-					return false
+					missingAttributes = append(missingAttributes, selectAttribute)
 				}
 			}
+			if len(missingAttributes) > 0 {
+				rejections = append(rejections, newIndexRejection(index.Name, missingAttributes))
+				return false
+			}
 			return true
 		})
 	} else {
@@ -156,5 +425,29 @@ func (table *Table) getViableQueryIndexes(ctx context.Context, expr *QueryExpr)
 		})
 	}
 
+	// consider hydrating items of indexes that do not project the queried attributes, if allowed
+	// or if no index projects them
+	hydratableIndexNameSet := newNameSet()
+	if expr.hydrationAllowed || viableIndexNameSet.Empty() {
+		for _, indexName := range projectionCandidates.Names() {
+			if !viableIndexNameSet.Contains(indexName) &&
+				table.indexHydratable(expr, table.allIndexes()[indexName]) {
+
+				hydratableIndexNameSet.Insert(indexName)
+			}
+		}
+	}
+
+	if !hydratableIndexNameSet.Empty() {
+		expr.logger.Printf("found indexes viable with hydration from table: %s\n",
+			hydratableIndexNameSet)
+		viableIndexNameSet.Insert(hydratableIndexNameSet.Names()...)
+	} else if viableIndexNameSet.Empty() && len(rejections) > 0 {
+		// explain how to fix the query if the selection alone disqualified all indexes
+		err := ErrNoViableIndexes{TableName: table.Name, Expr: expr, Rejections: rejections}
+		expr.logger.Printf("error: %s\n", err.Error())
+		return nil, err
+	}
+
 	return viableIndexNameSet, nil
 }
@@ -0,0 +1,129 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestMultipleConditionsOnIndexKeys(t *testing.T) {
+	cases := []struct {
+		name string
+		expr *QueryExpr
+
+		// expectIndex is the chosen index, or empty if no index is viable
+		expectIndex   string
+		expectFilters int
+	}{
+		{
+			name: "table sort key with two conditions",
+			expr: NewQuery("id").Equals("a").
+				And("ts").GreaterThan("1").
+				And("ts").LessThan("9"),
+		},
+		{
+			name: "index partition key with two conditions",
+			expr: NewQuery("status").Equals("open").
+				And("status").BeginsWith("o"),
+		},
+		{
+			name: "non-key attribute with two conditions",
+			expr: NewQuery("id").Equals("a").
+				And("name").GreaterThan("a").
+				And("name").LessThan("z"),
+			expectIndex:   tablePrimaryIndexName,
+			expectFilters: 2,
+		},
+		{
+			name: "key of another index with two conditions",
+			expr: NewQuery("id").Equals("a").
+				And("status").GreaterThan("a").
+				And("status").LessThan("z"),
+			expectIndex:   tablePrimaryIndexName,
+			expectFilters: 2,
+		},
+		{
+			name: "index sort key with two conditions",
+			expr: NewQuery("status").Equals("open").
+				And("createdAt").GreaterThan("1").
+				And("createdAt").LessThan("9"),
+		},
+		{
+			name: "single conditions on index keys",
+			expr: NewQuery("status").Equals("open").
+				And("createdAt").Between("1", "9"),
+			expectIndex: "byStatus",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := newFakeDynamoDB("items", "id", "ts", fakeIndex{
+				name:         "byStatus",
+				partitionKey: "status",
+				sortKey:      "createdAt",
+				projectAll:   true,
+			})
+			table := newFakeTable(fake)
+
+			parser, err := table.Query(context.Background(), tc.expr)
+			if tc.expectIndex == "" {
+				if _, isNoViable := err.(ErrNoViableIndexes); !isNoViable {
+					t.Fatalf("expected ErrNoViableIndexes, got %v", err)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if parser.index.Name != tc.expectIndex {
+				t.Errorf("expected index %s, got %s", tc.expectIndex, parser.index.Name)
+			}
+
+			// conditions on attributes that are not index keys are filter conditions
+			filters := 0
+			if filter := parser.queryInput.FilterExpression; filter != nil {
+				filters = len(splitConditions(*filter))
+			}
+			if filters != tc.expectFilters {
+				t.Errorf("expected %d filter conditions, got %d", tc.expectFilters, filters)
+			}
+		})
+	}
+}
+
+func TestQueryRawTenantIsolation(t *testing.T) {
+	cases := []struct {
+		name      string
+		tenant    string
+		expectErr bool
+	}{
+		{name: "without tenant"},
+		{name: "with tenant", tenant: "acme", expectErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := newFakeDynamoDB("items", "id", "ts")
+			table := newFakeTable(fake)
+			if tc.tenant != "" {
+				table = table.WithTenant(tc.tenant)
+			}
+
+			_, err := table.QueryRaw(context.Background(), &dynamodb.QueryInput{
+				KeyConditionExpression: aws.String("#id = :id"),
+				ExpressionAttributeNames: map[string]*string{
+					"#id": aws.String("id"),
+				},
+				ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+					":id": {S: aws.String("other#a")},
+				},
+			})
+			if (err != nil) != tc.expectErr {
+				t.Errorf("expected error %t, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
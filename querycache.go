@@ -0,0 +1,63 @@
+package dynamodbfriend
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// QueryCache is an in-memory cache of query result pages. Pages are keyed by a canonical hash of
+// the built query input, including the page start key, so structurally and value-identical
+// queries share cached pages.
+type QueryCache struct {
+	store *lruStore
+	ttl   time.Duration
+}
+
+// NewQueryCache creates a new QueryCache holding at most capacity pages, each cached until ttl has
+// passed.
+func NewQueryCache(capacity int, ttl time.Duration) *QueryCache {
+	return &QueryCache{
+		store: newLRUStore(capacity),
+		ttl:   ttl,
+	}
+}
+
+// WithQueryCache enables caching of query result pages for queries on this table.
+func (table *Table) WithQueryCache(cache *QueryCache) *Table {
+	table.queryCache = cache
+	return table
+}
+
+func (c *QueryCache) getPage(key string) (*queryPage, bool) {
+	value, found := c.store.Get(key)
+	if !found {
+		return nil, false
+	}
+	return value.(*queryPage), true
+}
+
+func (c *QueryCache) setPage(key string, page *queryPage) {
+	c.store.Set(key, page, c.ttl)
+}
+
+func queryCacheKey(queryInput *dynamodb.QueryInput) (string, error) {
+	// json encoding sorts map keys, giving a canonical form of the input
+	encoded, err := json.Marshal(queryInput)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(encoded)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+func copyItem(item map[string]*dynamodb.AttributeValue) map[string]*dynamodb.AttributeValue {
+	copied := map[string]*dynamodb.AttributeValue{}
+	for k, v := range item {
+		copied[k] = v
+	}
+	return copied
+}
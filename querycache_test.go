@@ -0,0 +1,80 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestQueryCache(t *testing.T) {
+	cases := []struct {
+		name          string
+		ttl           time.Duration
+		first, second *QueryExpr
+		wait          time.Duration
+		expectQueries int
+	}{
+		{
+			name:          "identical queries",
+			ttl:           time.Minute,
+			first:         NewQuery("id").Equals("a"),
+			second:        NewQuery("id").Equals("a"),
+			expectQueries: 1,
+		},
+		{
+			name:          "queries of different values",
+			ttl:           time.Minute,
+			first:         NewQuery("id").Equals("a"),
+			second:        NewQuery("id").Equals("b"),
+			expectQueries: 2,
+		},
+		{
+			name:          "queries of different conditions",
+			ttl:           time.Minute,
+			first:         NewQuery("id").Equals("a"),
+			second:        NewQuery("id").Equals("a").And("ts").GreaterThan("1"),
+			expectQueries: 2,
+		},
+		{
+			name:          "expired page",
+			ttl:           time.Millisecond,
+			first:         NewQuery("id").Equals("a"),
+			second:        NewQuery("id").Equals("a"),
+			wait:          10 * time.Millisecond,
+			expectQueries: 2,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := newFakeDynamoDB("items", "id", "ts")
+			fake.queryItems = []map[string]*dynamodb.AttributeValue{
+				stringItem(map[string]string{"id": "a", "ts": "1"}),
+				stringItem(map[string]string{"id": "a", "ts": "2"}),
+			}
+			table := newFakeTable(fake).WithQueryCache(NewQueryCache(16, tc.ttl))
+
+			for i, expr := range []*QueryExpr{tc.first, tc.second} {
+				if i > 0 {
+					time.Sleep(tc.wait)
+				}
+				parser, err := table.Query(context.Background(), expr)
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				items := []map[string]string{}
+				if err := parser.All(context.Background(), &items); err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				} else if len(items) != 2 {
+					t.Errorf("expected 2 items, got %d", len(items))
+				}
+			}
+
+			if len(fake.queryInputs) != tc.expectQueries {
+				t.Errorf("expected %d queries, got %d", tc.expectQueries, len(fake.queryInputs))
+			}
+		})
+	}
+}
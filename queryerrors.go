@@ -1,17 +1,68 @@
 package dynamodbfriend
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // ErrNoViableIndexes is returned when no viable indexes are found to execute a query expression
-// on a table.
+// on a table. If the selected attributes of the query disqualified all otherwise viable indexes,
+// Rejections holds the reason each of those indexes was rejected.
 type ErrNoViableIndexes struct {
-	TableName string
-	Expr      *QueryExpr
+	TableName  string
+	Expr       *QueryExpr
+	Rejections []IndexRejection
 }
 
 func (e ErrNoViableIndexes) Error() string {
-	// TODO: return a human-readable format for the query string, or better reasoning
-	return fmt.Sprintf("no viable indexes found for table \"%s\" for given query", e.TableName)
+	// TODO: return a human-readable format for the query string
+	msg := fmt.Sprintf("no viable indexes found for table \"%s\" for given query", e.TableName)
+	if len(e.Rejections) > 0 {
+		reasons := make([]string, len(e.Rejections))
+		for i, rejection := range e.Rejections {
+			reasons[i] = rejection.String()
+		}
+		msg += ": " + strings.Join(reasons, "; ")
+	}
+	return msg
+}
+
+// IndexRejection is the reason an index was rejected for a query because its projection does not
+// include all selected attributes of the query.
+type IndexRejection struct {
+	IndexName string
+
+	// MissingAttributes are the selected attributes not projected by the index. An index missing
+	// a single attribute is viable once that attribute alone is removed from the selection.
+	MissingAttributes []string
+
+	// Suggestion describes how to make the index viable, such as by removing the missing
+	// attributes from the selection or adding them to the index projection.
+	Suggestion string
+}
+
+func newIndexRejection(indexName string, missingAttributes []string) IndexRejection {
+	quoted := make([]string, len(missingAttributes))
+	for i, attribute := range missingAttributes {
+		quoted[i] = fmt.Sprintf("\"%s\"", attribute)
+	}
+	attributes := strings.Join(quoted, ", ")
+
+	return IndexRejection{
+		IndexName:         indexName,
+		MissingAttributes: missingAttributes,
+		Suggestion: fmt.Sprintf("remove %s from Select, or add %s to the projection of index "+
+			"\"%s\"", attributes, attributes, indexName),
+	}
+}
+
+func (r IndexRejection) String() string {
+	if len(r.MissingAttributes) == 1 {
+		return fmt.Sprintf("index \"%s\" does not project selected attribute \"%s\" (%s)",
+			r.IndexName, r.MissingAttributes[0], r.Suggestion)
+	}
+	return fmt.Sprintf("index \"%s\" does not project %d selected attributes (%s)",
+		r.IndexName, len(r.MissingAttributes), r.Suggestion)
 }
 
 // ErrParsingComplete is returned by QueryParser.Next() when all query items have been returned or
@@ -23,3 +74,87 @@ type ErrParsingComplete struct {
 func (e ErrParsingComplete) Error() string {
 	return fmt.Sprintf("parsing complete: %s", e.reason)
 }
+
+// ErrSoftDeleteNotEnabled is returned when a soft delete is requested on a table without soft
+// delete enabled.
+type ErrSoftDeleteNotEnabled struct {
+	TableName string
+}
+
+func (e ErrSoftDeleteNotEnabled) Error() string {
+	return fmt.Sprintf("soft delete not enabled for table \"%s\"", e.TableName)
+}
+
+// ErrItemNotFound is returned when a requested item does not exist in a table.
+type ErrItemNotFound struct {
+	TableName string
+}
+
+func (e ErrItemNotFound) Error() string {
+	return fmt.Sprintf("item not found in table \"%s\"", e.TableName)
+}
+
+// ErrMultipleItems is returned when more than one item matches a query expected to return a
+// single item.
+type ErrMultipleItems struct {
+	TableName string
+}
+
+func (e ErrMultipleItems) Error() string {
+	return fmt.Sprintf("multiple items found in table \"%s\"", e.TableName)
+}
+
+// ErrRewindOutOfRange is returned by QueryParser.Rewind when more items are requested to be
+// rewound than remain in the parser's buffer.
+type ErrRewindOutOfRange struct {
+	Requested int
+	Available int
+}
+
+func (e ErrRewindOutOfRange) Error() string {
+	return fmt.Sprintf("cannot rewind %d items, only %d buffered items available",
+		e.Requested, e.Available)
+}
+
+// ErrInvalidCursor is returned by QueryPage when a cursor cannot be decoded.
+type ErrInvalidCursor struct {
+	Cursor string
+}
+
+func (e ErrInvalidCursor) Error() string {
+	return fmt.Sprintf("invalid page cursor \"%s\"", e.Cursor)
+}
+
+// ErrMemoryBudgetExceeded is returned when collecting query results would exceed the table's
+// result memory budget.
+type ErrMemoryBudgetExceeded struct {
+	TableName string
+	Budget    int64
+}
+
+func (e ErrMemoryBudgetExceeded) Error() string {
+	return fmt.Sprintf("query results of table \"%s\" exceed memory budget of %d bytes",
+		e.TableName, e.Budget)
+}
+
+// ErrConditionFailed is returned when a conditional write is rejected because the existing item
+// does not meet the write's conditions.
+type ErrConditionFailed struct {
+	TableName string
+}
+
+func (e ErrConditionFailed) Error() string {
+	return fmt.Sprintf("condition not met for item in table \"%s\"", e.TableName)
+}
+
+// ErrUnprocessedKeys is returned by BatchGet, and reported for failed items of batch writes, when
+// some keys remain unprocessed after all retries, such as due to sustained throttling.
+type ErrUnprocessedKeys struct {
+	TableName   string
+	Unprocessed int
+}
+
+func (e ErrUnprocessedKeys) Error() string {
+	return fmt.Sprintf("%d keys of table \"%s\" remain unprocessed after retries",
+		e.Unprocessed, e.TableName)
+}
@@ -2,7 +2,7 @@ package dynamodbfriend
 
 import (
 	"fmt"
-	"reflect"
+	"sort"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
@@ -11,11 +11,17 @@ import (
 
 // QueryExpr is a fully-formed query expression.
 type QueryExpr struct {
-	filters map[string]queryFilter
+	// filters holds the conditions on each attribute, which are all applied
+	filters map[string][]queryFilter
 
 	limitSpecified bool
 	limitPerPage   int
 
+	totalLimitSpecified bool
+	totalLimit          int
+
+	startKey map[string]*dynamodb.AttributeValue
+
 	attributesSpecified bool
 	attributes          []string
 
@@ -23,27 +29,49 @@ type QueryExpr struct {
 	orderKey        string
 	orderDescending bool
 
+	reverse bool
+
 	maxPaginationSpecified bool
 	maxPagination          int
 
-	consistentRead bool
+	consistentReadSpecified bool
+	consistentRead          bool
 
 	additionalConditions []expression.ConditionBuilder
+	filterGroups         []*FilterGroup
+
+	keyConditionSpecified bool
+	keyCondition          expression.KeyConditionBuilder
+	keyConditionIndex     string
+
+	includeDeleted bool
 
-	logger Logger
+	// hydrate is set when the chosen index is queried for keys of items read from the table
+	hydrationAllowed bool
+	hydrate          bool
+
+	digestAttributes []string
+
+	// timeConditions is true if any conditions have time values to be encoded with the queried
+	// table's time encoding
+	timeConditions bool
+
+	retryPolicy *RetryPolicy
+
+	loggerSpecified bool
+	logger          Logger
 
 	buildErr error
 }
 
 // And extends a query with an additional query condition.
 func (expr *QueryExpr) And(key string) *QueryExprKey {
-	return &QueryExprKey{
-		expr: expr,
-		key:  key,
-	}
+	return newQueryExprKey(expr, key)
 }
 
-// LimitPerPage restricts the number of items evaluated per query page.
+// LimitPerPage restricts the number of items evaluated per query page. Queries with filter
+// conditions may return fewer items per page than the limit. Use TotalLimit to restrict the total
+// number of items returned by a query.
 func (expr *QueryExpr) LimitPerPage(count int) *QueryExpr {
 	expr.limitSpecified = true
 	expr.limitPerPage = count
@@ -51,6 +79,42 @@ func (expr *QueryExpr) LimitPerPage(count int) *QueryExpr {
 	return expr
 }
 
+// TotalLimit restricts the total number of items returned by a query across all pages. The parser
+// completes once count items have been returned. The count must be positive; otherwise an error
+// is returned when the query is made.
+func (expr *QueryExpr) TotalLimit(count int) *QueryExpr {
+	if count <= 0 {
+		err := fmt.Errorf("query total limit must be positive, got %d", count)
+		expr.logger.Printf("error: %s\n", err.Error())
+		expr.buildErr = err
+		return expr
+	}
+	expr.totalLimitSpecified = true
+	expr.totalLimit = count
+	expr.logger.Printf("query total limit set to %d items\n", count)
+	return expr
+}
+
+// StartFrom resumes a query after the position identified by a cursor from QueryParser.Cursor,
+// such as to paginate across stateless requests of a web API. The cursor is only valid with the
+// same query expression. If the cursor cannot be decoded, ErrInvalidCursor is returned when the
+// query is made. An empty cursor starts the query from the beginning.
+func (expr *QueryExpr) StartFrom(cursor string) *QueryExpr {
+	if cursor == "" {
+		expr.startKey = nil
+		return expr
+	}
+
+	startKey, err := decodeCursor(cursor)
+	if err != nil {
+		expr.logger.Printf("error: %s\n", err.Error())
+		expr.buildErr = err
+		return expr
+	}
+	expr.startKey = startKey
+	return expr
+}
+
 // Select restricts the attributes returned by a query.
 func (expr *QueryExpr) Select(attributes ...string) *QueryExpr {
 	expr.attributesSpecified = true
@@ -83,6 +147,15 @@ func (expr *QueryExpr) OrderDescending(sortKey string) *QueryExpr {
 	return expr
 }
 
+// Reverse returns items in descending order of the sort key of whichever index is chosen for the
+// query. Unlike OrderDescending, Reverse does not restrict index selection to a particular sort
+// key.
+func (expr *QueryExpr) Reverse() *QueryExpr {
+	expr.reverse = true
+	expr.logger.Printf("query order reversed on chosen index\n")
+	return expr
+}
+
 // MaxPagination restricts the number of paginated requests to make to DynamoDB. If the max
 // pagination is reached and all items have been read, the iterator will return Done.
 func (expr *QueryExpr) MaxPagination(count int) *QueryExpr {
@@ -96,6 +169,7 @@ func (expr *QueryExpr) MaxPagination(count int) *QueryExpr {
 // NOTE: For read consistency to be set to true, the partition key must be used with an Equals
 // condition expression. Additionally, the max pagination will be set to 1.
 func (expr *QueryExpr) ConsistentRead(val bool) *QueryExpr {
+	expr.consistentReadSpecified = true
 	expr.consistentRead = val
 	if val == true {
 		expr.maxPaginationSpecified = true
@@ -109,125 +183,172 @@ func (expr *QueryExpr) ConsistentRead(val bool) *QueryExpr {
 
 // WithFilter applies an additional condition in addition to other filters on the query
 // expression. This allows for filter conditions that are not otherwise supported by the query
-// expression, such as OR conditions.
+// expression, such as attribute existence conditions. Use Or for groups of OR conditions.
 func (expr *QueryExpr) WithFilter(condition expression.ConditionBuilder) *QueryExpr {
 	expr.additionalConditions = append(expr.additionalConditions, condition)
 	return expr
 }
 
+// WithKeyCondition sets a raw key condition to use for the query on the named index, bypassing
+// index selection. An empty index name refers to the table's primary index. All conditions
+// otherwise set on the query expression are applied as filter conditions.
+func (expr *QueryExpr) WithKeyCondition(kce expression.KeyConditionBuilder, index string) *QueryExpr {
+	expr.keyConditionSpecified = true
+	expr.keyCondition = kce
+	expr.keyConditionIndex = index
+	expr.logger.Printf("query uses raw key condition on index \"%s\"\n", index)
+	return expr
+}
+
+// WithDeleted includes soft-deleted items in query results on tables with soft delete enabled.
+func (expr *QueryExpr) WithDeleted() *QueryExpr {
+	expr.includeDeleted = true
+	expr.logger.Printf("query includes soft-deleted items\n")
+	return expr
+}
+
 // WithLogger sets a logger used to print logs about querying operations performed using this
-// expression.
+// expression. If no logger is set, the logger of the table being queried is used.
 func (expr *QueryExpr) WithLogger(logger Logger) *QueryExpr {
+	expr.loggerSpecified = true
 	expr.logger = logger
 	return expr
 }
 
-func (expr *QueryExpr) addFilter(v queryFilter, conditionName string) {
+// addFilter adds a condition on an attribute. Multiple conditions on the same attribute are all
+// applied, although an index may only be queried if each of its key attributes has at most one
+// condition, since key conditions support a single condition per key.
+func (expr *QueryExpr) addFilter(v queryFilter) {
 	key := v.Key()
-	_, alreadyExists := expr.filters[key]
-	if alreadyExists {
-		err := fmt.Errorf("key \"%s\" already used in \"%s\" condition", key, conditionName)
-		expr.logger.Printf("error: %s\n", err.Error())
-		expr.buildErr = err
-	} else {
-		expr.filters[key] = v
+	expr.filters[key] = append(expr.filters[key], v)
+}
+
+// filterOn returns the condition on an attribute, if the attribute has exactly one condition.
+func (expr *QueryExpr) filterOn(key string) (queryFilter, bool) {
+	return singleFilter(expr.filters, key)
+}
+
+func singleFilter(filters map[string][]queryFilter, key string) (queryFilter, bool) {
+	if len(filters[key]) != 1 {
+		return nil, false
 	}
+	return filters[key][0], true
 }
 
-func (expr *QueryExpr) getKeysOfFilterType(v interface{}) *nameSet {
-	getFilterType := reflect.TypeOf(v)
+// getKeysWithMultipleFilters returns the attributes with more than one condition.
+func (expr *QueryExpr) getKeysWithMultipleFilters() *nameSet {
+	keys := newNameSet()
+	for key, filters := range expr.filters {
+		if len(filters) > 1 {
+			keys.Insert(key)
+		}
+	}
+	return keys
+}
 
+func (expr *QueryExpr) getKeysOfFilterOp(op filterOp) *nameSet {
 	// create set of all keys with specific filters
 	keys := newNameSet()
-	for key, filter := range expr.filters {
-		thisFilterType := reflect.TypeOf(filter)
-		if getFilterType == thisFilterType {
-			keys.Insert(key)
+	for key, filters := range expr.filters {
+		for _, filter := range filters {
+			if filter.Op() == op {
+				keys.Insert(key)
+			}
 		}
 	}
 
 	return keys
 }
 
-func (expr *QueryExpr) copyFilters() map[string]queryFilter {
-	filters := map[string]queryFilter{}
-	for k, v := range expr.filters {
-		filters[k] = v
+func (expr *QueryExpr) copyFilters() map[string][]queryFilter {
+	return copyFilterMap(expr.filters)
+}
+
+func copyFilterMap(filterMap map[string][]queryFilter) map[string][]queryFilter {
+	filters := map[string][]queryFilter{}
+	for k, v := range filterMap {
+		filters[k] = append([]queryFilter{}, v...)
 	}
 	return filters
 }
 
-func (expr QueryExpr) constructQueryInputGivenIndex(index *tableIndex) (*dynamodb.QueryInput, error) {
+func (expr QueryExpr) constructQueryInputGivenIndex(index *tableIndex,
+	opts tableQueryOptions) (*dynamodb.QueryInput, error) {
+
 	filters := expr.copyFilters()
 
+	// use raw key condition as-is, if specified
+	if expr.keyConditionSpecified {
+		if opts.tenantPrefix != "" {
+			err := fmt.Errorf("raw key conditions are not supported with tenant isolation")
+			expr.logger.Printf("error: %s\n", err.Error())
+			return nil, err
+		}
+		return expr.constructQueryInput(index, expr.keyCondition, filters, opts)
+	}
+
+	// apply tenant prefix to partition key value, if applicable
+	partitionFilter, _ := singleFilter(filters, index.PartitionKey)
+	partitionValue := partitionFilter.(*equalsFilter).value
+	if opts.tenantPrefix != "" {
+		partitionStr, isString := partitionValue.(string)
+		if !isString {
+			err := fmt.Errorf("partition key \"%s\" must be a string for tenant isolation",
+				index.PartitionKey)
+			expr.logger.Printf("error: %s\n", err.Error())
+			return nil, err
+		}
+		partitionValue = opts.tenantPrefix + partitionStr
+	}
+
 	// initialize partition equals part of key condition expression
-	dbExprBuilder := expression.NewBuilder()
-	kce := expression.Key(index.PartitionKey).
-		Equal(expression.Value(filters[index.PartitionKey].(*equalsFilter).value))
+	kce := expression.Key(index.PartitionKey).Equal(expression.Value(partitionValue))
 	delete(filters, index.PartitionKey)
 
 	// apply sort key condition to key condition expression if applicable
 	if index.IsComposite {
-		filter, hasSortKeyFilter := filters[index.SortKey]
+		filter, hasSortKeyFilter := singleFilter(filters, index.SortKey)
 		if hasSortKeyFilter {
-			builder := expression.Key(index.SortKey)
-			switch f := filter.(type) {
-			case *equalsFilter:
-				kce = kce.And(builder.Equal(expression.Value(f.value)))
-			case *lessThanFilter:
-				kce = kce.And(builder.LessThan(expression.Value(f.value)))
-			case *greaterThanFilter:
-				kce = kce.And(builder.GreaterThan(expression.Value(f.value)))
-			case *lessThanEqualFilter:
-				kce = kce.And(builder.LessThanEqual(expression.Value(f.value)))
-			case *greaterThanEqualFilter:
-				kce = kce.And(builder.GreaterThanEqual(expression.Value(f.value)))
-			case *betweenFilter:
-				kce = kce.And(builder.Between(
-					expression.Value(f.lowval), expression.Value(f.highval)))
-			case *beginsWithFilter:
-				kce = kce.And(builder.BeginsWith(f.prefix))
-			default:
-				err := fmt.Errorf("unknown filter type: %T", f)
-				expr.logger.Printf("error: %s\n", err.Error())
-				return nil, err
+			sortKeyCondition, isKeyCondition := filter.KeyCondition(expression.Key(index.SortKey))
+			if isKeyCondition {
+				kce = kce.And(sortKeyCondition)
+				delete(filters, index.SortKey)
 			}
-			delete(filters, index.SortKey)
 		}
 	}
 
-	dbExprBuilder = dbExprBuilder.WithKeyCondition(kce)
+	return expr.constructQueryInput(index, kce, filters, opts)
+}
+
+func (expr QueryExpr) constructQueryInput(index *tableIndex, kce expression.KeyConditionBuilder,
+	filters map[string][]queryFilter, opts tableQueryOptions) (*dynamodb.QueryInput, error) {
+
+	dbExprBuilder := expression.NewBuilder().WithKeyCondition(kce)
 
 	// apply remaining filters as filter conditions
+	// filters are applied in sorted key order so that identical expressions build identically
+	filterKeys := []string{}
+	for key := range filters {
+		filterKeys = append(filterKeys, key)
+	}
+	sort.Strings(filterKeys)
+
 	filterConditions := []expression.ConditionBuilder{}
-	for key, filter := range filters {
-		var fc expression.ConditionBuilder
-		switch f := filter.(type) {
-		case *equalsFilter:
-			fc = expression.Name(key).Equal(expression.Value(f.value))
-		case *lessThanFilter:
-			fc = expression.Name(key).LessThan(expression.Value(f.value))
-		case *greaterThanFilter:
-			fc = expression.Name(key).GreaterThan(expression.Value(f.value))
-		case *lessThanEqualFilter:
-			fc = expression.Name(key).LessThanEqual(expression.Value(f.value))
-		case *greaterThanEqualFilter:
-			fc = expression.Name(key).GreaterThanEqual(expression.Value(f.value))
-		case *betweenFilter:
-			fc = expression.Name(key).Between(
-				expression.Value(f.lowval), expression.Value(f.highval))
-		case *beginsWithFilter:
-			fc = expression.Name(key).BeginsWith(f.prefix)
-		default:
-			err := fmt.Errorf("unknown filter type: %T", f)
-			expr.logger.Printf("error: %s\n", err.Error())
-			return nil, err
+	for _, key := range filterKeys {
+		for _, filter := range filters[key] {
+			fc := filter.Condition(expression.Name(key))
+			filterConditions = append(filterConditions, fc)
 		}
-		filterConditions = append(filterConditions, fc)
 	}
 
-	// apply additional filter conditions, if specified
+	// apply filter groups and additional filter conditions, if specified
+	groupConditions, err := filterGroupConditions(expr.filterGroups)
+	if err != nil {
+		return nil, err
+	}
+	filterConditions = append(filterConditions, groupConditions...)
 	filterConditions = append(filterConditions, expr.additionalConditions...)
+	filterConditions = append(filterConditions, opts.additionalConditions...)
 
 	if len(filterConditions) == 1 {
 		dbExprBuilder = dbExprBuilder.WithFilter(filterConditions[0])
@@ -270,12 +391,20 @@ func (expr QueryExpr) constructQueryInputGivenIndex(index *tableIndex) (*dynamod
 		queryInput.Limit = aws.Int64(int64(expr.limitPerPage))
 	}
 
+	// avoid reading beyond the total limit when every evaluated item is returned
+	if expr.totalLimitSpecified && queryInput.FilterExpression == nil &&
+		(queryInput.Limit == nil || *queryInput.Limit > int64(expr.totalLimit)) {
+		queryInput.Limit = aws.Int64(int64(expr.totalLimit))
+	}
+
 	if expr.consistentRead {
 		queryInput.ConsistentRead = aws.Bool(true)
 	}
 
 	if expr.orderMatters {
 		queryInput.ScanIndexForward = aws.Bool(!expr.orderDescending)
+	} else if expr.reverse {
+		queryInput.ScanIndexForward = aws.Bool(false)
 	}
 
 	return queryInput, nil
@@ -5,96 +5,51 @@ import "github.com/aws/aws-sdk-go/service/dynamodb/expression"
 // QueryExprKey is a partially-formed query expression.
 //
 // To make a fully-formed query expression, the key part must be followed by a conditional.
+// Conditionals on the keys of the chosen index are used in the key condition where DynamoDB
+// supports them, and all others are applied as filter conditions. NotEquals, Contains,
+// NotContains, Exists, and NotExists are always applied as filter conditions. An In conditional on
+// the partition key of an index is queried as one query per value, executed concurrently and
+// merged by the parser, in which case items are not returned in any particular order across
+// values.
 type QueryExprKey struct {
+	ConditionKey[*QueryExpr]
+
 	expr *QueryExpr
-	key  string
 }
 
 // NewQuery begins a new query expression.
 func NewQuery(key string) *QueryExprKey {
+	return newQueryExprKey(newQueryExpr(), key)
+}
+
+func newQueryExprKey(expr *QueryExpr, key string) *QueryExprKey {
 	return &QueryExprKey{
-		expr: &QueryExpr{
-			filters:              map[string]queryFilter{},
-			additionalConditions: []expression.ConditionBuilder{},
-			logger:               nullLogger{},
+		ConditionKey: ConditionKey[*QueryExpr]{
+			key: key,
+			add: func(filter queryFilter) *QueryExpr {
+				expr.addFilter(filter)
+				return expr
+			},
+			fail: func(err error) *QueryExpr {
+				expr.logger.Printf("error: %s\n", err.Error())
+				expr.buildErr = err
+				return expr
+			},
 		},
-		key: key,
+		expr: expr,
 	}
 }
 
-// Equals is a conditional where the value associated with a query key must equal val.
-func (k *QueryExprKey) Equals(val interface{}) *QueryExpr {
-	k.expr.addFilter(&equalsFilter{
-		key:   k.key,
-		value: val,
-	}, "equals")
-
-	return k.expr
+// NewQueryWithKeyCondition begins a new query expression from a raw key condition on the named
+// index. An empty index name refers to the table's primary index.
+func NewQueryWithKeyCondition(kce expression.KeyConditionBuilder, index string) *QueryExpr {
+	return newQueryExpr().WithKeyCondition(kce, index)
 }
 
-// LessThan is a conditional where the value associated with a query key must be less
-// than val.
-func (k *QueryExprKey) LessThan(val interface{}) *QueryExpr {
-	k.expr.addFilter(&lessThanFilter{
-		key:   k.key,
-		value: val,
-	}, "less than")
-
-	return k.expr
-}
-
-// GreaterThan is a conditional expression where the value associated with a query key must be
-// greater than val.
-func (k *QueryExprKey) GreaterThan(val interface{}) *QueryExpr {
-	k.expr.addFilter(&greaterThanFilter{
-		key:   k.key,
-		value: val,
-	}, "greater than")
-
-	return k.expr
-}
-
-// LessThanEqual is a conditional expression where the value associated with a query key must be
-// less than or equal to val.
-func (k *QueryExprKey) LessThanEqual(val interface{}) *QueryExpr {
-	k.expr.addFilter(&lessThanEqualFilter{
-		key:   k.key,
-		value: val,
-	}, "less than or equal")
-
-	return k.expr
-}
-
-// GreaterThanEqual is a conditional expression where the value associated with a query key must
-// be greater than or equal to val.
-func (k *QueryExprKey) GreaterThanEqual(val interface{}) *QueryExpr {
-	k.expr.addFilter(&greaterThanEqualFilter{
-		key:   k.key,
-		value: val,
-	}, "greater than or equal")
-
-	return k.expr
-}
-
-// Between is a conditional expression where the value associated with a query key must be between
-// lowval and highval.
-func (k *QueryExprKey) Between(lowval, highval interface{}) *QueryExpr {
-	k.expr.addFilter(&betweenFilter{
-		key:     k.key,
-		lowval:  lowval,
-		highval: highval,
-	}, "between")
-
-	return k.expr
-}
-
-// BeginsWith is a conditional expression where the value associated with a query key must begin
-// with a specified prefix.
-func (k *QueryExprKey) BeginsWith(prefix string) *QueryExpr {
-	k.expr.addFilter(&beginsWithFilter{
-		key:    k.key,
-		prefix: prefix,
-	}, "begins with")
-
-	return k.expr
+func newQueryExpr() *QueryExpr {
+	return &QueryExpr{
+		filters:              map[string][]queryFilter{},
+		additionalConditions: []expression.ConditionBuilder{},
+		logger:               nullLogger{},
+	}
 }
@@ -0,0 +1,152 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// maxInValues is the maximum number of values of an IN condition supported by DynamoDB.
+const maxInValues = 100
+
+// partitionPage is a page of results, or an error, from the query of a single partition key value.
+type partitionPage struct {
+	page *queryPage
+	err  error
+}
+
+// partitionQueryInputs builds one query input for each value of the IN condition on the index's
+// partition key.
+func (expr *QueryExpr) partitionQueryInputs(index *tableIndex, in *inFilter,
+	opts tableQueryOptions) ([]*dynamodb.QueryInput, error) {
+
+	var err error
+	if len(in.values) > maxInValues {
+		err = fmt.Errorf("partition key \"%s\" has %d values in \"%s\" condition, exceeding %d",
+			index.PartitionKey, len(in.values), inOp, maxInValues)
+	} else if expr.orderMatters {
+		err = fmt.Errorf("query of multiple partition key values cannot be ordered")
+	} else if expr.startKey != nil {
+		err = fmt.Errorf("query of multiple partition key values cannot start from a cursor")
+	}
+	if err != nil {
+		expr.logger.Printf("error: %s\n", err.Error())
+		return nil, err
+	}
+
+	inputs := []*dynamodb.QueryInput{}
+	for _, value := range in.values {
+		partitionExpr := *expr
+		partitionExpr.filters = expr.copyFilters()
+		partitionExpr.filters[index.PartitionKey] = []queryFilter{&equalsFilter{
+			key:   index.PartitionKey,
+			value: value,
+		}}
+
+		input, err := partitionExpr.constructQueryInputGivenIndex(index, opts)
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, input)
+	}
+
+	return inputs, nil
+}
+
+// startPartitions begins querying all partition key values concurrently. Pages are delivered to
+// the parser through a channel, which is closed once all partition queries have stopped.
+func (parser *QueryParser) startPartitions() {
+	partitionCtx, stopPartitions := context.WithCancel(parser.partitionCtx)
+
+	partitionPages := make(chan partitionPage, len(parser.partitionInputs))
+	parser.partitionPages = partitionPages
+	parser.stopPartitions = stopPartitions
+	parser.partitionsClosed = false
+
+	var wg sync.WaitGroup
+	for _, partitionInput := range parser.partitionInputs {
+		queryInput := *partitionInput
+		queryInput.Limit = parser.queryInput.Limit
+		queryInput.ReturnConsumedCapacity = parser.table.returnConsumedCapacity()
+
+		wg.Add(1)
+		go func(queryInput *dynamodb.QueryInput) {
+			defer wg.Done()
+			for {
+				// do not begin another request once partition queries have been stopped
+				if partitionCtx.Err() != nil {
+					return
+				}
+
+				page, err := parser.executeQueryInput(partitionCtx, queryInput)
+
+				select {
+				case partitionPages <- partitionPage{page: page, err: err}:
+				case <-partitionCtx.Done():
+					return
+				}
+
+				if err != nil || len(page.lastEvaluatedKey) == 0 {
+					return
+				}
+				queryInput.ExclusiveStartKey = page.lastEvaluatedKey
+			}
+		}(&queryInput)
+	}
+
+	go func() {
+		wg.Wait()
+		close(partitionPages)
+	}()
+}
+
+// fetchNextPartitionPage loads the next page received from the query of any partition key value.
+func (parser *QueryParser) fetchNextPartitionPage(ctx context.Context) error {
+	if parser.partitionPages == nil {
+		parser.startPartitions()
+	}
+
+	select {
+	case <-ctx.Done():
+		// the caller is no longer waiting on pages, so stop remaining partition queries
+		parser.stopPartitionQueries()
+		return ctx.Err()
+	case page, open := <-parser.partitionPages:
+		if !open {
+			// partition queries also stop when the query context is canceled
+			parser.partitionsClosed = true
+			parser.bufferedItems = []map[string]*dynamodb.AttributeValue{}
+			parser.currentBufferIndex = 0
+			return parser.partitionCtx.Err()
+		} else if page.err != nil {
+			parser.stopPartitionQueries()
+			parser.expr.logger.Printf("error: %s\n", page.err.Error())
+			return page.err
+		}
+
+		parser.loadPage(page.page)
+
+		// stop remaining partition queries once no further pages will be read
+		if parser.maxPaginationReached() {
+			parser.stopPartitionQueries()
+		}
+		return nil
+	}
+}
+
+// errPartitionCursor returns the error of cursors requested of a query of multiple partition key
+// values, whose position cannot be identified by a single key.
+func (parser *QueryParser) errPartitionCursor() error {
+	err := fmt.Errorf("cursors are not supported by queries of multiple partition key values")
+	parser.expr.logger.Printf("error: %s\n", err.Error())
+	return err
+}
+
+// stopPartitionQueries stops the queries of partition key values, if applicable.
+func (parser *QueryParser) stopPartitionQueries() {
+	if parser.stopPartitions != nil {
+		parser.stopPartitions()
+	}
+}
@@ -1,7 +1,68 @@
 package dynamodbfriend
 
+import "github.com/aws/aws-sdk-go/service/dynamodb/expression"
+
+// filterOp identifies the conditional operator of a query filter.
+type filterOp int
+
+const (
+	equalsOp filterOp = iota
+	lessThanOp
+	greaterThanOp
+	lessThanEqualOp
+	greaterThanEqualOp
+	beginsWithOp
+	betweenOp
+	inOp
+	containsOp
+	notContainsOp
+	existsOp
+	notExistsOp
+	notEqualsOp
+)
+
+func (op filterOp) String() string {
+	switch op {
+	case equalsOp:
+		return "equals"
+	case lessThanOp:
+		return "less than"
+	case greaterThanOp:
+		return "greater than"
+	case lessThanEqualOp:
+		return "less than or equal"
+	case greaterThanEqualOp:
+		return "greater than or equal"
+	case beginsWithOp:
+		return "begins with"
+	case betweenOp:
+		return "between"
+	case inOp:
+		return "in"
+	case containsOp:
+		return "contains"
+	case notContainsOp:
+		return "not contains"
+	case existsOp:
+		return "exists"
+	case notExistsOp:
+		return "not exists"
+	case notEqualsOp:
+		return "not equals"
+	}
+	return "unknown"
+}
+
 type queryFilter interface {
 	Key() string
+	Op() filterOp
+
+	// Condition returns the filter as a filter condition on the named attribute.
+	Condition(name expression.NameBuilder) expression.ConditionBuilder
+
+	// KeyCondition returns the filter as a key condition on the key attribute. The second return
+	// value is false if the filter cannot be expressed as a key condition.
+	KeyCondition(key expression.KeyBuilder) (expression.KeyConditionBuilder, bool)
 }
 
 type equalsFilter struct {
@@ -13,6 +74,18 @@ func (f equalsFilter) Key() string {
 	return f.key
 }
 
+func (f equalsFilter) Op() filterOp {
+	return equalsOp
+}
+
+func (f equalsFilter) Condition(name expression.NameBuilder) expression.ConditionBuilder {
+	return name.Equal(expression.Value(f.value))
+}
+
+func (f equalsFilter) KeyCondition(key expression.KeyBuilder) (expression.KeyConditionBuilder, bool) {
+	return key.Equal(expression.Value(f.value)), true
+}
+
 type lessThanFilter struct {
 	key   string
 	value interface{}
@@ -22,6 +95,18 @@ func (f lessThanFilter) Key() string {
 	return f.key
 }
 
+func (f lessThanFilter) Op() filterOp {
+	return lessThanOp
+}
+
+func (f lessThanFilter) Condition(name expression.NameBuilder) expression.ConditionBuilder {
+	return name.LessThan(expression.Value(f.value))
+}
+
+func (f lessThanFilter) KeyCondition(key expression.KeyBuilder) (expression.KeyConditionBuilder, bool) {
+	return key.LessThan(expression.Value(f.value)), true
+}
+
 type greaterThanFilter struct {
 	key   string
 	value interface{}
@@ -31,6 +116,18 @@ func (f greaterThanFilter) Key() string {
 	return f.key
 }
 
+func (f greaterThanFilter) Op() filterOp {
+	return greaterThanOp
+}
+
+func (f greaterThanFilter) Condition(name expression.NameBuilder) expression.ConditionBuilder {
+	return name.GreaterThan(expression.Value(f.value))
+}
+
+func (f greaterThanFilter) KeyCondition(key expression.KeyBuilder) (expression.KeyConditionBuilder, bool) {
+	return key.GreaterThan(expression.Value(f.value)), true
+}
+
 type lessThanEqualFilter struct {
 	key   string
 	value interface{}
@@ -40,6 +137,18 @@ func (f lessThanEqualFilter) Key() string {
 	return f.key
 }
 
+func (f lessThanEqualFilter) Op() filterOp {
+	return lessThanEqualOp
+}
+
+func (f lessThanEqualFilter) Condition(name expression.NameBuilder) expression.ConditionBuilder {
+	return name.LessThanEqual(expression.Value(f.value))
+}
+
+func (f lessThanEqualFilter) KeyCondition(key expression.KeyBuilder) (expression.KeyConditionBuilder, bool) {
+	return key.LessThanEqual(expression.Value(f.value)), true
+}
+
 type greaterThanEqualFilter struct {
 	key   string
 	value interface{}
@@ -49,6 +158,18 @@ func (f greaterThanEqualFilter) Key() string {
 	return f.key
 }
 
+func (f greaterThanEqualFilter) Op() filterOp {
+	return greaterThanEqualOp
+}
+
+func (f greaterThanEqualFilter) Condition(name expression.NameBuilder) expression.ConditionBuilder {
+	return name.GreaterThanEqual(expression.Value(f.value))
+}
+
+func (f greaterThanEqualFilter) KeyCondition(key expression.KeyBuilder) (expression.KeyConditionBuilder, bool) {
+	return key.GreaterThanEqual(expression.Value(f.value)), true
+}
+
 type beginsWithFilter struct {
 	key    string
 	prefix string
@@ -58,6 +179,18 @@ func (f beginsWithFilter) Key() string {
 	return f.key
 }
 
+func (f beginsWithFilter) Op() filterOp {
+	return beginsWithOp
+}
+
+func (f beginsWithFilter) Condition(name expression.NameBuilder) expression.ConditionBuilder {
+	return name.BeginsWith(f.prefix)
+}
+
+func (f beginsWithFilter) KeyCondition(key expression.KeyBuilder) (expression.KeyConditionBuilder, bool) {
+	return key.BeginsWith(f.prefix), true
+}
+
 type betweenFilter struct {
 	key             string
 	lowval, highval interface{}
@@ -66,3 +199,149 @@ type betweenFilter struct {
 func (f betweenFilter) Key() string {
 	return f.key
 }
+
+func (f betweenFilter) Op() filterOp {
+	return betweenOp
+}
+
+func (f betweenFilter) Condition(name expression.NameBuilder) expression.ConditionBuilder {
+	return name.Between(expression.Value(f.lowval), expression.Value(f.highval))
+}
+
+func (f betweenFilter) KeyCondition(key expression.KeyBuilder) (expression.KeyConditionBuilder, bool) {
+	return key.Between(expression.Value(f.lowval), expression.Value(f.highval)), true
+}
+
+type inFilter struct {
+	key    string
+	values []interface{}
+}
+
+func (f inFilter) Key() string {
+	return f.key
+}
+
+func (f inFilter) Op() filterOp {
+	return inOp
+}
+
+func (f inFilter) Condition(name expression.NameBuilder) expression.ConditionBuilder {
+	operands := make([]expression.OperandBuilder, len(f.values))
+	for i, value := range f.values {
+		operands[i] = expression.Value(value)
+	}
+	return name.In(operands[0], operands[1:]...)
+}
+
+func (f inFilter) KeyCondition(key expression.KeyBuilder) (expression.KeyConditionBuilder, bool) {
+	// DynamoDB key conditions do not support IN
+	return expression.KeyConditionBuilder{}, false
+}
+
+type containsFilter struct {
+	key   string
+	value string
+}
+
+func (f containsFilter) Key() string {
+	return f.key
+}
+
+func (f containsFilter) Op() filterOp {
+	return containsOp
+}
+
+func (f containsFilter) Condition(name expression.NameBuilder) expression.ConditionBuilder {
+	return name.Contains(f.value)
+}
+
+func (f containsFilter) KeyCondition(key expression.KeyBuilder) (expression.KeyConditionBuilder, bool) {
+	// DynamoDB key conditions do not support contains
+	return expression.KeyConditionBuilder{}, false
+}
+
+type notContainsFilter struct {
+	key   string
+	value string
+}
+
+func (f notContainsFilter) Key() string {
+	return f.key
+}
+
+func (f notContainsFilter) Op() filterOp {
+	return notContainsOp
+}
+
+func (f notContainsFilter) Condition(name expression.NameBuilder) expression.ConditionBuilder {
+	return expression.Not(name.Contains(f.value))
+}
+
+func (f notContainsFilter) KeyCondition(key expression.KeyBuilder) (expression.KeyConditionBuilder, bool) {
+	// DynamoDB key conditions do not support contains
+	return expression.KeyConditionBuilder{}, false
+}
+
+type existsFilter struct {
+	key string
+}
+
+func (f existsFilter) Key() string {
+	return f.key
+}
+
+func (f existsFilter) Op() filterOp {
+	return existsOp
+}
+
+func (f existsFilter) Condition(name expression.NameBuilder) expression.ConditionBuilder {
+	return expression.AttributeExists(name)
+}
+
+func (f existsFilter) KeyCondition(key expression.KeyBuilder) (expression.KeyConditionBuilder, bool) {
+	// DynamoDB key conditions do not support attribute existence
+	return expression.KeyConditionBuilder{}, false
+}
+
+type notExistsFilter struct {
+	key string
+}
+
+func (f notExistsFilter) Key() string {
+	return f.key
+}
+
+func (f notExistsFilter) Op() filterOp {
+	return notExistsOp
+}
+
+func (f notExistsFilter) Condition(name expression.NameBuilder) expression.ConditionBuilder {
+	return expression.AttributeNotExists(name)
+}
+
+func (f notExistsFilter) KeyCondition(key expression.KeyBuilder) (expression.KeyConditionBuilder, bool) {
+	// DynamoDB key conditions do not support attribute existence
+	return expression.KeyConditionBuilder{}, false
+}
+
+type notEqualsFilter struct {
+	key   string
+	value interface{}
+}
+
+func (f notEqualsFilter) Key() string {
+	return f.key
+}
+
+func (f notEqualsFilter) Op() filterOp {
+	return notEqualsOp
+}
+
+func (f notEqualsFilter) Condition(name expression.NameBuilder) expression.ConditionBuilder {
+	return name.NotEqual(expression.Value(f.value))
+}
+
+func (f notEqualsFilter) KeyCondition(key expression.KeyBuilder) (expression.KeyConditionBuilder, bool) {
+	// DynamoDB key conditions do not support inequality
+	return expression.KeyConditionBuilder{}, false
+}
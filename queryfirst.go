@@ -0,0 +1,75 @@
+package dynamodbfriend
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+// QueryFirst retrieves the first item returned by the query into val, which must be a non-nil
+// pointer. ErrItemNotFound is returned if the query returns no items.
+func (table *Table) QueryFirst(ctx context.Context, expr *QueryExpr, val interface{}) error {
+	parser, err := table.queryWithPageSize(ctx, expr, 1)
+	if err != nil {
+		return err
+	}
+	defer parser.Close()
+
+	return parser.nextOrNotFound(ctx, val)
+}
+
+// QueryOne retrieves the only item returned by the query into val, which must be a non-nil
+// pointer. ErrItemNotFound is returned if the query returns no items, and ErrMultipleItems is
+// returned if the query returns more than one item.
+func (table *Table) QueryOne(ctx context.Context, expr *QueryExpr, val interface{}) error {
+	parser, err := table.queryWithPageSize(ctx, expr, 2)
+	if err != nil {
+		return err
+	}
+	defer parser.Close()
+
+	if err := parser.nextOrNotFound(ctx, val); err != nil {
+		return err
+	}
+
+	// ensure no further items match
+	var extra map[string]interface{}
+	err = parser.Next(ctx, &extra)
+	if _, parsingComplete := err.(ErrParsingComplete); parsingComplete {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	err = ErrMultipleItems{TableName: table.Name}
+	parser.expr.logger.Printf("error: %s\n", err.Error())
+	return err
+}
+
+// queryWithPageSize begins a query with an efficient page size for reading only a few items. The
+// page size is only applied if the expression does not set a limit and the query has no filter
+// conditions, since filtered pages may contain fewer matching items than the limit.
+func (table *Table) queryWithPageSize(ctx context.Context, expr *QueryExpr,
+	pageSize int64) (*QueryParser, error) {
+
+	parser, err := table.Query(ctx, expr)
+	if err != nil {
+		return nil, err
+	}
+
+	if parser.queryInput.Limit == nil && parser.queryInput.FilterExpression == nil {
+		parser.queryInput.Limit = aws.Int64(pageSize)
+		parser.expr.logger.Printf("query limit set to %d items\n", pageSize)
+	}
+
+	return parser, nil
+}
+
+func (parser *QueryParser) nextOrNotFound(ctx context.Context, val interface{}) error {
+	err := parser.Next(ctx, val)
+	if _, parsingComplete := err.(ErrParsingComplete); parsingComplete {
+		err = ErrItemNotFound{TableName: parser.table.Name}
+		parser.expr.logger.Printf("error: %s\n", err.Error())
+	}
+	return err
+}
@@ -2,64 +2,376 @@ package dynamodbfriend
 
 import (
 	"context"
+	"crypto/sha256"
+	"hash"
+	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
-	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 )
 
 // QueryParser is used for parsing query results.
 // The query is executed lazily. The underlying query will only happen when new items are
 // requested and all buffered items have already been consumed.
 type QueryParser struct {
-	table *Table
+	table      *Table
+	readClient dynamodbiface.DynamoDBAPI
 
+	index            *tableIndex
 	expr             *QueryExpr
 	queryInput       *dynamodb.QueryInput
+	startKey         map[string]*dynamodb.AttributeValue
 	lastEvaluatedKey map[string]*dynamodb.AttributeValue
 
 	bufferedItemsRemaining int
 	bufferedItems          []map[string]*dynamodb.AttributeValue
 	currentBufferIndex     int
 
-	totalPagesParsed int
+	totalPagesParsed  int
+	totalItemsScanned int
+	totalItemsMatched int
+	pageScannedCount  int
+
+	itemsReturned int
+
+	digest hash.Hash
+
+	// queries of each value of a partition key IN condition, if applicable
+	partitionCtx     context.Context
+	partitionInputs  []*dynamodb.QueryInput
+	partitionPages   chan partitionPage
+	stopPartitions   context.CancelFunc
+	partitionsClosed bool
+
+	enrichments []enrichment
+
+	closed bool
+}
+
+func newQueryParser(table *Table, index *tableIndex, expr *QueryExpr,
+	queryInput *dynamodb.QueryInput) *QueryParser {
+
+	return &QueryParser{
+		table:            table,
+		readClient:       table.baseClient,
+		index:            index,
+		expr:             expr,
+		queryInput:       queryInput,
+		startKey:         queryInput.ExclusiveStartKey,
+		lastEvaluatedKey: queryInput.ExclusiveStartKey,
+		bufferedItems:    []map[string]*dynamodb.AttributeValue{},
+		digest:           sha256.New(),
+	}
 }
 
 // Next retrieves the next value returned by the query. The val must be a non-nil pointer.
 // The underlying query will only execute when new items are requested and any buffered items have
 // already been consumed.
 func (parser *QueryParser) Next(ctx context.Context, val interface{}) error {
+	storedItem, err := parser.nextStoredItem(ctx)
+	if err != nil {
+		return err
+	}
+
+	return parser.decodeStoredItem(ctx, storedItem, val)
+}
+
+// decodeStoredItem unmarshals an item as stored in the table into val, reversing all table-level
+// transformations and applying the parser's enrichments.
+func (parser *QueryParser) decodeStoredItem(ctx context.Context,
+	storedItem map[string]*dynamodb.AttributeValue, val interface{}) error {
+
+	item, err := parser.table.itemFromStore(storedItem)
+	if err != nil {
+		return err
+	}
+
+	parser.table.stripRestrictedAttributes(ctx, item)
+
+	err = parser.table.unmarshalItemChecked(item, val, !parser.expr.attributesSpecified)
+	if err != nil {
+		return err
+	}
+
+	return applyEnrichments(parser.enrichments, val)
+}
+
+// nextStoredItem returns the next item as stored in the table. The returned item must not be
+// modified, since buffered items may be rewound.
+func (parser *QueryParser) nextStoredItem(
+	ctx context.Context) (map[string]*dynamodb.AttributeValue, error) {
+
 	parsingComplete := func(reason string) error {
 		err := ErrParsingComplete{reason: reason}
 		parser.expr.logger.Printf("%s\n", err)
 		return err
 	}
 
+	if parser.closed {
+		return nil, parsingComplete("parser has been closed")
+	}
+
+	// observe cancellation even when items remain buffered
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if parser.totalLimitReached() {
+		parser.stopPartitionQueries()
+		return nil, parsingComplete("total limit has been reached")
+	}
+
 	// execute a new query to refill the buffer if necessary
 	// retry until new items are found or a parsing complete condition has been met
 	for parser.currentBufferIndex == len(parser.bufferedItems) {
 		if parser.allItemsParsed() {
-			return parsingComplete("all items have been parsed")
+			return nil, parsingComplete("all items have been parsed")
 		} else if parser.maxPaginationReached() {
-			return parsingComplete("max pagination has been reached")
+			return nil, parsingComplete("max pagination has been reached")
 		}
 
-		parser.queryInput.ExclusiveStartKey = parser.lastEvaluatedKey
+		if err := parser.fetchNextPage(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	storedItem := parser.bufferedItems[parser.currentBufferIndex]
+	parser.currentBufferIndex++
+	parser.itemsReturned++
+
+	return storedItem, nil
+}
+
+// itemFromStore returns a copy of an item as stored in the table with all table-level
+// transformations reversed. The stored item is left unmodified, such as so that buffered items
+// may be rewound.
+func (table *Table) itemFromStore(
+	storedItem map[string]*dynamodb.AttributeValue) (map[string]*dynamodb.AttributeValue, error) {
+
+	item := copyItem(storedItem)
+
+	table.stripTenantPrefix(item)
+	table.removeAliases(item)
+
+	return table.migrateItem(item, storedItem)
+}
 
-		queryOutput, err := parser.table.baseClient.QueryWithContext(ctx, parser.queryInput)
+func (parser *QueryParser) fetchNextPage(ctx context.Context) error {
+	if parser.partitionInputs != nil {
+		return parser.fetchNextPartitionPage(ctx)
+	}
+
+	parser.queryInput.ExclusiveStartKey = parser.lastEvaluatedKey
+	parser.queryInput.ReturnConsumedCapacity = parser.table.returnConsumedCapacity()
+
+	coalesce := parser.table.requestCoalescing != nil && parser.totalPagesParsed == 0
+
+	var inputKey string
+	if parser.table.queryCache != nil || coalesce {
+		var err error
+		inputKey, err = queryCacheKey(parser.queryInput)
 		if err != nil {
 			return err
 		}
+	}
 
-		parser.lastEvaluatedKey = queryOutput.LastEvaluatedKey
-		parser.totalPagesParsed++
-		parser.bufferedItems = queryOutput.Items
-		parser.currentBufferIndex = 0
+	// serve page from query cache, if applicable
+	if parser.table.queryCache != nil {
+		if page, found := parser.table.queryCache.getPage(inputKey); found {
+			parser.expr.logger.Printf("query page served from cache\n")
+			parser.loadPage(page)
+			return nil
+		}
 	}
 
-	thisItem := parser.bufferedItems[parser.currentBufferIndex]
-	parser.currentBufferIndex++
+	var page *queryPage
+	var err error
+	if coalesce {
+		// share first page with concurrent identical queries
+		var result interface{}
+		var shared bool
+		result, err, shared = parser.table.requestCoalescing.Do(inputKey,
+			func() (interface{}, error) {
+				return parser.executeQuery(ctx)
+			})
+		if shared {
+			parser.expr.logger.Printf("query page shared with concurrent identical query\n")
+		}
+		if err == nil {
+			page = result.(*queryPage)
+		}
+	} else {
+		page, err = parser.executeQuery(ctx)
+	}
+	if err != nil {
+		return err
+	}
 
-	return dynamodbattribute.UnmarshalMap(thisItem, val)
+	if parser.table.queryCache != nil {
+		parser.table.queryCache.setPage(inputKey, page)
+	}
+
+	parser.loadPage(page)
+
+	return nil
+}
+
+func (parser *QueryParser) executeQuery(ctx context.Context) (*queryPage, error) {
+	return parser.executeQueryInput(ctx, parser.queryInput)
+}
+
+// executeQueryInput reads a single page of results of the query input.
+func (parser *QueryParser) executeQueryInput(ctx context.Context,
+	queryInput *dynamodb.QueryInput) (*queryPage, error) {
+
+	start := time.Now()
+	queryOutput, err := parser.readClient.QueryWithContext(ctx, queryInput,
+		parser.expr.retryPolicy.requestOptions()...)
+
+	stats := OperationStats{
+		Operation: "Query",
+		IndexName: aws.StringValue(queryInput.IndexName),
+		Latency:   time.Since(start),
+		Err:       err,
+	}
+	if queryOutput != nil {
+		stats.ConsumedCapacity = consumedCapacityUnits(queryOutput.ConsumedCapacity)
+		stats.Items = len(queryOutput.Items)
+	}
+	parser.table.emitStats(ctx, stats)
+
+	if err != nil {
+		return nil, err
+	}
+
+	items := queryOutput.Items
+	if parser.expr.hydrate && len(items) > 0 {
+		if items, err = parser.hydrateItems(ctx, items); err != nil {
+			return nil, err
+		}
+	}
+
+	return &queryPage{
+		items:            items,
+		lastEvaluatedKey: queryOutput.LastEvaluatedKey,
+		scannedCount:     int(aws.Int64Value(queryOutput.ScannedCount)),
+	}, nil
+}
+
+// queryPage is a single page of query results.
+type queryPage struct {
+	items            []map[string]*dynamodb.AttributeValue
+	lastEvaluatedKey map[string]*dynamodb.AttributeValue
+	scannedCount     int
+}
+
+func (parser *QueryParser) loadPage(page *queryPage) {
+	parser.lastEvaluatedKey = page.lastEvaluatedKey
+	parser.totalPagesParsed++
+	parser.bufferedItems = parser.table.withoutCounterItems(page.items)
+	parser.currentBufferIndex = 0
+
+	parser.pageScannedCount = page.scannedCount
+	parser.totalItemsScanned += page.scannedCount
+	parser.totalItemsMatched += len(parser.bufferedItems)
+
+	for _, item := range parser.bufferedItems {
+		parser.writeDigest(item)
+	}
+}
+
+// EstimatedRemaining returns an estimate of the number of items remaining to be returned by Next.
+// The estimate is based on the number of items in the queried index and the ratio of matching
+// items to evaluated items observed so far, and is only refreshed when a new page is read. The
+// estimate is exact once all pages have been read, and never exceeds the remaining total limit of
+// the query.
+func (parser *QueryParser) EstimatedRemaining() int {
+	estimate := parser.estimatedRemainingItems()
+	if parser.expr.totalLimitSpecified {
+		if remaining := parser.expr.totalLimit - parser.itemsReturned; estimate > remaining {
+			return remaining
+		}
+	}
+	return estimate
+}
+
+func (parser *QueryParser) estimatedRemainingItems() int {
+	bufferedRemaining := len(parser.bufferedItems) - parser.currentBufferIndex
+	if parser.closed {
+		return 0
+	} else if parser.allItemsParsed() || parser.maxPaginationReached() {
+		return bufferedRemaining
+	} else if parser.totalItemsScanned == 0 {
+		return parser.index.Size
+	}
+
+	unscanned := parser.index.Size - parser.totalItemsScanned
+	if unscanned < 0 {
+		unscanned = 0
+	}
+	matchRatio := float64(parser.totalItemsMatched) / float64(parser.totalItemsScanned)
+
+	return bufferedRemaining + int(float64(unscanned)*matchRatio)
+}
+
+// Reset restarts iteration from the beginning of the query. Buffered items are discarded, and
+// the query is executed again from its original start key when items are next requested. Reset
+// may also be used to reopen a closed parser.
+func (parser *QueryParser) Reset() {
+	parser.expr.logger.Printf("parser reset to start of query\n")
+
+	parser.lastEvaluatedKey = parser.startKey
+	parser.queryInput.ExclusiveStartKey = parser.startKey
+	parser.bufferedItems = []map[string]*dynamodb.AttributeValue{}
+	parser.currentBufferIndex = 0
+	parser.totalPagesParsed = 0
+	parser.totalItemsScanned = 0
+	parser.totalItemsMatched = 0
+	parser.itemsReturned = 0
+	parser.digest.Reset()
+	parser.closed = false
+
+	// partition queries are started again when items are next requested
+	parser.stopPartitionQueries()
+	parser.partitionPages = nil
+	parser.partitionsClosed = false
+}
+
+// Rewind moves iteration back by n items, so that the next n calls to Next return the same items
+// again. Only items in the current page buffer may be rewound. ErrRewindOutOfRange is returned if
+// fewer than n items can be rewound, in which case the parser is unchanged.
+func (parser *QueryParser) Rewind(n int) error {
+	if n < 0 || n > parser.currentBufferIndex || parser.closed {
+		err := ErrRewindOutOfRange{Requested: n, Available: parser.currentBufferIndex}
+		parser.expr.logger.Printf("error: %s\n", err.Error())
+		return err
+	}
+
+	parser.currentBufferIndex -= n
+	parser.itemsReturned -= n
+	return nil
+}
+
+// Close releases any buffered items held by the parser. Subsequent calls to Next will return
+// ErrParsingComplete. Close implements io.Closer and always returns nil.
+func (parser *QueryParser) Close() error {
+	if parser.closed {
+		return nil
+	}
+
+	if !parser.allItemsParsed() && !parser.maxPaginationReached() && !parser.totalLimitReached() {
+		parser.expr.logger.Printf("parser closed before all items were parsed\n")
+	}
+
+	parser.stopPartitionQueries()
+
+	parser.closed = true
+	parser.bufferedItems = nil
+	parser.currentBufferIndex = 0
+	parser.lastEvaluatedKey = nil
+
+	return nil
 }
 
 func (parser *QueryParser) lastEvaluatedKeyIsEmpty() bool {
@@ -67,6 +379,9 @@ func (parser *QueryParser) lastEvaluatedKeyIsEmpty() bool {
 }
 
 func (parser *QueryParser) allItemsParsed() bool {
+	if parser.partitionInputs != nil {
+		return parser.partitionsClosed
+	}
 	return parser.totalPagesParsed > 0 && parser.lastEvaluatedKeyIsEmpty()
 }
 
@@ -74,3 +389,7 @@ func (parser *QueryParser) maxPaginationReached() bool {
 	return parser.expr.maxPaginationSpecified &&
 		parser.totalPagesParsed == parser.expr.maxPagination
 }
+
+func (parser *QueryParser) totalLimitReached() bool {
+	return parser.expr.totalLimitSpecified && parser.itemsReturned >= parser.expr.totalLimit
+}
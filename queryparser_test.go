@@ -0,0 +1,108 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestCancellationBetweenBufferedItems(t *testing.T) {
+	cases := []struct {
+		name  string
+		drain func(ctx context.Context, parser *QueryParser) error
+	}{
+		{
+			name: "Next",
+			drain: func(ctx context.Context, parser *QueryParser) error {
+				var item map[string]interface{}
+				return parser.Next(ctx, &item)
+			},
+		},
+		{
+			name: "All",
+			drain: func(ctx context.Context, parser *QueryParser) error {
+				items := []map[string]interface{}{}
+				return parser.All(ctx, &items)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := newFakeDynamoDB("items", "id", "ts")
+			fake.queryItems = []map[string]*dynamodb.AttributeValue{
+				stringItem(map[string]string{"id": "a", "ts": "1"}),
+				stringItem(map[string]string{"id": "a", "ts": "2"}),
+				stringItem(map[string]string{"id": "a", "ts": "3"}),
+			}
+			table := newFakeTable(fake)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			parser, err := table.Query(ctx, NewQuery("id").Equals("a"))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			// read the first item so that the rest of the page is buffered
+			var item map[string]interface{}
+			if err := parser.Next(ctx, &item); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			cancel()
+			if err := tc.drain(ctx, parser); err != ctx.Err() {
+				t.Errorf("expected %v, got %v", ctx.Err(), err)
+			}
+			if len(fake.queryInputs) != 1 {
+				t.Errorf("expected 1 query, got %d", len(fake.queryInputs))
+			}
+		})
+	}
+}
+
+func TestTotalLimit(t *testing.T) {
+	cases := []struct {
+		name        string
+		limit       int
+		expectItems int
+		expectErr   bool
+	}{
+		{name: "below page size", limit: 2, expectItems: 2},
+		{name: "above page size", limit: 5, expectItems: 3},
+		{name: "zero", limit: 0, expectErr: true},
+		{name: "negative", limit: -1, expectErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := newFakeDynamoDB("items", "id", "ts")
+			fake.queryItems = []map[string]*dynamodb.AttributeValue{
+				stringItem(map[string]string{"id": "a", "ts": "1"}),
+				stringItem(map[string]string{"id": "a", "ts": "2"}),
+				stringItem(map[string]string{"id": "a", "ts": "3"}),
+			}
+			table := newFakeTable(fake)
+
+			parser, err := table.Query(context.Background(),
+				NewQuery("id").Equals("a").TotalLimit(tc.limit))
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("expected error for total limit %d", tc.limit)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			items := []map[string]interface{}{}
+			if err := parser.All(context.Background(), &items); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			} else if len(items) != tc.expectItems {
+				t.Errorf("expected %d items, got %d", tc.expectItems, len(items))
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// queryPlan is the outcome of index selection for an expression shape, along with the template of
+// the query input built for the shape once it has been validated.
+type queryPlan struct {
+	indexName      string
+	consistentRead bool
+	hydrate        bool
+
+	template atomic.Pointer[queryInputTemplate]
+}
+
+// queryInputTemplate is the part of a built query input that is independent of condition values.
+type queryInputTemplate struct {
+	keyCondition string
+	filter       string
+	projection   string
+	names        map[string]*string
+}
+
+func newQueryInputTemplate(queryInput *dynamodb.QueryInput) *queryInputTemplate {
+	return &queryInputTemplate{
+		keyCondition: aws.StringValue(queryInput.KeyConditionExpression),
+		filter:       aws.StringValue(queryInput.FilterExpression),
+		projection:   aws.StringValue(queryInput.ProjectionExpression),
+		names:        queryInput.ExpressionAttributeNames,
+	}
+}
+
+// matches returns true if the query input was built from the template, differing only by values.
+func (template *queryInputTemplate) matches(queryInput *dynamodb.QueryInput) bool {
+	return template.keyCondition == aws.StringValue(queryInput.KeyConditionExpression) &&
+		template.filter == aws.StringValue(queryInput.FilterExpression) &&
+		template.projection == aws.StringValue(queryInput.ProjectionExpression) &&
+		reflect.DeepEqual(template.names, queryInput.ExpressionAttributeNames)
+}
+
+// validatedInput returns true if the query input matches the plan's validated template, so that
+// it need not be validated again.
+func (plan *queryPlan) validatedInput(queryInput *dynamodb.QueryInput) bool {
+	template := plan.template.Load()
+	return template != nil && template.matches(queryInput)
+}
+
+// queryPlanCache holds plans by expression shape. Plans are cleared in place so that the cache is
+// safe to use concurrently with a metadata refresh.
+type queryPlanCache struct {
+	plans sync.Map
+}
+
+func (planCache *queryPlanCache) clear() {
+	planCache.plans.Clear()
+}
+
+// WithQueryPlanCache sets whether the outcome of index selection and the validated query input
+// are cached for structurally identical query expressions, which use the same attributes and
+// conditionals with possibly different values. Cached plans are discarded when table metadata is
+// refreshed.
+func (table *Table) WithQueryPlanCache(enabled bool) *Table {
+	if enabled {
+		table.planCache = &queryPlanCache{}
+	} else {
+		table.planCache = nil
+	}
+	return table
+}
+
+// shapeKey returns a string identifying the structure of the expression relevant to index
+// selection, independent of condition values.
+func (expr *QueryExpr) shapeKey() string {
+	keys := []string{}
+	for key := range expr.filters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := []string{}
+	for _, key := range keys {
+		for _, filter := range expr.filters[key] {
+			parts = append(parts, fmt.Sprintf("%q:%s", key, filter.Op()))
+		}
+	}
+	for _, group := range expr.filterGroups {
+		parts = append(parts, group.shape())
+	}
+	if expr.attributesSpecified {
+		parts = append(parts, fmt.Sprintf("select:%q", expr.attributes))
+	}
+	if expr.orderMatters {
+		parts = append(parts, fmt.Sprintf("order:%q", expr.orderKey))
+	}
+	if expr.hydrationAllowed {
+		parts = append(parts, "hydration")
+	}
+	parts = append(parts, fmt.Sprintf("consistent:%t:%t",
+		expr.consistentReadSpecified, expr.consistentRead))
+
+	return strings.Join(parts, ",")
+}
+
+// chooseIndexWithPlanCache chooses an index for the query, using a cached plan for the
+// expression's shape if one exists. The plan of the expression's shape is returned, or nil if
+// plans are not cached.
+func (table *Table) chooseIndexWithPlanCache(ctx context.Context,
+	expr *QueryExpr) (*QueryExpr, *tableIndex, *queryPlan, error) {
+
+	planCache := table.planCache
+	if planCache == nil || expr.keyConditionSpecified {
+		expr, index, err := table.chooseIndexWithReadPolicy(ctx, expr)
+		return expr, index, nil, err
+	}
+
+	// refresh metadata if stale, which also discards cached plans
+	if err := table.loadIndexMetadata(ctx); err != nil {
+		return nil, nil, nil, err
+	}
+
+	shapeKey := expr.shapeKey()
+	if cached, found := planCache.plans.Load(shapeKey); found {
+		plan := cached.(*queryPlan)
+		if index, found := table.allIndexes()[plan.indexName]; found {
+			expr.logger.Printf("choosing index for query from plan cache: %s\n", plan.indexName)
+			if plan.consistentRead && !expr.consistentRead {
+				consistentExpr := *expr
+				consistentExpr.consistentRead = true
+				expr = &consistentExpr
+			}
+			return hydratedExpr(expr, plan.hydrate), index, plan, nil
+		}
+	}
+
+	expr, index, err := table.chooseIndexWithReadPolicy(ctx, expr)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	plan := &queryPlan{
+		indexName:      index.Name,
+		consistentRead: expr.consistentRead,
+		hydrate:        expr.hydrate,
+	}
+	planCache.plans.Store(shapeKey, plan)
+
+	return expr, index, plan, nil
+}
@@ -0,0 +1,132 @@
+package dynamodbfriend
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// defaultRedactionTruncateLength is the number of characters of values kept by RedactTruncate by
+// default.
+const defaultRedactionTruncateLength = 4
+
+// RedactionMode is how attribute values are redacted from logs.
+type RedactionMode int
+
+const (
+	// RedactOmit replaces attribute values with a placeholder. This is the default, so that values
+	// are only logged when a table or client opts in.
+	RedactOmit RedactionMode = iota
+	// RedactNone logs attribute values as is.
+	RedactNone
+	// RedactHash replaces attribute values with a truncated SHA-256 hash, so that equal values may
+	// be correlated across log lines without being revealed.
+	RedactHash
+	// RedactTruncate keeps only the leading characters of attribute values.
+	RedactTruncate
+)
+
+// RedactionPolicy describes how attribute values are redacted from logged expressions and errors,
+// so that logging does not leak sensitive data such as PII into log systems.
+type RedactionPolicy struct {
+	Mode RedactionMode
+
+	// TruncateLength is the number of characters kept by RedactTruncate. Defaults to 4.
+	TruncateLength int
+
+	// AllowedAttributes are the names of attributes whose values are logged as is.
+	AllowedAttributes []string
+}
+
+var expressionPlaceholderPattern = regexp.MustCompile(`[#:][0-9A-Za-z_]+`)
+
+// WithLogRedaction sets the redaction policy of attribute values logged by all tables
+// subsequently instantiated from this client.
+func (client *Client) WithLogRedaction(policy RedactionPolicy) *Client {
+	client.logRedaction = policy
+	return client
+}
+
+// WithLogRedaction sets the redaction policy of attribute values logged for this table, such as
+// in built query and scan expressions and unique constraint errors.
+func (table *Table) WithLogRedaction(policy RedactionPolicy) *Table {
+	table.logRedaction = policy
+	return table
+}
+
+// redactValue redacts the formatted value of the named attribute according to the table's
+// redaction policy.
+func (table *Table) redactValue(attribute, value string) string {
+	policy := table.logRedaction
+	for _, allowed := range policy.AllowedAttributes {
+		if allowed == attribute {
+			return value
+		}
+	}
+
+	switch policy.Mode {
+	case RedactOmit:
+		return "<redacted>"
+	case RedactHash:
+		sum := sha256.Sum256([]byte(value))
+		return "<sha256:" + hex.EncodeToString(sum[:6]) + ">"
+	case RedactTruncate:
+		length := policy.TruncateLength
+		if length <= 0 {
+			length = defaultRedactionTruncateLength
+		}
+		if runes := []rune(value); len(runes) > length {
+			return string(runes[:length]) + "..."
+		}
+	}
+	return value
+}
+
+// describeExpression renders a built expression for logging, substituting attribute names and
+// values for their placeholders. Each value is redacted as a value of the attribute last named
+// before it in the expression.
+func (table *Table) describeExpression(expr *string, names map[string]*string,
+	values map[string]*dynamodb.AttributeValue) string {
+
+	if expr == nil {
+		return ""
+	}
+
+	var lastName string
+	return expressionPlaceholderPattern.ReplaceAllStringFunc(*expr, func(placeholder string) string {
+		if strings.HasPrefix(placeholder, "#") {
+			name, found := names[placeholder]
+			if !found {
+				return placeholder
+			}
+			lastName = aws.StringValue(name)
+			return lastName
+		}
+
+		av, found := values[placeholder]
+		if !found {
+			return placeholder
+		}
+		return table.redactValue(lastName, formatAttributeValue(av))
+	})
+}
+
+// formatAttributeValue formats an attribute value on a single line.
+func formatAttributeValue(av *dynamodb.AttributeValue) string {
+	switch {
+	case av.S != nil:
+		return strconv.Quote(*av.S)
+	case av.N != nil:
+		return *av.N
+	case av.BOOL != nil:
+		return strconv.FormatBool(*av.BOOL)
+	case av.NULL != nil:
+		return "null"
+	}
+	return strings.Join(strings.Fields(av.String()), " ")
+}
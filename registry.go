@@ -0,0 +1,111 @@
+package dynamodbfriend
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// TableLocation describes where a logically-named table physically resides. If Base is set, it is
+// used to access the table. Otherwise, a DynamoDB client is created for the region, assuming
+// RoleARN if specified. Empty fields default to the client's own settings.
+type TableLocation struct {
+	PhysicalName string
+	Region       string
+	RoleARN      string
+	Base         dynamodbiface.DynamoDBAPI
+}
+
+type tableRegistry struct {
+	mu        sync.Mutex
+	locations map[string]TableLocation
+	clients   map[TableLocation]dynamodbiface.DynamoDBAPI
+}
+
+func newTableRegistry() *tableRegistry {
+	return &tableRegistry{
+		locations: map[string]TableLocation{},
+		clients:   map[TableLocation]dynamodbiface.DynamoDBAPI{},
+	}
+}
+
+// RegisterTable maps a logical table name to a physical table location. Subsequent calls to Table
+// with the logical name resolve to the physical table name and the client for its location.
+func (client *Client) RegisterTable(logicalName string, location TableLocation) error {
+	registry := client.registry
+	if registry == nil {
+		err := fmt.Errorf("tables may only be registered with clients created by NewClient")
+		client.getLogger().Printf("error: %s\n", err.Error())
+		return err
+	}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	if location.PhysicalName == "" {
+		location.PhysicalName = logicalName
+	}
+
+	// create client for location if not already known
+	if location.Base == nil && (location.Region != "" || location.RoleARN != "") {
+		clientKey := TableLocation{Region: location.Region, RoleARN: location.RoleARN}
+		base, found := registry.clients[clientKey]
+		if !found {
+			var err error
+			base, err = newRegionalClient(location.Region, location.RoleARN)
+			if err != nil {
+				client.getLogger().Printf("error: %s\n", err.Error())
+				return err
+			}
+			registry.clients[clientKey] = base
+		}
+		location.Base = base
+	}
+
+	registry.locations[logicalName] = location
+	client.getLogger().Printf("registered table \"%s\" as \"%s\"\n",
+		logicalName, location.PhysicalName)
+
+	return nil
+}
+
+func (client *Client) resolveTable(logicalName string) (string, dynamodbiface.DynamoDBAPI) {
+	if client.registry == nil {
+		return logicalName, client.Base
+	}
+
+	client.registry.mu.Lock()
+	defer client.registry.mu.Unlock()
+
+	location, found := client.registry.locations[logicalName]
+	if !found {
+		return logicalName, client.Base
+	}
+	if location.Base == nil {
+		return location.PhysicalName, client.Base
+	}
+	return location.PhysicalName, location.Base
+}
+
+func newRegionalClient(region, roleARN string) (dynamodbiface.DynamoDBAPI, error) {
+	config := aws.NewConfig()
+	if region != "" {
+		config = config.WithRegion(region)
+	}
+
+	sess, err := session.NewSession(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if roleARN != "" {
+		return dynamodb.New(sess, aws.NewConfig().
+			WithCredentials(stscreds.NewCredentials(sess, roleARN))), nil
+	}
+	return dynamodb.New(sess), nil
+}
@@ -0,0 +1,188 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// TableDescription describes a table and its replicas.
+type TableDescription struct {
+	Name      string
+	Status    string
+	ItemCount int64
+	SizeBytes int64
+	Replicas  []ReplicaDescription
+}
+
+// ReplicaDescription describes a global table replica.
+type ReplicaDescription struct {
+	Region string
+	Status string
+}
+
+// Describe returns a description of the table, including its global table replicas.
+func (table *Table) Describe(ctx context.Context) (*TableDescription, error) {
+	describeInfo, err := table.baseClient.DescribeTableWithContext(ctx,
+		&dynamodb.DescribeTableInput{
+			TableName: aws.String(table.Name),
+		})
+	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
+		return nil, err
+	}
+
+	tableDescription := describeInfo.Table
+	description := &TableDescription{
+		Name:      aws.StringValue(tableDescription.TableName),
+		Status:    aws.StringValue(tableDescription.TableStatus),
+		ItemCount: aws.Int64Value(tableDescription.ItemCount),
+		SizeBytes: aws.Int64Value(tableDescription.TableSizeBytes),
+		Replicas:  []ReplicaDescription{},
+	}
+	for _, replica := range tableDescription.Replicas {
+		description.Replicas = append(description.Replicas, ReplicaDescription{
+			Region: aws.StringValue(replica.RegionName),
+			Status: aws.StringValue(replica.ReplicaStatus),
+		})
+	}
+
+	return description, nil
+}
+
+// ReplicaLagSource is an interface for retrieving the replication lag of a global table replica.
+type ReplicaLagSource interface {
+	ReplicationLatency(ctx context.Context, tableName, region string) (time.Duration, error)
+}
+
+// CloudWatchReplicaLag is a ReplicaLagSource reading the ReplicationLatency metric published by
+// DynamoDB to CloudWatch. The metric is published in the region of the source table.
+type CloudWatchReplicaLag struct {
+	cw cloudwatchiface.CloudWatchAPI
+}
+
+// NewCloudWatchReplicaLag creates a new CloudWatchReplicaLag from a CloudWatch client.
+func NewCloudWatchReplicaLag(cw cloudwatchiface.CloudWatchAPI) *CloudWatchReplicaLag {
+	return &CloudWatchReplicaLag{cw: cw}
+}
+
+// ReplicationLatency returns the average replication latency to the receiving region over the
+// last five minutes.
+func (l *CloudWatchReplicaLag) ReplicationLatency(ctx context.Context, tableName,
+	region string) (time.Duration, error) {
+
+	endTime := time.Now()
+	statsInfo, err := l.cw.GetMetricStatisticsWithContext(ctx, &cloudwatch.GetMetricStatisticsInput{
+		Namespace:  aws.String("AWS/DynamoDB"),
+		MetricName: aws.String("ReplicationLatency"),
+		Dimensions: []*cloudwatch.Dimension{
+			{Name: aws.String("TableName"), Value: aws.String(tableName)},
+			{Name: aws.String("ReceivingRegion"), Value: aws.String(region)},
+		},
+		StartTime:  aws.Time(endTime.Add(-5 * time.Minute)),
+		EndTime:    aws.Time(endTime),
+		Period:     aws.Int64(300),
+		Statistics: []*string{aws.String(cloudwatch.StatisticAverage)},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// use the most recent datapoint, if any
+	var latest *cloudwatch.Datapoint
+	for _, datapoint := range statsInfo.Datapoints {
+		if latest == nil || datapoint.Timestamp.After(*latest.Timestamp) {
+			latest = datapoint
+		}
+	}
+	if latest == nil {
+		return 0, nil
+	}
+
+	// replication latency is reported in milliseconds
+	return time.Duration(aws.Float64Value(latest.Average) * float64(time.Millisecond)), nil
+}
+
+// replicaLagTTL is how long a replica's replication lag is reused before it is read again from the
+// lag source, so that reads do not each query the lag source.
+const replicaLagTTL = time.Minute
+
+type replicaReads struct {
+	region    string
+	client    dynamodbiface.DynamoDBAPI
+	lagSource ReplicaLagSource
+	maxLag    time.Duration
+
+	// lag is the last replication lag read from the lag source, or lagErr its error
+	mutex     sync.Mutex
+	lag       time.Duration
+	lagErr    error
+	lagExpiry time.Time
+}
+
+// WithReplicaReads directs reads on this table to the global table replica in region, accessed
+// through client, whenever the replica's replication lag reported by lagSource is below maxLag.
+// Otherwise, or if the lag cannot be determined, reads use the table's own client. The lag is read
+// from lagSource at most once a minute.
+func (table *Table) WithReplicaReads(region string, client dynamodbiface.DynamoDBAPI,
+	lagSource ReplicaLagSource, maxLag time.Duration) *Table {
+
+	table.replicaReads = &replicaReads{
+		region:    region,
+		client:    client,
+		lagSource: lagSource,
+		maxLag:    maxLag,
+	}
+	return table
+}
+
+// readClient returns the client to use for reads on this table.
+func (table *Table) readClient(ctx context.Context) dynamodbiface.DynamoDBAPI {
+	replica := table.replicaReads
+	if replica == nil {
+		return table.baseClient
+	}
+
+	if replica.lagBelowMax(ctx, table) {
+		return table.wrapClient(replica.client)
+	}
+	return table.baseClient
+}
+
+// lagBelowMax returns true if the last known replication lag of the replica is below the maximum,
+// reading the lag from the lag source if the last known lag has expired. The outcome is logged
+// only when the lag is read.
+func (replica *replicaReads) lagBelowMax(ctx context.Context, table *Table) bool {
+	replica.mutex.Lock()
+	defer replica.mutex.Unlock()
+
+	if time.Now().Before(replica.lagExpiry) {
+		return replica.lagErr == nil && replica.lag < replica.maxLag
+	}
+
+	replica.lag, replica.lagErr = replica.lagSource.ReplicationLatency(ctx, table.Name,
+		replica.region)
+	replica.lagExpiry = time.Now().Add(replicaLagTTL)
+
+	// failures due to the caller's context are not reused by other reads
+	if ctx.Err() != nil {
+		replica.lagExpiry = time.Time{}
+	}
+
+	if replica.lagErr != nil {
+		table.logger.Printf("warning: could not determine lag of replica in region \"%s\", "+
+			"reading from primary: %s\n", replica.region, replica.lagErr.Error())
+		return false
+	} else if replica.lag >= replica.maxLag {
+		table.logger.Printf("replica in region \"%s\" lag of %s exceeds %s, reading from primary\n",
+			replica.region, replica.lag, replica.maxLag)
+		return false
+	}
+	return true
+}
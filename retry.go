@@ -0,0 +1,58 @@
+package dynamodbfriend
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// RetryPolicy is the retry and backoff policy of the requests made by a single query or scan,
+// overriding the retry policy of the underlying DynamoDB client. Retries are made by the AWS SDK
+// for throttling and transient errors, with exponential backoff and jitter.
+type RetryPolicy struct {
+	// MaxRetries is the number of times a failed request is retried. Zero disables retries.
+	MaxRetries int
+
+	// MinDelay and MaxDelay bound the backoff delay between retries of transient errors. Zero
+	// values use the defaults of the AWS SDK.
+	MinDelay time.Duration
+	MaxDelay time.Duration
+
+	// MinThrottleDelay and MaxThrottleDelay bound the backoff delay between retries of throttled
+	// requests. Zero values use the defaults of the AWS SDK.
+	MinThrottleDelay time.Duration
+	MaxThrottleDelay time.Duration
+}
+
+// requestOptions returns the request options applying the retry policy, if any.
+func (policy *RetryPolicy) requestOptions() []request.Option {
+	if policy == nil {
+		return nil
+	}
+
+	retryer := client.DefaultRetryer{
+		NumMaxRetries:    policy.MaxRetries,
+		MinRetryDelay:    policy.MinDelay,
+		MaxRetryDelay:    policy.MaxDelay,
+		MinThrottleDelay: policy.MinThrottleDelay,
+		MaxThrottleDelay: policy.MaxThrottleDelay,
+	}
+	return []request.Option{func(r *request.Request) {
+		r.Retryer = retryer
+	}}
+}
+
+// WithRetryPolicy sets the retry policy of the requests made by the query, such as so that an
+// interactive endpoint may fail fast with few retries while batch traffic on the same client backs
+// off aggressively.
+func (expr *QueryExpr) WithRetryPolicy(policy RetryPolicy) *QueryExpr {
+	expr.retryPolicy = &policy
+	return expr
+}
+
+// WithRetryPolicy sets the retry policy of the requests made by the scan.
+func (expr *ScanExpr) WithRetryPolicy(policy RetryPolicy) *ScanExpr {
+	expr.retryPolicy = &policy
+	return expr
+}
@@ -0,0 +1,244 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+const sagaPrefix = "SAGA#"
+
+// SagaStatus is the persisted status of a saga.
+type SagaStatus string
+
+// Saga statuses persisted in the saga table.
+const (
+	SagaStatusRunning            SagaStatus = "Running"
+	SagaStatusCompensating       SagaStatus = "Compensating"
+	SagaStatusCompleted          SagaStatus = "Completed"
+	SagaStatusCompensated        SagaStatus = "Compensated"
+	SagaStatusCompensationFailed SagaStatus = "CompensationFailed"
+)
+
+// SagaAction is a single action of a saga step, typically a write or transaction.
+type SagaAction func(ctx context.Context) error
+
+type sagaStep struct {
+	name       string
+	action     SagaAction
+	compensate SagaAction
+}
+
+// sagaState is the state of a saga as persisted in the saga table.
+type sagaState struct {
+	Status         SagaStatus `dynamodbav:"sagaStatus"`
+	StepsCompleted int        `dynamodbav:"sagaStepsCompleted"`
+	FailedStep     string     `dynamodbav:"sagaFailedStep,omitempty"`
+}
+
+// Saga executes a sequence of steps, undoing completed steps with their compensating actions in
+// reverse order if any step fails. Saga state is persisted in a table so that an interrupted saga
+// can be resumed by running a saga with the same id and steps.
+type Saga struct {
+	id    string
+	table *Table
+	steps []sagaStep
+}
+
+// NewSaga instantiates a saga with the specified id, persisting its state in this table as an
+// item with all primary key attributes set to "SAGA#<id>". The table's primary key attributes
+// must be strings.
+func (table *Table) NewSaga(id string) *Saga {
+	return &Saga{
+		id:    id,
+		table: table,
+	}
+}
+
+// Step adds a step to the saga. If a later step fails, compensate is called to undo the action.
+// A nil compensate indicates the step needs no compensation. Since a saga may be resumed after
+// an interruption, actions and compensations should be idempotent.
+func (saga *Saga) Step(name string, action, compensate SagaAction) *Saga {
+	saga.steps = append(saga.steps, sagaStep{
+		name:       name,
+		action:     action,
+		compensate: compensate,
+	})
+	return saga
+}
+
+// ErrSagaFailed is returned when a step of a saga fails. CompensationErr is set if any completed
+// step could not be compensated.
+type ErrSagaFailed struct {
+	SagaID          string
+	Step            string
+	Err             error
+	CompensationErr error
+}
+
+func (e ErrSagaFailed) Error() string {
+	if e.CompensationErr != nil {
+		return fmt.Sprintf("saga \"%s\" failed at step \"%s\": %s; compensation failed: %s",
+			e.SagaID, e.Step, e.Err, e.CompensationErr)
+	}
+	return fmt.Sprintf("saga \"%s\" failed at step \"%s\": %s", e.SagaID, e.Step, e.Err)
+}
+
+// Run executes the saga's steps in order. If the saga was previously interrupted, steps already
+// completed are skipped, and an interrupted compensation is resumed. If a step fails, completed
+// steps are compensated in reverse order and ErrSagaFailed is returned. Running a saga that has
+// already completed returns nil, and running a saga that was already compensated returns
+// ErrSagaFailed without executing any steps.
+func (saga *Saga) Run(ctx context.Context) error {
+	state, err := saga.loadState(ctx)
+	if err != nil {
+		return err
+	}
+
+	switch state.Status {
+	case SagaStatusCompleted:
+		saga.table.logger.Printf("saga \"%s\" already completed\n", saga.id)
+		return nil
+	case SagaStatusCompensated, SagaStatusCompensationFailed:
+		err := ErrSagaFailed{
+			SagaID: saga.id,
+			Step:   state.FailedStep,
+			Err:    fmt.Errorf("saga previously ended with status \"%s\"", state.Status),
+		}
+		saga.table.logger.Printf("error: %s\n", err.Error())
+		return err
+	case SagaStatusCompensating:
+		return saga.compensate(ctx, state,
+			fmt.Errorf("saga interrupted while compensating"))
+	}
+
+	for state.StepsCompleted < len(saga.steps) {
+		step := saga.steps[state.StepsCompleted]
+		saga.table.logger.Printf("running step \"%s\" of saga \"%s\"\n", step.name, saga.id)
+
+		if stepErr := step.action(ctx); stepErr != nil {
+			return saga.compensate(ctx, state, stepErr)
+		}
+
+		state.StepsCompleted++
+		if state.StepsCompleted == len(saga.steps) {
+			state.Status = SagaStatusCompleted
+		}
+		if err := saga.saveState(ctx, state); err != nil {
+			return err
+		}
+	}
+
+	saga.table.logger.Printf("saga \"%s\" completed\n", saga.id)
+	return nil
+}
+
+// compensate undoes completed steps in reverse order following the failure of the next step, or
+// resumes compensation of an interrupted saga.
+func (saga *Saga) compensate(ctx context.Context, state *sagaState, stepErr error) error {
+	if state.Status != SagaStatusCompensating {
+		state.Status = SagaStatusCompensating
+		state.FailedStep = saga.steps[state.StepsCompleted].name
+		if err := saga.saveState(ctx, state); err != nil {
+			return err
+		}
+	}
+
+	sagaErr := ErrSagaFailed{
+		SagaID: saga.id,
+		Step:   state.FailedStep,
+		Err:    stepErr,
+	}
+	saga.table.logger.Printf("error: %s\n", sagaErr.Error())
+
+	for state.StepsCompleted > 0 {
+		step := saga.steps[state.StepsCompleted-1]
+		if step.compensate != nil {
+			saga.table.logger.Printf("compensating step \"%s\" of saga \"%s\"\n", step.name, saga.id)
+			if err := step.compensate(ctx); err != nil {
+				sagaErr.CompensationErr = err
+				saga.table.logger.Printf("error: %s\n", err.Error())
+				break
+			}
+		}
+
+		state.StepsCompleted--
+		if err := saga.saveState(ctx, state); err != nil {
+			return err
+		}
+	}
+
+	if sagaErr.CompensationErr != nil {
+		state.Status = SagaStatusCompensationFailed
+	} else {
+		state.Status = SagaStatusCompensated
+	}
+	if err := saga.saveState(ctx, state); err != nil {
+		return err
+	}
+
+	return sagaErr
+}
+
+func (saga *Saga) stateKey(ctx context.Context) (map[string]*dynamodb.AttributeValue, error) {
+	if err := saga.table.loadIndexMetadata(ctx); err != nil {
+		return nil, err
+	}
+	return saga.table.reservedItemKey(ctx, sagaPrefix+saga.id)
+}
+
+func (saga *Saga) loadState(ctx context.Context) (*sagaState, error) {
+	key, err := saga.stateKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	getOutput, err := saga.table.baseClient.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(saga.table.Name),
+		Key:            key,
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		saga.table.logger.Printf("error: %s\n", err.Error())
+		return nil, err
+	}
+
+	state := &sagaState{Status: SagaStatusRunning}
+	if len(getOutput.Item) > 0 {
+		if err := dynamodbattribute.UnmarshalMap(getOutput.Item, state); err != nil {
+			saga.table.logger.Printf("error: %s\n", err.Error())
+			return nil, err
+		}
+		saga.table.logger.Printf("resuming saga \"%s\" with status \"%s\" after %d steps\n",
+			saga.id, state.Status, state.StepsCompleted)
+	}
+	return state, nil
+}
+
+func (saga *Saga) saveState(ctx context.Context, state *sagaState) error {
+	item, err := dynamodbattribute.MarshalMap(state)
+	if err != nil {
+		saga.table.logger.Printf("error: %s\n", err.Error())
+		return err
+	}
+
+	key, err := saga.stateKey(ctx)
+	if err != nil {
+		return err
+	}
+	for keyName, av := range key {
+		item[keyName] = av
+	}
+
+	_, err = saga.table.baseClient.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(saga.table.Name),
+		Item:      item,
+	})
+	if err != nil {
+		saga.table.logger.Printf("error: %s\n", err.Error())
+	}
+	return err
+}
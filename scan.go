@@ -0,0 +1,182 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+// segmentScan describes a resumable scan of a single segment of a table.
+type segmentScan struct {
+	segment       int
+	totalSegments int
+	filter        *expression.ConditionBuilder
+	keysOnly      bool
+
+	// progress is saved after each page under checkpointID, if checkpoints are set
+	checkpoints  CheckpointStore
+	checkpointID string
+}
+
+// scanSegment scans a segment of the table, calling handlePage for each page of results. Scans
+// with checkpoints resume from the last handled page, and the checkpoint is reset once the
+// segment is complete. Items are given as stored in the table. Index metadata must already be
+// loaded.
+func (table *Table) scanSegment(ctx context.Context, scan segmentScan,
+	handlePage func(ctx context.Context, page *dynamodb.ScanOutput) error) error {
+
+	// resume from checkpoint, if applicable
+	var startKey map[string]*dynamodb.AttributeValue
+	if scan.checkpoints != nil {
+		cursor, found, err := scan.checkpoints.LoadCheckpoint(ctx, scan.checkpointID)
+		if err != nil {
+			table.logger.Printf("error: %s\n", err.Error())
+			return err
+		} else if found && cursor != "" {
+			if startKey, err = decodeCursor(cursor); err != nil {
+				table.logger.Printf("error: %s\n", err.Error())
+				return err
+			}
+		}
+	}
+
+	scanInput, err := table.segmentScanInput(scan)
+	if err != nil {
+		return err
+	}
+
+	for {
+		scanInput.ExclusiveStartKey = startKey
+
+		start := time.Now()
+		scanOutput, err := table.baseClient.ScanWithContext(ctx, scanInput)
+
+		stats := OperationStats{
+			Operation:     "Scan",
+			Latency:       time.Since(start),
+			Err:           err,
+			Segment:       scan.segment,
+			TotalSegments: scan.totalSegments,
+		}
+		if scanOutput != nil {
+			stats.ConsumedCapacity = consumedCapacityUnits(scanOutput.ConsumedCapacity)
+			stats.Items = len(scanOutput.Items)
+		}
+		table.emitStats(ctx, stats)
+
+		if err != nil {
+			table.logger.Printf("error: %s\n", err.Error())
+			return err
+		}
+
+		// counter items are maintained by the package and are not items of the table
+		scanOutput.Items = table.withoutCounterItems(scanOutput.Items)
+
+		if err := handlePage(ctx, scanOutput); err != nil {
+			return err
+		}
+
+		// save progress, resetting the checkpoint once the segment is complete
+		startKey = scanOutput.LastEvaluatedKey
+		if scan.checkpoints != nil {
+			cursor := ""
+			if len(startKey) > 0 {
+				if cursor, err = encodeCursor(startKey); err != nil {
+					return err
+				}
+			}
+			if err := scan.checkpoints.SaveCheckpoint(ctx, scan.checkpointID, cursor); err != nil {
+				table.logger.Printf("error: %s\n", err.Error())
+				return err
+			}
+		}
+
+		if len(startKey) == 0 {
+			return nil
+		}
+	}
+}
+
+// runSegments runs each segment concurrently and returns the first error of any segment. Once any
+// segment fails, the context of the remaining segments is canceled so that they stop promptly
+// rather than consuming capacity for a result that will be discarded.
+func runSegments(ctx context.Context, segments int,
+	run func(ctx context.Context, segment int) error) error {
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	for segment := 0; segment < segments; segment++ {
+		wg.Add(1)
+		go func(segment int) {
+			defer wg.Done()
+			if err := run(ctx, segment); err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(segment)
+	}
+	wg.Wait()
+
+	return firstErr
+}
+
+func (table *Table) segmentScanInput(scan segmentScan) (*dynamodb.ScanInput, error) {
+	primaryIndex := table.allIndexes()[tablePrimaryIndexName]
+
+	// restrict scan to items of the tenant, if applicable
+	filter := scan.filter
+	if prefix := table.tenantPrefix(); prefix != "" {
+		tenantCondition := expression.Name(primaryIndex.PartitionKey).BeginsWith(prefix)
+		if filter != nil {
+			tenantCondition = expression.And(*filter, tenantCondition)
+		}
+		filter = &tenantCondition
+	}
+
+	scanInput := &dynamodb.ScanInput{
+		TableName:              aws.String(table.Name),
+		Segment:                aws.Int64(int64(scan.segment)),
+		TotalSegments:          aws.Int64(int64(scan.totalSegments)),
+		ReturnConsumedCapacity: aws.String(dynamodb.ReturnConsumedCapacityTotal),
+	}
+
+	if filter == nil && !scan.keysOnly {
+		return scanInput, nil
+	}
+
+	dbExprBuilder := expression.NewBuilder()
+	if filter != nil {
+		dbExprBuilder = dbExprBuilder.WithFilter(*filter)
+	}
+	if scan.keysOnly {
+		keyNames := primaryIndex.getKeys()
+		projection := expression.NamesList(expression.Name(keyNames[0]))
+		for _, keyName := range keyNames[1:] {
+			projection = projection.AddNames(expression.Name(keyName))
+		}
+		dbExprBuilder = dbExprBuilder.WithProjection(projection)
+	}
+
+	dbExpr, err := dbExprBuilder.Build()
+	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
+		return nil, err
+	}
+
+	scanInput.FilterExpression = dbExpr.Filter()
+	scanInput.ProjectionExpression = dbExpr.Projection()
+	scanInput.ExpressionAttributeNames = dbExpr.Names()
+	scanInput.ExpressionAttributeValues = dbExpr.Values()
+
+	return scanInput, nil
+}
@@ -0,0 +1,208 @@
+package dynamodbfriend
+
+import (
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+// ScanExpr is a scan expression. Unlike a query expression, a scan expression requires no
+// condition on any key and reads every item of the table, applying all conditions as filters.
+type ScanExpr struct {
+	// filters holds the conditions on each attribute, which are all applied
+	filters map[string][]queryFilter
+
+	limitSpecified bool
+	limitPerPage   int
+
+	attributesSpecified bool
+	attributes          []string
+
+	maxPaginationSpecified bool
+	maxPagination          int
+
+	consistentRead bool
+
+	additionalConditions []expression.ConditionBuilder
+	filterGroups         []*FilterGroup
+
+	includeDeleted bool
+
+	retryPolicy *RetryPolicy
+
+	loggerSpecified bool
+	logger          Logger
+
+	buildErr error
+}
+
+// ScanExprKey is a partially-formed scan expression.
+//
+// To make a fully-formed scan expression, the key part must be followed by a conditional.
+type ScanExprKey = ConditionKey[*ScanExpr]
+
+// NewScan begins a new scan expression. With no conditions, all items of the table are returned.
+func NewScan() *ScanExpr {
+	return &ScanExpr{
+		filters:              map[string][]queryFilter{},
+		additionalConditions: []expression.ConditionBuilder{},
+		logger:               nullLogger{},
+	}
+}
+
+// Where begins a filter condition on the named attribute.
+func (expr *ScanExpr) Where(key string) *ScanExprKey {
+	return &ScanExprKey{
+		key: key,
+		add: func(filter queryFilter) *ScanExpr {
+			expr.addFilter(filter)
+			return expr
+		},
+		fail: func(err error) *ScanExpr {
+			expr.logger.Printf("error: %s\n", err.Error())
+			expr.buildErr = err
+			return expr
+		},
+	}
+}
+
+// And extends a scan with an additional filter condition.
+func (expr *ScanExpr) And(key string) *ScanExprKey {
+	return expr.Where(key)
+}
+
+// LimitPerPage restricts the number of items evaluated per scan page.
+func (expr *ScanExpr) LimitPerPage(count int) *ScanExpr {
+	expr.limitSpecified = true
+	expr.limitPerPage = count
+	expr.logger.Printf("scan limit set to %d items\n", count)
+	return expr
+}
+
+// Select restricts the attributes returned by a scan.
+func (expr *ScanExpr) Select(attributes ...string) *ScanExpr {
+	expr.attributesSpecified = true
+	expr.attributes = attributes
+	return expr
+}
+
+// MaxPagination restricts the number of paginated requests to make to DynamoDB.
+func (expr *ScanExpr) MaxPagination(count int) *ScanExpr {
+	expr.maxPaginationSpecified = true
+	expr.maxPagination = count
+	expr.logger.Printf("max pagination of scan set to %d\n", count)
+	return expr
+}
+
+// ConsistentRead sets the read consistency. Consistent scans are always read from the primary
+// region.
+func (expr *ScanExpr) ConsistentRead(val bool) *ScanExpr {
+	expr.consistentRead = val
+	return expr
+}
+
+// WithFilter applies an additional condition in addition to other filters on the scan
+// expression. This allows for filter conditions that are not otherwise supported by the scan
+// expression, such as attribute existence conditions. Use Or for groups of OR conditions.
+func (expr *ScanExpr) WithFilter(condition expression.ConditionBuilder) *ScanExpr {
+	expr.additionalConditions = append(expr.additionalConditions, condition)
+	return expr
+}
+
+// WithDeleted includes soft-deleted items in scan results on tables with soft delete enabled.
+func (expr *ScanExpr) WithDeleted() *ScanExpr {
+	expr.includeDeleted = true
+	expr.logger.Printf("scan includes soft-deleted items\n")
+	return expr
+}
+
+// WithLogger sets a logger used to print logs about scan operations performed using this
+// expression. If no logger is set, the logger of the table being scanned is used.
+func (expr *ScanExpr) WithLogger(logger Logger) *ScanExpr {
+	expr.loggerSpecified = true
+	expr.logger = logger
+	return expr
+}
+
+// addFilter adds a condition on an attribute. Multiple conditions on the same attribute are all
+// applied.
+func (expr *ScanExpr) addFilter(v queryFilter) {
+	key := v.Key()
+	expr.filters[key] = append(expr.filters[key], v)
+}
+
+// constructScanInput builds a scan input on the table from the expression. Filter and selected
+// attribute names must already be stored names.
+func (expr ScanExpr) constructScanInput(tableName string,
+	opts tableQueryOptions) (*dynamodb.ScanInput, error) {
+
+	// apply filters in sorted key order so that identical expressions build identically
+	filterKeys := []string{}
+	for key := range expr.filters {
+		filterKeys = append(filterKeys, key)
+	}
+	sort.Strings(filterKeys)
+
+	filterConditions := []expression.ConditionBuilder{}
+	for _, key := range filterKeys {
+		for _, filter := range expr.filters[key] {
+			filterConditions = append(filterConditions, filter.Condition(expression.Name(key)))
+		}
+	}
+
+	groupConditions, err := filterGroupConditions(expr.filterGroups)
+	if err != nil {
+		return nil, err
+	}
+	filterConditions = append(filterConditions, groupConditions...)
+	filterConditions = append(filterConditions, expr.additionalConditions...)
+	filterConditions = append(filterConditions, opts.additionalConditions...)
+
+	scanInput := &dynamodb.ScanInput{
+		TableName: aws.String(tableName),
+	}
+
+	if len(filterConditions) > 0 || expr.attributesSpecified {
+		dbExprBuilder := expression.NewBuilder()
+
+		if len(filterConditions) == 1 {
+			dbExprBuilder = dbExprBuilder.WithFilter(filterConditions[0])
+		} else if len(filterConditions) > 1 {
+			dbExprBuilder = dbExprBuilder.WithFilter(expression.And(
+				filterConditions[0],
+				filterConditions[1],
+				filterConditions[2:]...))
+		}
+
+		if expr.attributesSpecified {
+			names := []expression.NameBuilder{}
+			for _, attribute := range expr.attributes {
+				names = append(names, expression.Name(attribute))
+			}
+			proj := expression.NamesList(names[0], names[1:]...)
+			dbExprBuilder = dbExprBuilder.WithProjection(proj)
+		}
+
+		dbExpr, err := dbExprBuilder.Build()
+		if err != nil {
+			return nil, err
+		}
+
+		scanInput.FilterExpression = dbExpr.Filter()
+		scanInput.ProjectionExpression = dbExpr.Projection()
+		scanInput.ExpressionAttributeNames = dbExpr.Names()
+		scanInput.ExpressionAttributeValues = dbExpr.Values()
+	}
+
+	if expr.limitSpecified {
+		scanInput.Limit = aws.Int64(int64(expr.limitPerPage))
+	}
+
+	if expr.consistentRead {
+		scanInput.ConsistentRead = aws.Bool(true)
+	}
+
+	return scanInput, nil
+}
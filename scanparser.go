@@ -0,0 +1,421 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+// Scan returns a new ScanParser that may be used to retrieve all items of the table matching the
+// scan expression. Scans read every item of the table, so queries should be preferred whenever
+// the table has a viable index for the conditions.
+func (table *Table) Scan(ctx context.Context, expr *ScanExpr) (*ScanParser, error) {
+	// fall back to table logger if no logger is set on the expression
+	if !expr.loggerSpecified {
+		expr.logger = table.logger
+	}
+
+	if expr.buildErr != nil {
+		return nil, expr.buildErr
+	}
+
+	if err := table.loadIndexMetadata(ctx); err != nil {
+		return nil, err
+	}
+
+	scanInput, err := table.scanInput(ctx, expr)
+	if err != nil {
+		expr.logger.Printf("error: %s\n", err.Error())
+		return nil, err
+	}
+
+	parser := newScanParser(table, expr, scanInput)
+
+	// consistent reads must be made against the primary region
+	if !expr.consistentRead {
+		parser.readClient = table.readClient(ctx)
+	}
+
+	return parser, nil
+}
+
+// scanInput builds the scan input of a scan expression, applying aliases and table-level
+// conditions. Index metadata must already be loaded.
+func (table *Table) scanInput(ctx context.Context, expr *ScanExpr) (*dynamodb.ScanInput, error) {
+	if err := table.checkScanPattern(); err != nil {
+		return nil, err
+	}
+
+	opts, err := table.queryOptions(ctx, expr.includeDeleted)
+	if err != nil {
+		return nil, err
+	}
+
+	// restrict scan to items of the tenant, if applicable
+	if opts.tenantPrefix != "" {
+		partitionKey := table.allIndexes()[tablePrimaryIndexName].PartitionKey
+		opts.additionalConditions = append(opts.additionalConditions,
+			expression.Name(partitionKey).BeginsWith(opts.tenantPrefix))
+	}
+
+	aliased := *expr
+	aliased.filters = map[string][]queryFilter{}
+	for key, filters := range expr.filters {
+		storedKey := table.storedName(key)
+		aliased.filters[storedKey] = append(aliased.filters[storedKey], filters...)
+	}
+	aliased.filterGroups = renamedFilterGroups(expr.filterGroups, table.storedName)
+	aliased.attributes = table.storedNames(expr.attributes)
+
+	scanInput, err := aliased.constructScanInput(table.Name, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = table.validateExpressions(scanInput.ExpressionAttributeNames,
+		expressionPart{kind: "filter", expression: scanInput.FilterExpression},
+		expressionPart{kind: "projection", expression: scanInput.ProjectionExpression})
+	if err != nil {
+		return nil, err
+	}
+
+	if scanInput.FilterExpression != nil {
+		expr.logger.Printf("scan filter: %s\n", table.describeExpression(
+			scanInput.FilterExpression, scanInput.ExpressionAttributeNames,
+			scanInput.ExpressionAttributeValues))
+	}
+
+	return scanInput, nil
+}
+
+// ScanParallel returns a new ScanParser that scans the table in the specified number of segments
+// concurrently, merging pages of all segments into a single parser as they arrive. Items are not
+// returned in any particular order. Segments stop scanning when ctx is canceled, the parser is
+// closed, a segment fails, a call to Next is canceled, or the max pagination of the expression is
+// reached across all segments. In-flight requests of stopped segments are canceled, and are
+// reported as canceled in the stats of each segment.
+func (table *Table) ScanParallel(ctx context.Context, expr *ScanExpr,
+	segments int) (*ScanParser, error) {
+
+	if segments < 1 {
+		err := fmt.Errorf("scan requires at least 1 segment, got %d", segments)
+		table.logger.Printf("error: %s\n", err.Error())
+		return nil, err
+	}
+
+	parser, err := table.Scan(ctx, expr)
+	if err != nil {
+		return nil, err
+	}
+
+	if segments > 1 {
+		expr.logger.Printf("scanning table \"%s\" in %d parallel segments\n", table.Name, segments)
+		parser.startSegments(ctx, segments)
+	}
+
+	return parser, nil
+}
+
+// ScanParser is used for parsing scan results.
+// The scan is executed lazily. The underlying scan will only happen when new items are requested
+// and all buffered items have already been consumed.
+type ScanParser struct {
+	table      *Table
+	readClient dynamodbiface.DynamoDBAPI
+
+	expr             *ScanExpr
+	scanInput        *dynamodb.ScanInput
+	lastEvaluatedKey map[string]*dynamodb.AttributeValue
+
+	bufferedItems      []map[string]*dynamodb.AttributeValue
+	currentBufferIndex int
+
+	totalPagesParsed int
+
+	// metadata of the most recently loaded page
+	pageScannedCount     int
+	pageLastEvaluatedKey map[string]*dynamodb.AttributeValue
+
+	// pages of parallel segment scans, if applicable
+	segmentCtx     context.Context
+	segmentPages   chan segmentPage
+	stopSegments   context.CancelFunc
+	segmentsClosed bool
+
+	enrichments []enrichment
+
+	closed bool
+}
+
+// segmentPage is a page of results, or an error, from a segment of a parallel scan.
+type segmentPage struct {
+	output *dynamodb.ScanOutput
+	err    error
+}
+
+var _ Iterator = (*ScanParser)(nil)
+
+func newScanParser(table *Table, expr *ScanExpr, scanInput *dynamodb.ScanInput) *ScanParser {
+	return &ScanParser{
+		table:            table,
+		readClient:       table.baseClient,
+		expr:             expr,
+		scanInput:        scanInput,
+		lastEvaluatedKey: scanInput.ExclusiveStartKey,
+		bufferedItems:    []map[string]*dynamodb.AttributeValue{},
+	}
+}
+
+// Next retrieves the next value returned by the scan. The val must be a non-nil pointer.
+// The underlying scan will only execute when new items are requested and any buffered items have
+// already been consumed.
+func (parser *ScanParser) Next(ctx context.Context, val interface{}) error {
+	storedItem, err := parser.nextStoredItem(ctx)
+	if err != nil {
+		return err
+	}
+
+	return parser.decodeStoredItem(ctx, storedItem, val)
+}
+
+// All reads all remaining items of the scan into items, which must be a non-nil pointer to a
+// slice. ErrMemoryBudgetExceeded is returned if the items exceed the table's result memory budget.
+// The parser is closed when All returns.
+func (parser *ScanParser) All(ctx context.Context, items interface{}) error {
+	defer parser.Close()
+
+	return parser.table.drainAll(ctx, items, parser.expr.logger, parser.nextStoredItem,
+		parser.decodeStoredItem)
+}
+
+// decodeStoredItem converts an item as stored in the table into val.
+func (parser *ScanParser) decodeStoredItem(ctx context.Context,
+	storedItem map[string]*dynamodb.AttributeValue, val interface{}) error {
+
+	item, err := parser.table.itemFromStore(storedItem)
+	if err != nil {
+		return err
+	}
+
+	parser.table.stripRestrictedAttributes(ctx, item)
+
+	err = parser.table.unmarshalItemChecked(item, val, !parser.expr.attributesSpecified)
+	if err != nil {
+		return err
+	}
+
+	return applyEnrichments(parser.enrichments, val)
+}
+
+// nextStoredItem returns the next item as stored in the table.
+func (parser *ScanParser) nextStoredItem(
+	ctx context.Context) (map[string]*dynamodb.AttributeValue, error) {
+
+	parsingComplete := func(reason string) error {
+		err := ErrParsingComplete{reason: reason}
+		parser.expr.logger.Printf("%s\n", err)
+		return err
+	}
+
+	if parser.closed {
+		return nil, parsingComplete("parser has been closed")
+	}
+
+	// observe cancellation even when items remain buffered
+	if err := ctx.Err(); err != nil {
+		if parser.stopSegments != nil {
+			parser.stopSegments()
+		}
+		return nil, err
+	}
+
+	// execute a new scan to refill the buffer if necessary
+	// retry until new items are found or a parsing complete condition has been met
+	for parser.currentBufferIndex == len(parser.bufferedItems) {
+		if parser.allItemsParsed() {
+			return nil, parsingComplete("all items have been parsed")
+		} else if parser.maxPaginationReached() {
+			return nil, parsingComplete("max pagination has been reached")
+		}
+
+		if err := parser.fetchNextPage(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	storedItem := parser.bufferedItems[parser.currentBufferIndex]
+	parser.currentBufferIndex++
+
+	return storedItem, nil
+}
+
+func (parser *ScanParser) fetchNextPage(ctx context.Context) error {
+	if parser.segmentPages != nil {
+		return parser.fetchNextSegmentPage(ctx)
+	}
+
+	parser.scanInput.ExclusiveStartKey = parser.lastEvaluatedKey
+
+	scanOutput, err := parser.scanPage(ctx, parser.scanInput)
+	if err != nil {
+		return err
+	}
+
+	parser.lastEvaluatedKey = scanOutput.LastEvaluatedKey
+	parser.loadPage(scanOutput)
+
+	return nil
+}
+
+// fetchNextSegmentPage loads the next page received from any segment of a parallel scan.
+func (parser *ScanParser) fetchNextSegmentPage(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		// the caller is no longer waiting on pages, so stop remaining segments
+		parser.stopSegments()
+		return ctx.Err()
+	case page, open := <-parser.segmentPages:
+		if !open {
+			// segments also stop when the scan context is canceled
+			parser.segmentsClosed = true
+			return parser.segmentCtx.Err()
+		} else if page.err != nil {
+			parser.stopSegments()
+			return page.err
+		}
+
+		parser.loadPage(page.output)
+
+		// stop remaining segments once no further pages will be read
+		if parser.maxPaginationReached() {
+			parser.stopSegments()
+		}
+		return nil
+	}
+}
+
+func (parser *ScanParser) loadPage(scanOutput *dynamodb.ScanOutput) {
+	parser.totalPagesParsed++
+	parser.pageScannedCount = int(aws.Int64Value(scanOutput.ScannedCount))
+	parser.pageLastEvaluatedKey = scanOutput.LastEvaluatedKey
+	parser.bufferedItems = parser.table.withoutCounterItems(scanOutput.Items)
+	parser.currentBufferIndex = 0
+}
+
+// scanPage reads a single page of scan results.
+func (parser *ScanParser) scanPage(ctx context.Context,
+	scanInput *dynamodb.ScanInput) (*dynamodb.ScanOutput, error) {
+
+	scanInput.ReturnConsumedCapacity = parser.table.returnConsumedCapacity()
+
+	start := time.Now()
+	scanOutput, err := parser.readClient.ScanWithContext(ctx, scanInput,
+		parser.expr.retryPolicy.requestOptions()...)
+
+	stats := OperationStats{
+		Operation:     "Scan",
+		Latency:       time.Since(start),
+		Err:           err,
+		Segment:       int(aws.Int64Value(scanInput.Segment)),
+		TotalSegments: int(aws.Int64Value(scanInput.TotalSegments)),
+	}
+	if scanOutput != nil {
+		stats.ConsumedCapacity = consumedCapacityUnits(scanOutput.ConsumedCapacity)
+		stats.Items = len(scanOutput.Items)
+	}
+	parser.table.emitStats(ctx, stats)
+
+	if err != nil {
+		parser.expr.logger.Printf("error: %s\n", err.Error())
+		return nil, err
+	}
+
+	return scanOutput, nil
+}
+
+// startSegments begins scanning all segments concurrently. Pages are delivered to the parser
+// through a channel, which is closed once all segments have stopped.
+func (parser *ScanParser) startSegments(ctx context.Context, segments int) {
+	segmentCtx, stopSegments := context.WithCancel(ctx)
+
+	parser.segmentCtx = ctx
+	parser.segmentPages = make(chan segmentPage, segments)
+	parser.stopSegments = stopSegments
+
+	var wg sync.WaitGroup
+	for segment := 0; segment < segments; segment++ {
+		scanInput := *parser.scanInput
+		scanInput.Segment = aws.Int64(int64(segment))
+		scanInput.TotalSegments = aws.Int64(int64(segments))
+
+		wg.Add(1)
+		go func(scanInput *dynamodb.ScanInput) {
+			defer wg.Done()
+			for {
+				// do not begin another request once segments have been stopped
+				if segmentCtx.Err() != nil {
+					return
+				}
+
+				scanOutput, err := parser.scanPage(segmentCtx, scanInput)
+
+				select {
+				case parser.segmentPages <- segmentPage{output: scanOutput, err: err}:
+				case <-segmentCtx.Done():
+					return
+				}
+
+				if err != nil || len(scanOutput.LastEvaluatedKey) == 0 {
+					return
+				}
+				scanInput.ExclusiveStartKey = scanOutput.LastEvaluatedKey
+			}
+		}(&scanInput)
+	}
+
+	go func() {
+		wg.Wait()
+		close(parser.segmentPages)
+	}()
+}
+
+// Close releases any buffered items held by the parser. Subsequent calls to Next will return
+// ErrParsingComplete. Close implements io.Closer and always returns nil.
+func (parser *ScanParser) Close() error {
+	if parser.closed {
+		return nil
+	}
+
+	if !parser.allItemsParsed() && !parser.maxPaginationReached() {
+		parser.expr.logger.Printf("parser closed before all items were parsed\n")
+	}
+
+	if parser.stopSegments != nil {
+		parser.stopSegments()
+	}
+
+	parser.closed = true
+	parser.bufferedItems = nil
+	parser.currentBufferIndex = 0
+	parser.lastEvaluatedKey = nil
+
+	return nil
+}
+
+func (parser *ScanParser) allItemsParsed() bool {
+	if parser.segmentPages != nil {
+		return parser.segmentsClosed
+	}
+	return parser.totalPagesParsed > 0 && len(parser.lastEvaluatedKey) == 0
+}
+
+func (parser *ScanParser) maxPaginationReached() bool {
+	return parser.expr.maxPaginationSpecified &&
+		parser.totalPagesParsed == parser.expr.maxPagination
+}
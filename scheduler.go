@@ -0,0 +1,259 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// Priority is the scheduling priority of requests made with a context.
+type Priority int
+
+// Request priorities, from highest to lowest.
+const (
+	// PriorityInteractive is the default priority, for requests serving interactive traffic.
+	PriorityInteractive Priority = iota
+	// PriorityBatch is for requests of background work, such as jobs and exports.
+	PriorityBatch
+)
+
+// schedulerPressureWindow is how long a table is considered under pressure after a throttled
+// request.
+const schedulerPressureWindow = 5 * time.Second
+
+type priorityKey struct{}
+
+// WithPriority returns a copy of the context whose requests are scheduled with the priority on
+// tables with request scheduling enabled.
+func WithPriority(ctx context.Context, priority Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+func priorityOf(ctx context.Context) Priority {
+	if priority, found := ctx.Value(priorityKey{}).(Priority); found {
+		return priority
+	}
+	return PriorityInteractive
+}
+
+// WithRequestScheduling limits the number of requests in flight on this table to maxInFlight, with
+// waiting requests admitted in order of priority. While the table is under pressure, as indicated
+// by recently throttled requests, batch priority requests are limited to a quarter of the requests
+// in flight, leaving capacity for interactive traffic. Use WithPriority to set the priority of
+// requests made with a context.
+func (table *Table) WithRequestScheduling(maxInFlight int) *Table {
+	if maxInFlight < 1 {
+		table.requestScheduler = nil
+	} else {
+		table.requestScheduler = &requestScheduler{maxInFlight: maxInFlight}
+	}
+	table.baseClient = table.wrapClient(table.rawClient)
+	return table
+}
+
+// requestScheduler admits requests up to a limit in flight, preferring higher priority requests.
+type requestScheduler struct {
+	mu            sync.Mutex
+	maxInFlight   int
+	inFlight      int
+	waiting       [PriorityBatch + 1][]chan struct{}
+	pressureUntil time.Time
+}
+
+// limit returns the number of requests in flight up to which requests of the priority are
+// admitted.
+func (s *requestScheduler) limit(priority Priority) int {
+	if priority == PriorityBatch && time.Now().Before(s.pressureUntil) {
+		if batchLimit := s.maxInFlight / 4; batchLimit > 1 {
+			return batchLimit
+		}
+		return 1
+	}
+	return s.maxInFlight
+}
+
+// acquire blocks until a request of the context's priority is admitted or the context is
+// cancelled. Admitted requests must be released.
+func (s *requestScheduler) acquire(ctx context.Context) error {
+	priority := priorityOf(ctx)
+	if priority < PriorityInteractive || priority > PriorityBatch {
+		priority = PriorityBatch
+	}
+
+	s.mu.Lock()
+	if s.inFlight < s.limit(priority) && s.noneWaitingBefore(priority) {
+		s.inFlight++
+		s.mu.Unlock()
+		return nil
+	}
+
+	admitted := make(chan struct{})
+	s.waiting[priority] = append(s.waiting[priority], admitted)
+	s.mu.Unlock()
+
+	select {
+	case <-admitted:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for i, waiter := range s.waiting[priority] {
+			if waiter == admitted {
+				s.waiting[priority] = append(s.waiting[priority][:i], s.waiting[priority][i+1:]...)
+				return ctx.Err()
+			}
+		}
+		// admitted concurrently with cancellation, so give up the slot
+		s.inFlight--
+		s.admitWaiting()
+		return ctx.Err()
+	}
+}
+
+func (s *requestScheduler) noneWaitingBefore(priority Priority) bool {
+	for p := PriorityInteractive; p <= priority; p++ {
+		if len(s.waiting[p]) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// release ends an admitted request, noting pressure on the table if the request was throttled.
+func (s *requestScheduler) release(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if isThrottleError(err) {
+		s.pressureUntil = time.Now().Add(schedulerPressureWindow)
+	}
+
+	s.inFlight--
+	s.admitWaiting()
+}
+
+// admitWaiting admits waiting requests in order of priority while below their limits.
+func (s *requestScheduler) admitWaiting() {
+	for priority := PriorityInteractive; priority <= PriorityBatch; priority++ {
+		for len(s.waiting[priority]) > 0 && s.inFlight < s.limit(priority) {
+			close(s.waiting[priority][0])
+			s.waiting[priority] = s.waiting[priority][1:]
+			s.inFlight++
+		}
+		if len(s.waiting[priority]) > 0 {
+			return
+		}
+	}
+}
+
+// scheduledClient admits the data requests of a DynamoDB client through a request scheduler.
+type scheduledClient struct {
+	dynamodbiface.DynamoDBAPI
+	scheduler *requestScheduler
+}
+
+func (c *scheduledClient) GetItemWithContext(ctx aws.Context,
+	input *dynamodb.GetItemInput, opts ...request.Option) (*dynamodb.GetItemOutput, error) {
+
+	if err := c.scheduler.acquire(ctx); err != nil {
+		return nil, err
+	}
+	output, err := c.DynamoDBAPI.GetItemWithContext(ctx, input, opts...)
+	c.scheduler.release(err)
+	return output, err
+}
+
+func (c *scheduledClient) QueryWithContext(ctx aws.Context,
+	input *dynamodb.QueryInput, opts ...request.Option) (*dynamodb.QueryOutput, error) {
+
+	if err := c.scheduler.acquire(ctx); err != nil {
+		return nil, err
+	}
+	output, err := c.DynamoDBAPI.QueryWithContext(ctx, input, opts...)
+	c.scheduler.release(err)
+	return output, err
+}
+
+func (c *scheduledClient) ScanWithContext(ctx aws.Context,
+	input *dynamodb.ScanInput, opts ...request.Option) (*dynamodb.ScanOutput, error) {
+
+	if err := c.scheduler.acquire(ctx); err != nil {
+		return nil, err
+	}
+	output, err := c.DynamoDBAPI.ScanWithContext(ctx, input, opts...)
+	c.scheduler.release(err)
+	return output, err
+}
+
+func (c *scheduledClient) PutItemWithContext(ctx aws.Context,
+	input *dynamodb.PutItemInput, opts ...request.Option) (*dynamodb.PutItemOutput, error) {
+
+	if err := c.scheduler.acquire(ctx); err != nil {
+		return nil, err
+	}
+	output, err := c.DynamoDBAPI.PutItemWithContext(ctx, input, opts...)
+	c.scheduler.release(err)
+	return output, err
+}
+
+func (c *scheduledClient) UpdateItemWithContext(ctx aws.Context,
+	input *dynamodb.UpdateItemInput, opts ...request.Option) (*dynamodb.UpdateItemOutput, error) {
+
+	if err := c.scheduler.acquire(ctx); err != nil {
+		return nil, err
+	}
+	output, err := c.DynamoDBAPI.UpdateItemWithContext(ctx, input, opts...)
+	c.scheduler.release(err)
+	return output, err
+}
+
+func (c *scheduledClient) DeleteItemWithContext(ctx aws.Context,
+	input *dynamodb.DeleteItemInput, opts ...request.Option) (*dynamodb.DeleteItemOutput, error) {
+
+	if err := c.scheduler.acquire(ctx); err != nil {
+		return nil, err
+	}
+	output, err := c.DynamoDBAPI.DeleteItemWithContext(ctx, input, opts...)
+	c.scheduler.release(err)
+	return output, err
+}
+
+func (c *scheduledClient) TransactWriteItemsWithContext(ctx aws.Context,
+	input *dynamodb.TransactWriteItemsInput,
+	opts ...request.Option) (*dynamodb.TransactWriteItemsOutput, error) {
+
+	if err := c.scheduler.acquire(ctx); err != nil {
+		return nil, err
+	}
+	output, err := c.DynamoDBAPI.TransactWriteItemsWithContext(ctx, input, opts...)
+	c.scheduler.release(err)
+	return output, err
+}
+
+func (c *scheduledClient) BatchGetItemWithContext(ctx aws.Context,
+	input *dynamodb.BatchGetItemInput, opts ...request.Option) (*dynamodb.BatchGetItemOutput, error) {
+
+	if err := c.scheduler.acquire(ctx); err != nil {
+		return nil, err
+	}
+	output, err := c.DynamoDBAPI.BatchGetItemWithContext(ctx, input, opts...)
+	c.scheduler.release(err)
+	return output, err
+}
+
+func (c *scheduledClient) BatchWriteItemWithContext(ctx aws.Context,
+	input *dynamodb.BatchWriteItemInput,
+	opts ...request.Option) (*dynamodb.BatchWriteItemOutput, error) {
+
+	if err := c.scheduler.acquire(ctx); err != nil {
+		return nil, err
+	}
+	output, err := c.DynamoDBAPI.BatchWriteItemWithContext(ctx, input, opts...)
+	c.scheduler.release(err)
+	return output, err
+}
@@ -0,0 +1,87 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRequestSchedulerConcurrency(t *testing.T) {
+	const maxInFlight = 2
+	scheduler := &requestScheduler{maxInFlight: maxInFlight}
+
+	var inFlight, maxObserved int32
+	var wg sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			ctx := context.Background()
+			if i%2 == 1 {
+				ctx = WithPriority(ctx, PriorityBatch)
+			}
+			// some requests are canceled while waiting to be admitted
+			if i%5 == 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, time.Millisecond)
+				defer cancel()
+			}
+
+			if err := scheduler.acquire(ctx); err != nil {
+				return
+			}
+			current := atomic.AddInt32(&inFlight, 1)
+			for {
+				observed := atomic.LoadInt32(&maxObserved)
+				if current <= observed ||
+					atomic.CompareAndSwapInt32(&maxObserved, observed, current) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&inFlight, -1)
+			scheduler.release(nil)
+		}(i)
+	}
+	wg.Wait()
+
+	if maxObserved > maxInFlight {
+		t.Errorf("expected at most %d requests in flight, got %d", maxInFlight, maxObserved)
+	}
+	scheduler.mu.Lock()
+	defer scheduler.mu.Unlock()
+	if scheduler.inFlight != 0 {
+		t.Errorf("expected no requests in flight, got %d", scheduler.inFlight)
+	}
+	for priority, waiting := range scheduler.waiting {
+		if len(waiting) != 0 {
+			t.Errorf("expected no waiting requests of priority %d, got %d", priority, len(waiting))
+		}
+	}
+}
+
+func TestScheduledTableConcurrentRequests(t *testing.T) {
+	fake := newFakeDynamoDB("items", "id", "")
+	fake.putItems(stringItem(map[string]string{"id": "a"}))
+	table := newFakeTable(fake).WithRequestScheduling(2)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var item map[string]string
+			if err := table.Get(context.Background(), map[string]string{"id": "a"}, &item); err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(fake.getInputs) != 16 {
+		t.Errorf("expected 16 reads, got %d", len(fake.getInputs))
+	}
+}
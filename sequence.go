@@ -0,0 +1,134 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+const (
+	sequencePrefix           = "SEQUENCE#"
+	sequenceValueAttr        = "sequenceValue"
+	defaultSequenceBlockSize = 1
+)
+
+// sequenceAllocator holds blocks of sequence values reserved by this table instance.
+type sequenceAllocator struct {
+	mu        sync.Mutex
+	blockSize int64
+	blocks    map[string]*sequenceBlock
+}
+
+// sequenceBlock is a range of reserved sequence values, from next through last.
+type sequenceBlock struct {
+	next int64
+	last int64
+}
+
+// WithSequenceBlockSize sets the number of sequence values reserved by each write to a sequence
+// counter in NextSequence. Values are handed out from the reserved block until it is exhausted,
+// reducing writes for frequently used sequences. Values are unique across all table instances,
+// but are only increasing within a single table instance, and unused values of a reserved block
+// are skipped when the table instance is discarded.
+func (table *Table) WithSequenceBlockSize(size int64) *Table {
+	if size < 1 {
+		size = defaultSequenceBlockSize
+	}
+	table.sequences = &sequenceAllocator{
+		blockSize: size,
+		blocks:    map[string]*sequenceBlock{},
+	}
+	return table
+}
+
+// NextSequence returns the next value of the named sequence, starting from 1. Sequences are backed
+// by an atomic counter item stored in the table with all primary key attributes set to
+// "SEQUENCE#<name>", so the table's primary key attributes must be strings.
+func (table *Table) NextSequence(ctx context.Context, name string) (int64, error) {
+	if table.sequences == nil {
+		return table.reserveSequenceBlock(ctx, name, defaultSequenceBlockSize)
+	}
+
+	allocator := table.sequences
+	allocator.mu.Lock()
+	defer allocator.mu.Unlock()
+
+	block, found := allocator.blocks[name]
+	if !found || block.next > block.last {
+		last, err := table.reserveSequenceBlock(ctx, name, allocator.blockSize)
+		if err != nil {
+			return 0, err
+		}
+		block = &sequenceBlock{next: last - allocator.blockSize + 1, last: last}
+		allocator.blocks[name] = block
+	}
+
+	value := block.next
+	block.next++
+	return value, nil
+}
+
+// reserveSequenceBlock atomically increments the sequence counter by size and returns the last
+// value of the reserved block.
+func (table *Table) reserveSequenceBlock(ctx context.Context, name string, size int64) (int64, error) {
+	if err := table.loadIndexMetadata(ctx); err != nil {
+		return 0, err
+	}
+
+	key, err := table.reservedItemKey(ctx, sequencePrefix+name)
+	if err != nil {
+		return 0, err
+	}
+
+	update := expression.Add(expression.Name(sequenceValueAttr), expression.Value(size))
+	dbExpr, err := expression.NewBuilder().WithUpdate(update).Build()
+	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
+		return 0, err
+	}
+
+	updateInput := &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(table.Name),
+		Key:                       key,
+		UpdateExpression:          dbExpr.Update(),
+		ExpressionAttributeNames:  dbExpr.Names(),
+		ExpressionAttributeValues: dbExpr.Values(),
+		ReturnValues:              aws.String(dynamodb.ReturnValueUpdatedNew),
+		ReturnConsumedCapacity:    table.returnConsumedCapacity(),
+	}
+
+	start := time.Now()
+	updateOutput, err := table.baseClient.UpdateItemWithContext(ctx, updateInput)
+
+	stats := OperationStats{
+		Operation: "UpdateItem",
+		Latency:   time.Since(start),
+		Items:     1,
+		Err:       err,
+	}
+	if updateOutput != nil {
+		stats.ConsumedCapacity = consumedCapacityUnits(updateOutput.ConsumedCapacity)
+	}
+	table.emitStats(ctx, stats)
+
+	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
+		return 0, err
+	}
+
+	last, err := strconv.ParseInt(aws.StringValue(updateOutput.Attributes[sequenceValueAttr].N), 10, 64)
+	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
+		return 0, err
+	}
+
+	table.logger.Printf("reserved %d values of sequence \"%s\" in table \"%s\"\n",
+		size, name, table.Name)
+
+	return last, nil
+}
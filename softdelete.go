@@ -0,0 +1,100 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+// WithSoftDelete enables soft delete on the table using the named attribute. Items soft-deleted
+// with SoftDelete have the attribute set to the deletion time in Unix seconds, and queries on the
+// table exclude items with the attribute set unless QueryExpr.WithDeleted is used.
+func (table *Table) WithSoftDelete(deletedAtAttribute string) *Table {
+	table.softDeleteAttribute = deletedAtAttribute
+	return table
+}
+
+// SoftDelete marks the item with the specified key as deleted without removing it from the table.
+// The key may be a struct or map containing the table's primary key attributes. ErrItemNotFound is
+// returned if no item exists with the key.
+func (table *Table) SoftDelete(ctx context.Context, key interface{}) error {
+	if table.softDeleteAttribute == "" {
+		err := ErrSoftDeleteNotEnabled{TableName: table.Name}
+		table.logger.Printf("error: %s\n", err.Error())
+		return err
+	}
+
+	keyMap, err := table.marshalStoredKey(ctx, key)
+	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
+		return err
+	}
+
+	// only existing items are marked, so that no item is created for a missing key
+	update := expression.Set(expression.Name(table.softDeleteAttribute),
+		expression.Value(time.Now().Unix()))
+	condition := expression.AttributeExists(
+		expression.Name(table.allIndexes()[tablePrimaryIndexName].PartitionKey))
+	dbExpr, err := expression.NewBuilder().WithUpdate(update).WithCondition(condition).Build()
+	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
+		return err
+	}
+
+	updateInput := &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(table.Name),
+		Key:                       keyMap,
+		UpdateExpression:          dbExpr.Update(),
+		ConditionExpression:       dbExpr.Condition(),
+		ExpressionAttributeNames:  dbExpr.Names(),
+		ExpressionAttributeValues: dbExpr.Values(),
+	}
+
+	// request new image for auditing, if applicable
+	if table.auditor != nil {
+		updateInput.ReturnValues = aws.String(dynamodb.ReturnValueAllNew)
+	}
+
+	updateInput.ReturnConsumedCapacity = table.returnConsumedCapacity()
+
+	start := time.Now()
+	updateOutput, err := table.baseClient.UpdateItemWithContext(ctx, updateInput)
+
+	stats := OperationStats{
+		Operation: "UpdateItem",
+		Latency:   time.Since(start),
+		Items:     1,
+		Err:       err,
+	}
+	if updateOutput != nil {
+		stats.ConsumedCapacity = consumedCapacityUnits(updateOutput.ConsumedCapacity)
+	}
+	table.emitStats(ctx, stats)
+
+	if awsErr, isAWSErr := err.(awserr.Error); isAWSErr &&
+		awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+
+		err = ErrItemNotFound{TableName: table.Name}
+	}
+	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
+	} else {
+		table.invalidateItem(keyMap)
+	}
+
+	event := AuditEvent{
+		Operation: AuditOperationSoftDelete,
+		Key:       keyMap,
+		Err:       err,
+	}
+	if updateOutput != nil {
+		event.NewImage = updateOutput.Attributes
+	}
+	table.audit(ctx, event)
+
+	return err
+}
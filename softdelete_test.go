@@ -0,0 +1,45 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSoftDelete(t *testing.T) {
+	cases := []struct {
+		name      string
+		stored    bool
+		expectErr error
+	}{
+		{
+			name:   "existing item",
+			stored: true,
+		},
+		{
+			name:      "missing item",
+			expectErr: ErrItemNotFound{TableName: "items"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := newFakeDynamoDB("items", "id", "")
+			if tc.stored {
+				fake.putItems(stringItem(map[string]string{"id": "a"}))
+			}
+			table := newFakeTable(fake).WithSoftDelete("deletedAt")
+
+			err := table.SoftDelete(context.Background(), map[string]string{"id": "a"})
+			if err != tc.expectErr {
+				t.Fatalf("expected error %v, got %v", tc.expectErr, err)
+			}
+
+			// the update must be conditioned on the item existing, so no item is created
+			if len(fake.updateInputs) != 1 {
+				t.Fatalf("expected 1 update, got %d", len(fake.updateInputs))
+			} else if fake.updateInputs[0].ConditionExpression == nil {
+				t.Errorf("expected update to have a condition expression")
+			}
+		})
+	}
+}
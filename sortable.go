@@ -0,0 +1,147 @@
+package dynamodbfriend
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sortableTimeLayout formats times with a fixed number of digits, unlike time.RFC3339Nano, which
+// trims trailing zeros of fractional seconds and so does not sort lexicographically.
+const sortableTimeLayout = "2006-01-02T15:04:05.000000000Z"
+
+// negativeSortablePrefix marks negative sortable integers. It sorts before all digits.
+const negativeSortablePrefix = "-"
+
+// SortableInt encodes n as a string of width digits, such that the lexicographic order of encoded
+// values is the numeric order of n, for numbers stored within string sort keys. Non-negative
+// values are zero-padded, so that 42 with a width of 6 is "000042". Negative values are encoded
+// as "-" followed by n+10^width, so that they sort before all non-negative values. An error is
+// returned if n is outside of the range -10^width to 10^width-1.
+func SortableInt(n int64, width int) (string, error) {
+	if width < 1 || width > 18 {
+		return "", fmt.Errorf("sortable int width must be between 1 and 18, got %d", width)
+	}
+
+	bound := int64(1)
+	for i := 0; i < width; i++ {
+		bound *= 10
+	}
+	if n >= bound || n < -bound {
+		return "", fmt.Errorf("value %d does not fit sortable int width of %d", n, width)
+	}
+
+	if n < 0 {
+		return negativeSortablePrefix + fmt.Sprintf("%0*d", width, n+bound), nil
+	}
+	return fmt.Sprintf("%0*d", width, n), nil
+}
+
+// ParseSortableInt decodes a value encoded with SortableInt of any width.
+func ParseSortableInt(s string) (int64, error) {
+	digits := strings.TrimPrefix(s, negativeSortablePrefix)
+	if digits == "" || len(digits) > 18 || strings.IndexFunc(digits, func(r rune) bool {
+		return r < '0' || r > '9'
+	}) >= 0 {
+		return 0, fmt.Errorf("\"%s\" is not a sortable int", s)
+	}
+
+	n, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("\"%s\" is not a sortable int: %s", s, err.Error())
+	}
+
+	if len(digits) < len(s) {
+		bound := int64(1)
+		for i := 0; i < len(digits); i++ {
+			bound *= 10
+		}
+		n -= bound
+	}
+	return n, nil
+}
+
+// SortableTime encodes t as a fixed-width UTC timestamp with nanoseconds, such that the
+// lexicographic order of encoded values is the chronological order of t, for times stored within
+// string sort keys. Times must be within the years 0 through 9999 to sort correctly.
+func SortableTime(t time.Time) string {
+	return t.UTC().Format(sortableTimeLayout)
+}
+
+// ParseSortableTime decodes a value encoded with SortableTime. The returned time is in UTC.
+func ParseSortableTime(s string) (time.Time, error) {
+	t, err := time.Parse(sortableTimeLayout, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("\"%s\" is not a sortable time: %s", s, err.Error())
+	}
+	return t, nil
+}
+
+// KeyComponentEncoding encodes values of placeholder components of a key template to strings and
+// decodes them back, such as so that numbers within string sort keys sort numerically.
+type KeyComponentEncoding interface {
+	EncodeComponent(val interface{}) (string, error)
+	DecodeComponent(s string) (interface{}, error)
+}
+
+// SortableIntEncoding is a KeyComponentEncoding of integers with SortableInt. Values of any
+// integer type are encoded, and values are decoded as int64.
+type SortableIntEncoding struct {
+	Width int
+}
+
+// EncodeComponent encodes an integer with SortableInt.
+func (e SortableIntEncoding) EncodeComponent(val interface{}) (string, error) {
+	var n int64
+	switch v := val.(type) {
+	case int:
+		n = int64(v)
+	case int8:
+		n = int64(v)
+	case int16:
+		n = int64(v)
+	case int32:
+		n = int64(v)
+	case int64:
+		n = v
+	case uint:
+		n = int64(v)
+	case uint8:
+		n = int64(v)
+	case uint16:
+		n = int64(v)
+	case uint32:
+		n = int64(v)
+	case uint64:
+		if v > uint64(1<<63-1) {
+			return "", fmt.Errorf("value %d does not fit sortable int width of %d", v, e.Width)
+		}
+		n = int64(v)
+	default:
+		return "", fmt.Errorf("sortable int encoding requires an integer, got %T", val)
+	}
+	return SortableInt(n, e.Width)
+}
+
+// DecodeComponent decodes a value encoded with SortableInt as int64.
+func (e SortableIntEncoding) DecodeComponent(s string) (interface{}, error) {
+	return ParseSortableInt(s)
+}
+
+// SortableTimeEncoding is a KeyComponentEncoding of time.Time values with SortableTime.
+type SortableTimeEncoding struct{}
+
+// EncodeComponent encodes a time.Time with SortableTime.
+func (SortableTimeEncoding) EncodeComponent(val interface{}) (string, error) {
+	t, isTime := val.(time.Time)
+	if !isTime {
+		return "", fmt.Errorf("sortable time encoding requires a time.Time, got %T", val)
+	}
+	return SortableTime(t), nil
+}
+
+// DecodeComponent decodes a value encoded with SortableTime as time.Time.
+func (SortableTimeEncoding) DecodeComponent(s string) (interface{}, error) {
+	return ParseSortableTime(s)
+}
@@ -0,0 +1,78 @@
+package dynamodbfriend
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestSortableIntOrdering(t *testing.T) {
+	cases := []struct {
+		name   string
+		width  int
+		values []int64
+	}{
+		{
+			name:   "non-negative",
+			width:  4,
+			values: []int64{0, 1, 9, 10, 99, 100, 9999},
+		},
+		{
+			name:   "negative",
+			width:  4,
+			values: []int64{-10000, -9999, -100, -99, -10, -9, -1},
+		},
+		{
+			name:   "mixed",
+			width:  3,
+			values: []int64{-1000, -999, -42, -1, 0, 1, 42, 999},
+		},
+		{
+			name:   "widest",
+			width:  18,
+			values: []int64{-1e18, -1, 0, 1, 1e18 - 1},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			encoded := make([]string, len(tc.values))
+			for i, value := range tc.values {
+				s, err := SortableInt(value, tc.width)
+				if err != nil {
+					t.Fatalf("SortableInt(%d, %d): %s", value, tc.width, err)
+				}
+				encoded[i] = s
+
+				decoded, err := ParseSortableInt(s)
+				if err != nil {
+					t.Fatalf("ParseSortableInt(%q): %s", s, err)
+				} else if decoded != value {
+					t.Errorf("expected %q to decode to %d, got %d", s, value, decoded)
+				}
+			}
+
+			// values are given in numeric order, so encodings must already be sorted
+			if !sort.StringsAreSorted(encoded) {
+				t.Errorf("encodings of %v are not in numeric order: %q", tc.values, encoded)
+			}
+		})
+	}
+}
+
+func TestSortableIntOutOfRange(t *testing.T) {
+	cases := []struct {
+		value int64
+		width int
+	}{
+		{value: 1000, width: 3},
+		{value: -1001, width: 3},
+		{value: 0, width: 0},
+		{value: 0, width: 19},
+	}
+
+	for _, tc := range cases {
+		if s, err := SortableInt(tc.value, tc.width); err == nil {
+			t.Errorf("expected error for SortableInt(%d, %d), got %q", tc.value, tc.width, s)
+		}
+	}
+}
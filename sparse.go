@@ -0,0 +1,32 @@
+package dynamodbfriend
+
+import (
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// SparsePredicate decides whether an item should carry a sparse index key attribute.
+type SparsePredicate func(item map[string]*dynamodb.AttributeValue) bool
+
+type sparseAttribute struct {
+	name    string
+	include SparsePredicate
+}
+
+// WithSparseAttribute registers a rule removing the named attribute from items written through
+// this table whenever include returns false, so that only matching items appear in a sparse
+// index keyed on the attribute. Rules are applied after derived attributes are computed.
+func (table *Table) WithSparseAttribute(name string, include SparsePredicate) *Table {
+	table.sparseAttributes = append(table.sparseAttributes, sparseAttribute{
+		name:    name,
+		include: include,
+	})
+	return table
+}
+
+func (table *Table) applySparseAttributes(item map[string]*dynamodb.AttributeValue) {
+	for _, sparse := range table.sparseAttributes {
+		if _, found := item[sparse.name]; found && !sparse.include(item) {
+			delete(item, sparse.name)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// OperationStats describes a single request made to DynamoDB.
+type OperationStats struct {
+	Operation        string
+	TableName        string
+	IndexName        string
+	Latency          time.Duration
+	ConsumedCapacity float64
+	Items            int
+	Throttled        bool
+	Err              error
+
+	// Segment and TotalSegments identify the segment read by a request of a segmented scan, such
+	// as of ScanParallel. TotalSegments is 0 for requests that are not part of a segmented scan.
+	Segment       int
+	TotalSegments int
+
+	// Canceled is whether the request was canceled by its context, such as when the remaining
+	// segments of a scan are stopped because the caller is done with the results.
+	Canceled bool
+
+	// Tags are the tags carried by the context of the request, set with WithTag.
+	Tags map[string]string
+}
+
+// StatsEmitter is an interface for receiving stats about each request made to DynamoDB.
+type StatsEmitter interface {
+	EmitStats(stats OperationStats)
+}
+
+// WithStatsEmitter sets the default stats emitter for all tables subsequently instantiated from
+// this client.
+func (client *Client) WithStatsEmitter(emitter StatsEmitter) *Client {
+	client.statsEmitter = emitter
+	return client
+}
+
+// WithStatsEmitter sets the stats emitter notified of all requests made for this table.
+func (table *Table) WithStatsEmitter(emitter StatsEmitter) *Table {
+	table.statsEmitter = emitter
+	return table
+}
+
+func (table *Table) emitStats(ctx context.Context, stats OperationStats) {
+	if table.statsEmitter == nil {
+		return
+	}
+	stats.TableName = table.Name
+	stats.Tags = Tags(ctx)
+	stats.Throttled = isThrottleError(stats.Err)
+	stats.Canceled = isCanceledError(stats.Err)
+	table.statsEmitter.EmitStats(stats)
+}
+
+// returnConsumedCapacity returns the consumed capacity setting for requests made for this table.
+func (table *Table) returnConsumedCapacity() *string {
+	if table.statsEmitter == nil {
+		return nil
+	}
+	return aws.String(dynamodb.ReturnConsumedCapacityTotal)
+}
+
+func consumedCapacityUnits(capacity *dynamodb.ConsumedCapacity) float64 {
+	if capacity == nil {
+		return 0
+	}
+	return aws.Float64Value(capacity.CapacityUnits)
+}
+
+// isCanceledError returns whether a request failed because its context was canceled.
+func isCanceledError(err error) bool {
+	if err == context.Canceled {
+		return true
+	}
+	awsErr, isAWSErr := err.(awserr.Error)
+	return isAWSErr && awsErr.Code() == request.CanceledErrorCode
+}
+
+func isThrottleError(err error) bool {
+	awsErr, isAWSErr := err.(awserr.Error)
+	if !isAWSErr {
+		return false
+	}
+	switch awsErr.Code() {
+	case dynamodb.ErrCodeProvisionedThroughputExceededException,
+		dynamodb.ErrCodeRequestLimitExceeded,
+		"ThrottlingException":
+		return true
+	}
+	return false
+}
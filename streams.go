@@ -0,0 +1,274 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams/dynamodbstreamsiface"
+)
+
+// StreamHandler is called for each record read from a table's stream.
+type StreamHandler func(ctx context.Context, record *dynamodbstreams.Record) error
+
+const streamPollInterval = time.Second
+
+// ConsumeStream reads new records from the table's stream using a DynamoDB Streams client and
+// calls handler for each record in order per shard. Records written before ConsumeStream is called
+// are not read. ConsumeStream blocks until the context is cancelled or the handler returns an
+// error, and returns the corresponding error.
+func (table *Table) ConsumeStream(ctx context.Context,
+	streams dynamodbstreamsiface.DynamoDBStreamsAPI, handler StreamHandler) error {
+
+	return table.consumeStream(ctx, streams, nil, handler)
+}
+
+// ConsumeStreamWithCheckpoints reads records from the table's stream like ConsumeStream, but
+// resumes each shard after the last record checkpointed in the store. Shards without a checkpoint
+// are read from the oldest available record, and child shards are only read once their parent
+// shard has been fully read. A checkpoint is saved after each batch of records is handled, so
+// records handled since the last checkpoint may be handled again after a restart and the handler
+// should be idempotent.
+func (table *Table) ConsumeStreamWithCheckpoints(ctx context.Context,
+	streams dynamodbstreamsiface.DynamoDBStreamsAPI, checkpoints CheckpointStore,
+	handler StreamHandler) error {
+
+	return table.consumeStream(ctx, streams, checkpoints, handler)
+}
+
+func (table *Table) consumeStream(ctx context.Context,
+	streams dynamodbstreamsiface.DynamoDBStreamsAPI, checkpoints CheckpointStore,
+	handler StreamHandler) error {
+
+	streamARN, err := table.streamARN(ctx)
+	if err != nil {
+		return err
+	}
+
+	consumer := &streamConsumer{
+		table:          table,
+		streams:        streams,
+		streamARN:      streamARN,
+		checkpoints:    checkpoints,
+		shardIterators: map[string]*string{},
+		knownShards:    newNameSet(),
+	}
+
+	// begin reading open shards at their latest records, or all shards if checkpointed
+	initialIteratorType := dynamodbstreams.ShardIteratorTypeLatest
+	if checkpoints != nil {
+		initialIteratorType = dynamodbstreams.ShardIteratorTypeTrimHorizon
+	}
+	if err := consumer.discoverShards(ctx, initialIteratorType); err != nil {
+		return err
+	}
+
+	for {
+		recordsFound, shardsClosed, err := consumer.poll(ctx, handler)
+		if err != nil {
+			return err
+		}
+
+		// look for child shards to read from the start whenever a parent shard is closed
+		if shardsClosed || len(consumer.shardIterators) == 0 {
+			err := consumer.discoverShards(ctx, dynamodbstreams.ShardIteratorTypeTrimHorizon)
+			if err != nil {
+				return err
+			}
+		}
+
+		if !recordsFound {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(streamPollInterval):
+			}
+		}
+	}
+}
+
+func (table *Table) streamARN(ctx context.Context) (string, error) {
+	describeInfo, err := table.baseClient.DescribeTableWithContext(ctx,
+		&dynamodb.DescribeTableInput{
+			TableName: aws.String(table.Name),
+		})
+	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
+		return "", err
+	}
+
+	streamARN := aws.StringValue(describeInfo.Table.LatestStreamArn)
+	if streamARN == "" {
+		err := fmt.Errorf("stream not enabled for table \"%s\"", table.Name)
+		table.logger.Printf("error: %s\n", err.Error())
+		return "", err
+	}
+
+	return streamARN, nil
+}
+
+type streamConsumer struct {
+	table          *Table
+	streams        dynamodbstreamsiface.DynamoDBStreamsAPI
+	streamARN      string
+	checkpoints    CheckpointStore
+	shardIterators map[string]*string
+	knownShards    *nameSet
+}
+
+// discoverShards begins reading shards of the stream that are not yet being read. Checkpointed
+// child shards are deferred until their parent shard has been fully read, and are found by a later
+// discovery once the parent is closed.
+func (consumer *streamConsumer) discoverShards(ctx context.Context, iteratorType string) error {
+	shards, err := consumer.listShards(ctx)
+	if err != nil {
+		return err
+	}
+
+	listedShards := newNameSet()
+	for _, shard := range shards {
+		listedShards.Insert(aws.StringValue(shard.ShardId))
+	}
+
+	for _, shard := range shards {
+		shardID := aws.StringValue(shard.ShardId)
+		if consumer.knownShards.Contains(shardID) {
+			continue
+		}
+
+		// read checkpointed child shards once the parent shard has been read, including parents
+		// that are listed but not yet being read
+		if consumer.checkpoints != nil && consumer.parentUnfinished(shard, listedShards) {
+			continue
+		}
+		consumer.knownShards.Insert(shardID)
+
+		// skip shards that were already closed before the consumer started
+		isClosed := shard.SequenceNumberRange != nil &&
+			shard.SequenceNumberRange.EndingSequenceNumber != nil
+		if isClosed && iteratorType == dynamodbstreams.ShardIteratorTypeLatest {
+			continue
+		}
+
+		iteratorInput := &dynamodbstreams.GetShardIteratorInput{
+			StreamArn:         aws.String(consumer.streamARN),
+			ShardId:           shard.ShardId,
+			ShardIteratorType: aws.String(iteratorType),
+		}
+
+		// resume after checkpoint, if applicable
+		if consumer.checkpoints != nil {
+			sequenceNumber, found, err := consumer.checkpoints.LoadCheckpoint(ctx, shardID)
+			if err != nil {
+				consumer.table.logger.Printf("error: %s\n", err.Error())
+				return err
+			} else if found {
+				iteratorInput.ShardIteratorType = aws.String(
+					dynamodbstreams.ShardIteratorTypeAfterSequenceNumber)
+				iteratorInput.SequenceNumber = aws.String(sequenceNumber)
+			}
+		}
+
+		iteratorInfo, err := consumer.streams.GetShardIteratorWithContext(ctx, iteratorInput)
+		if err != nil {
+			consumer.table.logger.Printf("error: %s\n", err.Error())
+			return err
+		}
+
+		consumer.table.logger.Printf("reading stream shard \"%s\" of table \"%s\"\n",
+			shardID, consumer.table.Name)
+		consumer.shardIterators[shardID] = iteratorInfo.ShardIterator
+	}
+
+	return nil
+}
+
+// listShards returns all shards of the stream.
+func (consumer *streamConsumer) listShards(ctx context.Context) ([]*dynamodbstreams.Shard, error) {
+	shards := []*dynamodbstreams.Shard{}
+	var exclusiveStartShardID *string
+	for {
+		describeInfo, err := consumer.streams.DescribeStreamWithContext(ctx,
+			&dynamodbstreams.DescribeStreamInput{
+				StreamArn:             aws.String(consumer.streamARN),
+				ExclusiveStartShardId: exclusiveStartShardID,
+			})
+		if err != nil {
+			consumer.table.logger.Printf("error: %s\n", err.Error())
+			return nil, err
+		}
+		shards = append(shards, describeInfo.StreamDescription.Shards...)
+
+		exclusiveStartShardID = describeInfo.StreamDescription.LastEvaluatedShardId
+		if exclusiveStartShardID == nil {
+			return shards, nil
+		}
+	}
+}
+
+// parentUnfinished returns true if the shard's parent is still listed in the stream and has not
+// been fully read. Parents trimmed from the stream have no records left to read.
+func (consumer *streamConsumer) parentUnfinished(shard *dynamodbstreams.Shard,
+	listedShards *nameSet) bool {
+
+	parentID := aws.StringValue(shard.ParentShardId)
+	if parentID == "" || !listedShards.Contains(parentID) {
+		return false
+	}
+	_, reading := consumer.shardIterators[parentID]
+	return reading || !consumer.knownShards.Contains(parentID)
+}
+
+// poll reads the next batch of records of each open shard, returning whether any records were
+// found and whether any shard was closed.
+func (consumer *streamConsumer) poll(ctx context.Context,
+	handler StreamHandler) (recordsFound, shardsClosed bool, err error) {
+
+	for shardID, shardIterator := range consumer.shardIterators {
+		if err := ctx.Err(); err != nil {
+			return recordsFound, shardsClosed, err
+		}
+
+		recordsInfo, err := consumer.streams.GetRecordsWithContext(ctx,
+			&dynamodbstreams.GetRecordsInput{
+				ShardIterator: shardIterator,
+			})
+		if err != nil {
+			consumer.table.logger.Printf("error: %s\n", err.Error())
+			return recordsFound, shardsClosed, err
+		}
+
+		for _, record := range recordsInfo.Records {
+			recordsFound = true
+			if err := handler(ctx, record); err != nil {
+				return recordsFound, shardsClosed, err
+			}
+		}
+
+		// checkpoint last handled record, if applicable
+		if consumer.checkpoints != nil && len(recordsInfo.Records) > 0 {
+			lastRecord := recordsInfo.Records[len(recordsInfo.Records)-1]
+			sequenceNumber := aws.StringValue(lastRecord.Dynamodb.SequenceNumber)
+			err := consumer.checkpoints.SaveCheckpoint(ctx, shardID, sequenceNumber)
+			if err != nil {
+				consumer.table.logger.Printf("error: %s\n", err.Error())
+				return recordsFound, shardsClosed, err
+			}
+		}
+
+		// a nil iterator indicates the shard has been closed and fully read
+		if recordsInfo.NextShardIterator == nil {
+			consumer.table.logger.Printf("stream shard \"%s\" of table \"%s\" closed\n",
+				shardID, consumer.table.Name)
+			delete(consumer.shardIterators, shardID)
+			shardsClosed = true
+		} else {
+			consumer.shardIterators[shardID] = recordsInfo.NextShardIterator
+		}
+	}
+
+	return recordsFound, shardsClosed, nil
+}
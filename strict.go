@@ -0,0 +1,141 @@
+package dynamodbfriend
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// StrictMode determines how mismatches between items and the structs they are read into are
+// reported.
+type StrictMode int
+
+// Strict unmarshal modes.
+const (
+	// StrictOff ignores mismatches between items and structs.
+	StrictOff StrictMode = iota
+	// StrictWarn logs a warning for each item that does not match its struct.
+	StrictWarn
+	// StrictError fails reads of items that do not match their struct with ErrSchemaMismatch.
+	StrictError
+)
+
+// WithStrictUnmarshal sets how items read into structs are checked against the struct. An item
+// mismatches its struct if it has attributes with no corresponding field, or if a field without
+// the omitempty option has no corresponding attribute. Attributes managed by the table, such as
+// soft delete, TTL, schema version, derived and codec attributes, are not reported. Items decoded
+// by a codec are not checked, and restricted fields or fields outside a query or scan's selected
+// attributes are not reported as missing.
+func (table *Table) WithStrictUnmarshal(mode StrictMode) *Table {
+	table.strictUnmarshal = mode
+	return table
+}
+
+// ErrSchemaMismatch is returned when an item read into a struct has attributes with no
+// corresponding field, or lacks attributes for fields of the struct.
+type ErrSchemaMismatch struct {
+	TableName         string
+	Type              string
+	UnknownAttributes []string
+	MissingAttributes []string
+}
+
+func (e ErrSchemaMismatch) Error() string {
+	problems := []string{}
+	if len(e.UnknownAttributes) > 0 {
+		problems = append(problems, fmt.Sprintf("attributes with no field: %s",
+			strings.Join(e.UnknownAttributes, ", ")))
+	}
+	if len(e.MissingAttributes) > 0 {
+		problems = append(problems, fmt.Sprintf("fields with no attribute: %s",
+			strings.Join(e.MissingAttributes, ", ")))
+	}
+	return fmt.Sprintf("item of table \"%s\" does not match type %s; %s",
+		e.TableName, e.Type, strings.Join(problems, "; "))
+}
+
+// checkStrictUnmarshal checks an item against the struct it is read into, reporting any mismatch
+// according to the table's strict unmarshal mode. Values that are not pointers to structs are
+// not checked, and fields with no attribute are only reported if checkMissing is true.
+func (table *Table) checkStrictUnmarshal(attrMap map[string]*dynamodb.AttributeValue,
+	val interface{}, checkMissing bool) error {
+
+	if table.strictUnmarshal == StrictOff {
+		return nil
+	}
+
+	valType := reflect.TypeOf(val)
+	if valType == nil || valType.Kind() != reflect.Ptr {
+		return nil
+	}
+	structType := indirectType(valType)
+	if structType.Kind() != reflect.Struct {
+		return nil
+	}
+
+	fields := map[string]bool{}
+	structAttributeNames(structType, fields)
+
+	managed := newNameSet()
+	for _, name := range []string{table.softDeleteAttribute, table.ttlAttribute,
+		table.schemaVersionAttribute} {
+		if name != "" {
+			managed.Insert(name)
+		}
+	}
+	for _, derived := range table.derivedAttributes {
+		managed.Insert(derived.name)
+	}
+	if len(table.codecs) > 0 {
+		managed.Insert(table.discriminatorAttribute())
+	}
+
+	mismatch := ErrSchemaMismatch{TableName: table.Name, Type: structType.String()}
+	for name := range attrMap {
+		if _, found := fields[name]; !found && !managed.Contains(name) {
+			mismatch.UnknownAttributes = append(mismatch.UnknownAttributes, name)
+		}
+	}
+	for name, omitEmpty := range fields {
+		restricted := table.restrictedAttributes != nil && table.restrictedAttributes.Contains(name)
+		if _, found := attrMap[name]; !found && !omitEmpty && !restricted && checkMissing {
+			mismatch.MissingAttributes = append(mismatch.MissingAttributes, name)
+		}
+	}
+	if len(mismatch.UnknownAttributes) == 0 && len(mismatch.MissingAttributes) == 0 {
+		return nil
+	}
+	sort.Strings(mismatch.UnknownAttributes)
+	sort.Strings(mismatch.MissingAttributes)
+
+	if table.strictUnmarshal == StrictWarn {
+		table.logger.Printf("warning: %s\n", mismatch.Error())
+		return nil
+	}
+	table.logger.Printf("error: %s\n", mismatch.Error())
+	return mismatch
+}
+
+// structAttributeNames collects the attribute names of the fields of a struct type, including
+// fields of inlined embedded structs, mapped to whether the field has the omitempty option.
+func structAttributeNames(structType reflect.Type, fields map[string]bool) {
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		name, inline, skip := attributeNameOfField(field)
+		if skip {
+			continue
+		} else if inline {
+			structAttributeNames(indirectType(field.Type), fields)
+			continue
+		}
+
+		tag := field.Tag.Get("dynamodbav")
+		if tag == "" {
+			tag = field.Tag.Get("json")
+		}
+		fields[name] = strings.Contains(tag, ",omitempty")
+	}
+}
@@ -0,0 +1,326 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+// RetentionAction is the action taken on items matched by a retention policy.
+type RetentionAction int
+
+// Retention actions taken by a Sweeper.
+const (
+	// RetentionDelete deletes matched items.
+	RetentionDelete RetentionAction = iota
+	// RetentionExpire sets the time to live of matched items, leaving deletion to DynamoDB.
+	RetentionExpire
+)
+
+// RetentionPolicy describes the items removed by a Sweeper.
+type RetentionPolicy struct {
+	// Predicate matches items to be removed, using attribute names as stored in the table. The
+	// predicate is also checked as a condition of each removal, so items changed since they were
+	// scanned are only removed if they still match.
+	Predicate expression.ConditionBuilder
+
+	// Action is the action taken on matched items.
+	Action RetentionAction
+
+	// ExpireAfter is the delay from the time an item is swept until it expires, used with
+	// RetentionExpire.
+	ExpireAfter time.Duration
+}
+
+// SweepResult summarizes a single sweep of a table.
+type SweepResult struct {
+	ItemsScanned int64
+	ItemsSwept   int64
+}
+
+// Sweeper scans a table for items matching a retention policy and removes them, such as for
+// cleanup of items written without a time to live.
+type Sweeper struct {
+	name        string
+	table       *Table
+	policy      RetentionPolicy
+	segments    int
+	rateLimit   float64
+	checkpoints CheckpointStore
+}
+
+// NewSweeper instantiates a named sweeper for the table with the specified retention policy. The
+// sweeper scans with a single segment and no rate limit by default.
+func (table *Table) NewSweeper(name string, policy RetentionPolicy) *Sweeper {
+	return &Sweeper{
+		name:     name,
+		table:    table,
+		policy:   policy,
+		segments: 1,
+	}
+}
+
+// WithSegments sets the number of segments scanned in parallel.
+func (sweeper *Sweeper) WithSegments(segments int) *Sweeper {
+	if segments < 1 {
+		segments = 1
+	}
+	sweeper.segments = segments
+	return sweeper
+}
+
+// WithRateLimit limits the number of items swept per second across all segments.
+func (sweeper *Sweeper) WithRateLimit(itemsPerSecond float64) *Sweeper {
+	sweeper.rateLimit = itemsPerSecond
+	return sweeper
+}
+
+// WithCheckpoints saves scan progress of each segment in the checkpoint store, so that an
+// interrupted sweep resumes where it left off. Checkpoints are keyed by the sweeper name and
+// segment.
+func (sweeper *Sweeper) WithCheckpoints(checkpoints CheckpointStore) *Sweeper {
+	sweeper.checkpoints = checkpoints
+	return sweeper
+}
+
+// Run sweeps the table repeatedly, waiting for the interval between sweeps. Run blocks until the
+// context is cancelled or a sweep fails.
+func (sweeper *Sweeper) Run(ctx context.Context, interval time.Duration) error {
+	for {
+		if _, err := sweeper.Sweep(ctx); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Sweep performs a single pass over the table, removing all items matching the retention policy.
+func (sweeper *Sweeper) Sweep(ctx context.Context) (SweepResult, error) {
+	table := sweeper.table
+	result := SweepResult{}
+
+	if err := table.loadIndexMetadata(ctx); err != nil {
+		return result, err
+	}
+	if sweeper.policy.Action == RetentionExpire {
+		if err := table.loadTTLMetadata(ctx); err != nil {
+			return result, err
+		}
+		if table.ttlAttribute == "" {
+			err := fmt.Errorf("time to live not enabled for table \"%s\"", table.Name)
+			table.logger.Printf("error: %s\n", err.Error())
+			return result, err
+		}
+	}
+
+	// schedule sweep requests behind interactive traffic unless otherwise specified
+	if _, found := ctx.Value(priorityKey{}).(Priority); !found {
+		ctx = WithPriority(ctx, PriorityBatch)
+	}
+
+	table.logger.Printf("starting sweep \"%s\" of table \"%s\"\n", sweeper.name, table.Name)
+
+	limiter := newRateLimiter(sweeper.rateLimit)
+
+	err := runSegments(ctx, sweeper.segments, func(ctx context.Context, segment int) error {
+		return sweeper.sweepSegment(ctx, segment, limiter, &result)
+	})
+	if err != nil {
+		return result, err
+	}
+
+	table.logger.Printf("sweep \"%s\" of table \"%s\" removed %d of %d items scanned\n",
+		sweeper.name, table.Name, result.ItemsSwept, result.ItemsScanned)
+
+	return result, nil
+}
+
+func (sweeper *Sweeper) sweepSegment(ctx context.Context, segment int, limiter *rateLimiter,
+	result *SweepResult) error {
+
+	scan := segmentScan{
+		segment:       segment,
+		totalSegments: sweeper.segments,
+		filter:        &sweeper.policy.Predicate,
+		keysOnly:      true,
+		checkpoints:   sweeper.checkpoints,
+		checkpointID:  fmt.Sprintf("%s#%d/%d", sweeper.name, segment, sweeper.segments),
+	}
+
+	return sweeper.table.scanSegment(ctx, scan,
+		func(ctx context.Context, page *dynamodb.ScanOutput) error {
+			atomic.AddInt64(&result.ItemsScanned, aws.Int64Value(page.ScannedCount))
+
+			for _, key := range page.Items {
+				if err := limiter.wait(ctx); err != nil {
+					return err
+				}
+
+				swept, err := sweeper.sweepItem(ctx, key)
+				if err != nil {
+					return err
+				} else if swept {
+					atomic.AddInt64(&result.ItemsSwept, 1)
+				}
+			}
+			return nil
+		})
+}
+
+// sweepItem removes the item with the key if it still matches the retention predicate.
+func (sweeper *Sweeper) sweepItem(ctx context.Context,
+	key map[string]*dynamodb.AttributeValue) (bool, error) {
+
+	table := sweeper.table
+	predicate := sweeper.policy.Predicate
+
+	var err error
+	switch sweeper.policy.Action {
+	case RetentionDelete:
+		err = table.deleteItem(ctx, key, &predicate)
+	case RetentionExpire:
+		err = sweeper.expireItem(ctx, key)
+	}
+
+	if awsErr, isAWSErr := err.(awserr.Error); isAWSErr &&
+		awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		// item changed since it was scanned and no longer matches
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (sweeper *Sweeper) expireItem(ctx context.Context, key map[string]*dynamodb.AttributeValue) error {
+	table := sweeper.table
+
+	// items deleted since they were scanned must not be recreated by the update
+	condition := expression.And(sweeper.policy.Predicate, expression.AttributeExists(
+		expression.Name(table.allIndexes()[tablePrimaryIndexName].PartitionKey)))
+
+	expiresAt := time.Now().Add(sweeper.policy.ExpireAfter).Unix()
+	update := expression.Set(expression.Name(table.ttlAttribute), expression.Value(expiresAt))
+	dbExpr, err := expression.NewBuilder().
+		WithCondition(condition).
+		WithUpdate(update).
+		Build()
+	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
+		return err
+	}
+
+	updateInput := &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(table.Name),
+		Key:                       key,
+		ConditionExpression:       dbExpr.Condition(),
+		UpdateExpression:          dbExpr.Update(),
+		ExpressionAttributeNames:  dbExpr.Names(),
+		ExpressionAttributeValues: dbExpr.Values(),
+		ReturnConsumedCapacity:    table.returnConsumedCapacity(),
+	}
+
+	// request new image for auditing, if applicable
+	if table.auditor != nil {
+		updateInput.ReturnValues = aws.String(dynamodb.ReturnValueAllNew)
+	}
+
+	start := time.Now()
+	updateOutput, err := table.baseClient.UpdateItemWithContext(ctx, updateInput)
+
+	stats := OperationStats{
+		Operation: "UpdateItem",
+		Latency:   time.Since(start),
+		Items:     1,
+		Err:       err,
+	}
+	if updateOutput != nil {
+		stats.ConsumedCapacity = consumedCapacityUnits(updateOutput.ConsumedCapacity)
+	}
+	table.emitStats(ctx, stats)
+
+	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
+	} else {
+		table.invalidateItem(key)
+	}
+
+	event := AuditEvent{
+		Operation: AuditOperationUpdate,
+		Key:       key,
+		Err:       err,
+	}
+	if updateOutput != nil {
+		event.NewImage = updateOutput.Attributes
+	}
+	table.audit(ctx, event)
+
+	return err
+}
+
+// rateLimiter spaces events evenly to stay within a rate.
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// newRateLimiter returns a limiter for the rate in events per second. A non-positive rate is
+// unlimited.
+func newRateLimiter(perSecond float64) *rateLimiter {
+	limiter := &rateLimiter{}
+	if perSecond > 0 {
+		limiter.interval = time.Duration(float64(time.Second) / perSecond)
+	}
+	return limiter
+}
+
+// wait blocks until the next event is allowed or the context is cancelled.
+func (limiter *rateLimiter) wait(ctx context.Context) error {
+	return limiter.waitN(ctx, 1)
+}
+
+// waitN blocks until n events are allowed or the context is cancelled.
+func (limiter *rateLimiter) waitN(ctx context.Context, n float64) error {
+	if limiter.interval == 0 {
+		return ctx.Err()
+	}
+
+	delay := limiter.reserveN(n)
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(delay):
+		return nil
+	}
+}
+
+// reserveN counts n events against the rate without waiting, such as events that have already
+// occurred, and returns the delay until they are allowed.
+func (limiter *rateLimiter) reserveN(n float64) time.Duration {
+	if limiter.interval == 0 {
+		return 0
+	}
+
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	now := time.Now()
+	if limiter.next.Before(now) {
+		limiter.next = now
+	}
+	delay := limiter.next.Sub(now)
+	limiter.next = limiter.next.Add(time.Duration(n * float64(limiter.interval)))
+	return delay
+}
@@ -2,6 +2,10 @@ package dynamodbfriend
 
 import (
 	"context"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
@@ -13,9 +17,94 @@ import (
 type Table struct {
 	Name string
 
+	// client is the client that instantiated the table, through which transactions are submitted
+	client *Client
+
 	baseClient dynamodbiface.DynamoDBAPI
+	rawClient  dynamodbiface.DynamoDBAPI
+
+	operationTimeouts OperationTimeouts
+	requestScheduler  *requestScheduler
+
+	logger Logger
+
+	tenant string
+
+	softDeleteAttribute string
+
+	auditor Auditor
+
+	statsEmitter StatsEmitter
+
+	excludeExpiredItems bool
+	ttlMetadataLoaded   bool
+	ttlAttribute        string
+
+	schemaVersionAttribute string
+	currentSchemaVersion   int
+	migrations             map[int]Migration
+	migrationWriteBack     bool
+
+	attributeAliases map[string]string
+	aliasDualWrite   bool
+
+	itemCache    ItemCache
+	itemCacheTTL time.Duration
+
+	negativeItemCacheTTL time.Duration
+
+	queryCache *QueryCache
+
+	consistentReads bool
+
+	planCache *queryPlanCache
+
+	requestCoalescing *requestGroup
+
+	writeDedup *writeDeduplicator
 
-	allIndexes map[string]*tableIndex
+	replicaReads *replicaReads
+
+	derivedAttributes []derivedAttribute
+	sparseAttributes  []sparseAttribute
+
+	sequences *sequenceAllocator
+
+	protoSupport bool
+
+	jsonAttributes []jsonAttribute
+
+	strictUnmarshal StrictMode
+
+	timeEncoding TimeEncoding
+
+	versionAttribute string
+
+	accessPatterns *accessPatternCatalog
+
+	logRedaction RedactionPolicy
+
+	codecDiscriminator string
+	codecs             map[reflect.Type]*entityCodecs
+
+	restrictedAttributes *nameSet
+	attributeReadPolicy  AttributeReadPolicy
+	protectedAttributes  *nameSet
+
+	resultMemoryBudget int64
+	resultSpill        bool
+	resultSpillDir     string
+
+	// indexMetadata is shared by copies of the table, which may be used concurrently
+	indexMetadata *indexMetadata
+}
+
+// indexMetadata holds the index metadata of a table. Metadata is loaded by one caller at a time,
+// and may be read concurrently with loads.
+type indexMetadata struct {
+	mutex   sync.Mutex
+	indexes atomic.Pointer[map[string]*tableIndex]
+	stale   int32
 }
 
 type tableIndex struct {
@@ -24,6 +113,7 @@ type tableIndex struct {
 	PartitionKey          string
 	SortKey               string
 	IsComposite           bool
+	KeyTypes              map[string]string
 	AttributeSet          map[string]struct{}
 	IncludesAllAttributes bool
 	Size                  int
@@ -31,12 +121,63 @@ type tableIndex struct {
 }
 
 // Table instantiates a new Table instance from a Client. This operation only sets metadata for
-// subsequent requests and is guaranteed to succeed.
+// subsequent requests and is guaranteed to succeed. If the table name has been registered with
+// RegisterTable, the table resolves to the registered physical table and location.
 func (client *Client) Table(tableName string) *Table {
-	return &Table{
-		baseClient: client.Base,
-		Name:       tableName,
+	physicalName, base := client.resolveTable(tableName)
+	table := &Table{
+		client:            client,
+		rawClient:         base,
+		Name:              physicalName,
+		logger:            client.getLogger(),
+		tenant:            client.tenant,
+		auditor:           client.auditor,
+		statsEmitter:      client.statsEmitter,
+		operationTimeouts: client.operationTimeouts,
+		timeEncoding:      client.timeEncoding,
+		accessPatterns:    client.accessPatterns,
+		logRedaction:      client.logRedaction,
+		indexMetadata:     &indexMetadata{},
+	}
+	table.baseClient = table.wrapClient(base)
+	return table
+}
+
+// WithClient sets the underlying DynamoDB client used for all operations on this table, such as
+// to access a table in a different account or region than the client that instantiated it. Any
+// previously learned table metadata is discarded, while operation timeouts are retained.
+func (table *Table) WithClient(base dynamodbiface.DynamoDBAPI) *Table {
+	table.rawClient = base
+	table.baseClient = table.wrapClient(base)
+	table.indexMetadata = &indexMetadata{}
+	table.ttlMetadataLoaded = false
+	return table
+}
+
+// wrapClient wraps a DynamoDB client to apply the table's operation timeouts and request
+// scheduling, if applicable.
+func (table *Table) wrapClient(base dynamodbiface.DynamoDBAPI) dynamodbiface.DynamoDBAPI {
+	base = withOperationTimeouts(base, table.operationTimeouts)
+	if table.requestScheduler != nil {
+		base = &scheduledClient{DynamoDBAPI: base, scheduler: table.requestScheduler}
 	}
+	return base
+}
+
+// WithConsistentReads sets whether reads on this table default to consistent reads. Queries that
+// do not set read consistency with QueryExpr.ConsistentRead use a consistent read when a viable
+// index supports it, and otherwise downgrade to an eventually consistent read with a logged
+// warning. Unlike QueryExpr.ConsistentRead, the default does not restrict max pagination.
+func (table *Table) WithConsistentReads(consistent bool) *Table {
+	table.consistentReads = consistent
+	return table
+}
+
+// WithLogger sets the logger used by all operations on this table. A logger set on a query
+// expression takes precedence over the table logger for operations using that expression.
+func (table *Table) WithLogger(logger Logger) *Table {
+	table.logger = logger
+	return table
 }
 
 const tablePrimaryIndexName = "#primary"
@@ -44,8 +185,8 @@ const tablePrimaryIndexName = "#primary"
 func (table *Table) indexNameSet() *nameSet {
 	indexNames := newNameSet()
 
-	if table.allIndexes != nil {
-		for indexName := range table.allIndexes {
+	if allIndexes := table.allIndexes(); allIndexes != nil {
+		for indexName := range allIndexes {
 			indexNames.Insert(indexName)
 		}
 	}
@@ -53,8 +194,40 @@ func (table *Table) indexNameSet() *nameSet {
 	return indexNames
 }
 
+// allIndexes returns the indexes of the table by name, or nil if index metadata is not loaded.
+func (table *Table) allIndexes() map[string]*tableIndex {
+	if indexes := table.indexMetadata.indexes.Load(); indexes != nil {
+		return *indexes
+	}
+	return nil
+}
+
+func (table *Table) loadIndexMetadata(ctx context.Context) error {
+	metadata := table.indexMetadata
+	if metadata.indexes.Load() != nil && atomic.LoadInt32(&metadata.stale) == 0 {
+		return nil
+	}
+
+	// tables are shared across goroutines, so metadata is loaded by one caller at a time
+	metadata.mutex.Lock()
+	defer metadata.mutex.Unlock()
+
+	// learn table indexes if not already known or known to be stale
+	if metadata.indexes.Load() == nil || atomic.CompareAndSwapInt32(&metadata.stale, 1, 0) {
+		err := table.fetchIndexMetadata(ctx)
+		if err != nil && metadata.indexes.Load() != nil {
+			// keep the stale metadata, and fetch it again on the next load
+			atomic.StoreInt32(&metadata.stale, 1)
+		}
+		return err
+	}
+	return nil
+}
+
+// fetchIndexMetadata describes the table and replaces its index metadata. The metadata is left
+// unmodified if the table cannot be described.
 func (table *Table) fetchIndexMetadata(ctx context.Context) error {
-	table.allIndexes = nil
+	table.logger.Printf("fetching index metadata for table \"%s\"\n", table.Name)
 
 	// make call to AWS describe table
 	describeInfo, err := table.baseClient.DescribeTableWithContext(ctx,
@@ -62,12 +235,13 @@ func (table *Table) fetchIndexMetadata(ctx context.Context) error {
 			TableName: aws.String(table.Name),
 		})
 	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
 		return err
 	}
 
 	tableDescription := describeInfo.Table
 
-	table.allIndexes = map[string]*tableIndex{}
+	allIndexes := map[string]*tableIndex{}
 
 	// extract primary key index
 	tablePrimaryIndex := new(tableIndex)
@@ -75,9 +249,10 @@ func (table *Table) fetchIndexMetadata(ctx context.Context) error {
 	tablePrimaryIndex.TableName = table.Name
 	tablePrimaryIndex.Size = int(*tableDescription.ItemCount)
 	tablePrimaryIndex.loadKeysFromSchema(tableDescription.KeySchema)
+	tablePrimaryIndex.loadKeyTypes(tableDescription.AttributeDefinitions)
 	tablePrimaryIndex.IncludesAllAttributes = true
 	tablePrimaryIndex.ConsistentReadable = true // true for table primary index
-	table.allIndexes[tablePrimaryIndexName] = tablePrimaryIndex
+	allIndexes[tablePrimaryIndexName] = tablePrimaryIndex
 
 	tablePrimaryIndexKeys := tablePrimaryIndex.getKeys()
 
@@ -88,9 +263,10 @@ func (table *Table) fetchIndexMetadata(ctx context.Context) error {
 		index.TableName = table.Name
 		index.Size = int(*indexDescription.ItemCount)
 		index.loadKeysFromSchema(indexDescription.KeySchema)
+		index.loadKeyTypes(tableDescription.AttributeDefinitions)
 		index.loadAttributesFromProjection(indexDescription.Projection, tablePrimaryIndexKeys)
 		index.ConsistentReadable = false // false for global secondary indexes
-		table.allIndexes[index.Name] = index
+		allIndexes[index.Name] = index
 	}
 
 	// extract local secondary indexes
@@ -100,11 +276,21 @@ func (table *Table) fetchIndexMetadata(ctx context.Context) error {
 		index.TableName = table.Name
 		index.Size = int(*indexDescription.ItemCount)
 		index.loadKeysFromSchema(indexDescription.KeySchema)
+		index.loadKeyTypes(tableDescription.AttributeDefinitions)
 		index.loadAttributesFromProjection(indexDescription.Projection, tablePrimaryIndexKeys)
 		index.ConsistentReadable = true // true for local secondary indexes
-		table.allIndexes[index.Name] = index
+		allIndexes[index.Name] = index
 	}
 
+	table.indexMetadata.indexes.Store(&allIndexes)
+
+	// discard plans made with previous metadata
+	if table.planCache != nil {
+		table.planCache.clear()
+	}
+
+	table.logger.Printf("found %d indexes in table \"%s\"\n", len(allIndexes), table.Name)
+
 	return nil
 }
 
@@ -121,6 +307,27 @@ func (index *tableIndex) loadKeysFromSchema(keySchema []*dynamodb.KeySchemaEleme
 	}
 }
 
+// loadKeyTypes sets the scalar types of the index's key attributes, which must already be loaded.
+func (index *tableIndex) loadKeyTypes(definitions []*dynamodb.AttributeDefinition) {
+	index.KeyTypes = map[string]string{}
+	for _, key := range index.getKeys() {
+		for _, definition := range definitions {
+			if aws.StringValue(definition.AttributeName) == key {
+				index.KeyTypes[key] = aws.StringValue(definition.AttributeType)
+			}
+		}
+	}
+}
+
+// keyType returns the scalar type of a key attribute of the index, which is assumed to be a string
+// if not known.
+func (index tableIndex) keyType(key string) string {
+	if keyType, found := index.KeyTypes[key]; found {
+		return keyType
+	}
+	return dynamodb.ScalarAttributeTypeS
+}
+
 func (index tableIndex) getKeys() []string {
 	if index.IsComposite {
 		return []string{index.PartitionKey, index.SortKey}
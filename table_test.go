@@ -0,0 +1,47 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestConcurrentIndexMetadataLoads(t *testing.T) {
+	fake := newFakeDynamoDB("items", "id", "ts", fakeIndex{
+		name:         "byStatus",
+		partitionKey: "status",
+		projectAll:   true,
+	})
+	fake.putItems(stringItem(map[string]string{"id": "a", "ts": "1"}))
+	table := newFakeTable(fake)
+	tenantTable := table.WithTenant("acme")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			// metadata is refreshed while other goroutines read it
+			if i%4 == 0 {
+				atomic.StoreInt32(&table.indexMetadata.stale, 1)
+			}
+
+			readTable := table
+			if i%2 == 1 {
+				readTable = tenantTable
+			}
+			var item map[string]string
+			err := readTable.Get(context.Background(), map[string]string{"id": "a", "ts": "1"},
+				&item)
+			if _, notFound := err.(ErrItemNotFound); err != nil && !notFound {
+				t.Errorf("unexpected error: %s", err)
+			}
+			if _, found := readTable.allIndexes()["byStatus"]; !found {
+				t.Errorf("expected index metadata to be loaded")
+			}
+		}(i)
+	}
+	wg.Wait()
+}
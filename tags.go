@@ -0,0 +1,31 @@
+package dynamodbfriend
+
+import "context"
+
+type tagsKey struct{}
+
+// WithTag returns a copy of the context carrying a tag with the key and value, in addition to any
+// tags already carried by the context. Operations performed with the context report its tags in
+// OperationStats and AuditEvent, such as to attribute consumed capacity to a caller's API route.
+// A tag with an existing key replaces the existing value. Tags are not included in the output of
+// table and expression loggers.
+func WithTag(ctx context.Context, key, value string) context.Context {
+	existing := Tags(ctx)
+	tags := make(map[string]string, len(existing)+1)
+	for k, v := range existing {
+		tags[k] = v
+	}
+	tags[key] = value
+	return context.WithValue(ctx, tagsKey{}, tags)
+}
+
+// Tags returns the tags carried by the context, or nil if the context carries no tags. The
+// returned map must not be modified. Tags may be read by wrappers of the DynamoDB client, which
+// receive the caller's context with each request.
+func Tags(ctx context.Context) map[string]string {
+	if ctx == nil {
+		return nil
+	}
+	tags, _ := ctx.Value(tagsKey{}).(map[string]string)
+	return tags
+}
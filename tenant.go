@@ -0,0 +1,89 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+const tenantDelimiter = "#"
+
+// WithTenant returns a copy of the client whose tables are isolated to a single tenant. See
+// Table.WithTenant for details.
+func (client *Client) WithTenant(tenant string) *Client {
+	tenantClient := *client
+	tenantClient.tenant = tenant
+	return &tenantClient
+}
+
+// WithTenant returns a copy of the table isolated to a single tenant. Partition key values of the
+// table and its global secondary indexes are prefixed with the tenant on writes and key
+// conditions, and the prefix is stripped from items on reads. All partition keys must be string
+// attributes.
+func (table *Table) WithTenant(tenant string) *Table {
+	tenantTable := *table
+	tenantTable.tenant = tenant
+	return &tenantTable
+}
+
+func (table *Table) tenantPrefix() string {
+	if table.tenant == "" {
+		return ""
+	}
+	return table.tenant + tenantDelimiter
+}
+
+func (table *Table) partitionKeyNameSet() *nameSet {
+	partitionKeys := newNameSet()
+	for _, index := range table.allIndexes() {
+		partitionKeys.Insert(index.PartitionKey)
+	}
+	return partitionKeys
+}
+
+func (table *Table) applyTenantPrefix(ctx context.Context,
+	item map[string]*dynamodb.AttributeValue) error {
+
+	prefix := table.tenantPrefix()
+	if prefix == "" {
+		return nil
+	}
+
+	if err := table.loadIndexMetadata(ctx); err != nil {
+		return err
+	}
+
+	for _, key := range table.partitionKeyNameSet().Names() {
+		av, found := item[key]
+		if !found {
+			continue
+		}
+		if av.S == nil {
+			err := fmt.Errorf("partition key \"%s\" must be a string for tenant isolation", key)
+			table.logger.Printf("error: %s\n", err.Error())
+			return err
+		}
+		prefixed := prefix + *av.S
+		item[key] = &dynamodb.AttributeValue{S: &prefixed}
+	}
+
+	return nil
+}
+
+func (table *Table) stripTenantPrefix(item map[string]*dynamodb.AttributeValue) {
+	prefix := table.tenantPrefix()
+	if prefix == "" {
+		return
+	}
+
+	for _, key := range table.partitionKeyNameSet().Names() {
+		av, found := item[key]
+		if !found || av.S == nil {
+			continue
+		}
+		stripped := strings.TrimPrefix(*av.S, prefix)
+		item[key] = &dynamodb.AttributeValue{S: &stripped}
+	}
+}
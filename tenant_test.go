@@ -0,0 +1,82 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestTenantPrefixRoundTrip(t *testing.T) {
+	fake := newFakeDynamoDB("items", "id", "ts", fakeIndex{
+		name:         "byStatus",
+		partitionKey: "status",
+		projectAll:   true,
+	})
+	table := newFakeTable(fake)
+	acme, other := table.WithTenant("acme"), table.WithTenant("other")
+
+	item := map[string]string{"id": "a", "ts": "1", "status": "open", "title": "t"}
+	if err := acme.Put(context.Background(), item); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// partition keys of the table and its indexes are stored with the tenant prefix
+	stored := fake.putInputs[0].Item
+	expectStored := stringItem(map[string]string{
+		"id": "acme#a", "ts": "1", "status": "acme#open", "title": "t",
+	})
+	if !reflect.DeepEqual(stored, expectStored) {
+		t.Errorf("expected stored item %v, got %v", expectStored, stored)
+	}
+
+	key := map[string]string{"id": "a", "ts": "1"}
+	var got map[string]string
+	if err := acme.Get(context.Background(), key, &got); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if !reflect.DeepEqual(got, item) {
+		t.Errorf("expected item %v, got %v", item, got)
+	}
+
+	if err := other.Get(context.Background(), key, &got); err == nil {
+		t.Errorf("expected item of another tenant not to be found")
+	}
+
+	// query key conditions are prefixed, and the prefix is stripped from results
+	fake.queryItems = []map[string]*dynamodb.AttributeValue{stored}
+	parser, err := acme.Query(context.Background(), NewQuery("status").Equals("open"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	results := []map[string]string{}
+	if err := parser.All(context.Background(), &results); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if len(results) != 1 || !reflect.DeepEqual(results[0], item) {
+		t.Errorf("expected query results [%v], got %v", item, results)
+	}
+
+	queryValues := fake.queryInputs[0].ExpressionAttributeValues
+	prefixed := false
+	for _, av := range queryValues {
+		if aws.StringValue(av.S) == "acme#open" {
+			prefixed = true
+		}
+	}
+	if !prefixed {
+		t.Errorf("expected key condition value to be prefixed, got %v", queryValues)
+	}
+}
+
+func TestTenantPrefixRequiresStringPartitionKey(t *testing.T) {
+	fake := newFakeDynamoDB("items", "id", "")
+	table := newFakeTable(fake).WithTenant("acme")
+
+	err := table.Put(context.Background(), map[string]interface{}{"id": 1})
+	if err == nil {
+		t.Errorf("expected error for numeric partition key")
+	} else if len(fake.putInputs) != 0 {
+		t.Errorf("expected no puts, got %d", len(fake.putInputs))
+	}
+}
@@ -0,0 +1,56 @@
+// Package testlog provides a logger that records messages for assertions in tests.
+package testlog
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Recorder is a logger that records all messages printed to it. Recorder satisfies the
+// dynamodbfriend.Logger interface and is safe for concurrent use.
+type Recorder struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+// NewRecorder creates a new Recorder with no messages.
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Printf records a formatted message. Trailing newlines are removed.
+func (r *Recorder) Printf(format string, v ...interface{}) {
+	message := strings.TrimRight(fmt.Sprintf(format, v...), "\n")
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = append(r.messages, message)
+}
+
+// Messages returns all recorded messages in the order they were printed.
+func (r *Recorder) Messages() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	messages := make([]string, len(r.messages))
+	copy(messages, r.messages)
+	return messages
+}
+
+// Contains returns true if any recorded message contains substr.
+func (r *Recorder) Contains(substr string) bool {
+	for _, message := range r.Messages() {
+		if strings.Contains(message, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Reset discards all recorded messages.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = nil
+}
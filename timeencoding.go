@@ -0,0 +1,130 @@
+package dynamodbfriend
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// TimeEncoding is the encoding of time values stored in table items.
+type TimeEncoding int
+
+const (
+	// TimeEncodingRFC3339 encodes times as RFC 3339 strings with nanoseconds, as time.Time values
+	// are marshaled by the AWS SDK. This is the default encoding. Trailing zeros of fractional
+	// seconds are trimmed, so times within the same second may not compare in chronological order;
+	// use TimeEncodingSortable for time attributes compared at sub-second precision.
+	TimeEncodingRFC3339 TimeEncoding = iota
+	// TimeEncodingUnix encodes times as numbers of seconds since the Unix epoch, as with
+	// dynamodbattribute.UnixTime.
+	TimeEncodingUnix
+	// TimeEncodingUnixMilli encodes times as numbers of milliseconds since the Unix epoch.
+	TimeEncodingUnixMilli
+	// TimeEncodingSortable encodes times as fixed-width UTC strings with SortableTime, which
+	// compare in chronological order.
+	TimeEncodingSortable
+)
+
+// WithTimeEncoding sets the encoding of time values for all tables subsequently instantiated from
+// this client. See Table.WithTimeEncoding for details.
+func (client *Client) WithTimeEncoding(encoding TimeEncoding) *Client {
+	client.timeEncoding = encoding
+	return client
+}
+
+// WithTimeEncoding sets the encoding of time values used by time conditions of query expressions,
+// such as InTimeRange and Since. The encoding should match how time attributes are stored in the
+// table's items.
+func (table *Table) WithTimeEncoding(encoding TimeEncoding) *Table {
+	table.timeEncoding = encoding
+	return table
+}
+
+// queryTime is a time value of a query condition, replaced with its encoded value once the
+// queried table's time encoding is known.
+type queryTime struct {
+	t time.Time
+}
+
+func (expr *QueryExpr) queryTime(t time.Time) queryTime {
+	expr.timeConditions = true
+	return queryTime{t: t}
+}
+
+// encode returns the attribute value of the time with the encoding.
+func (qt queryTime) encode(encoding TimeEncoding) (*dynamodb.AttributeValue, error) {
+	switch encoding {
+	case TimeEncodingRFC3339:
+		return &dynamodb.AttributeValue{S: aws.String(qt.t.UTC().Format(time.RFC3339Nano))}, nil
+	case TimeEncodingUnix:
+		return &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(qt.t.Unix(), 10))}, nil
+	case TimeEncodingUnixMilli:
+		return &dynamodb.AttributeValue{
+			N: aws.String(strconv.FormatInt(qt.t.UnixNano()/int64(time.Millisecond), 10)),
+		}, nil
+	case TimeEncodingSortable:
+		return &dynamodb.AttributeValue{S: aws.String(SortableTime(qt.t))}, nil
+	}
+	return nil, fmt.Errorf("unknown time encoding %d", encoding)
+}
+
+// encodedTimeValue returns the encoded value of a condition value if it is a time value of a time
+// condition, and otherwise the value as is.
+func encodedTimeValue(value interface{}, encoding TimeEncoding) (interface{}, error) {
+	if qt, isTime := value.(queryTime); isTime {
+		return qt.encode(encoding)
+	}
+	return value, nil
+}
+
+// timeEncodedExpr returns a copy of the query expression with the time values of its time
+// conditions encoded with the table's time encoding, or the expression as is if it has no time
+// conditions. The expression itself is left unmodified, so that it may be reused across tables.
+func (table *Table) timeEncodedExpr(expr *QueryExpr) (*QueryExpr, error) {
+	if !expr.timeConditions {
+		return expr, nil
+	}
+
+	encoded := *expr
+	encoded.filters = map[string][]queryFilter{}
+	for key, filters := range expr.filters {
+		for _, filter := range filters {
+			var err error
+			switch f := filter.(type) {
+			case *greaterThanEqualFilter:
+				encodedFilter := *f
+				encodedFilter.value, err = encodedTimeValue(f.value, table.timeEncoding)
+				filter = &encodedFilter
+			case *betweenFilter:
+				encodedFilter := *f
+				encodedFilter.lowval, err = encodedTimeValue(f.lowval, table.timeEncoding)
+				if err == nil {
+					encodedFilter.highval, err = encodedTimeValue(f.highval, table.timeEncoding)
+				}
+				filter = &encodedFilter
+			}
+			if err != nil {
+				expr.logger.Printf("error: %s\n", err.Error())
+				return nil, err
+			}
+			encoded.filters[key] = append(encoded.filters[key], filter)
+		}
+	}
+	return &encoded, nil
+}
+
+// InTimeRange is a conditional where the time value associated with a query key must be between
+// from and to, inclusive. Times are formatted with the time encoding of the queried table.
+func (k *QueryExprKey) InTimeRange(from, to time.Time) *QueryExpr {
+	return k.Between(k.expr.queryTime(from), k.expr.queryTime(to))
+}
+
+// Since is a conditional where the time value associated with a query key must be no earlier than
+// the duration d before the time Since is called. Times are formatted with the time encoding of
+// the queried table.
+func (k *QueryExprKey) Since(d time.Duration) *QueryExpr {
+	return k.GreaterThanEqual(k.expr.queryTime(time.Now().Add(-d)))
+}
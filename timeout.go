@@ -0,0 +1,167 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// OperationTimeouts sets default timeouts for classes of DynamoDB operations. Timeouts are only
+// applied when the caller's context has no deadline. A zero timeout leaves the operation class
+// without a default timeout.
+type OperationTimeouts struct {
+	// Metadata applies to table description requests.
+	Metadata time.Duration
+
+	// Read applies to each item read, read transaction, and page of a query or scan.
+	Read time.Duration
+
+	// Write applies to each item write and write transaction.
+	Write time.Duration
+
+	// Batch applies to each chunk of a batch read or write.
+	Batch time.Duration
+}
+
+// WithOperationTimeouts sets the default operation timeouts for all tables subsequently
+// instantiated from this client and for transactions submitted by this client, so that a hung
+// network path cannot block callers indefinitely.
+func (client *Client) WithOperationTimeouts(timeouts OperationTimeouts) *Client {
+	client.operationTimeouts = timeouts
+	return client
+}
+
+// WithOperationTimeouts sets the default operation timeouts for all operations on this table.
+func (table *Table) WithOperationTimeouts(timeouts OperationTimeouts) *Table {
+	table.operationTimeouts = timeouts
+	table.baseClient = table.wrapClient(table.rawClient)
+	return table
+}
+
+// timeoutClient applies default operation timeouts to the requests of a DynamoDB client.
+type timeoutClient struct {
+	dynamodbiface.DynamoDBAPI
+	timeouts OperationTimeouts
+}
+
+// withOperationTimeouts wraps the client to apply the timeouts, if any are set.
+func withOperationTimeouts(base dynamodbiface.DynamoDBAPI,
+	timeouts OperationTimeouts) dynamodbiface.DynamoDBAPI {
+
+	if base == nil || timeouts == (OperationTimeouts{}) {
+		return base
+	}
+	return &timeoutClient{DynamoDBAPI: base, timeouts: timeouts}
+}
+
+// withTimeout returns a context with the timeout applied if the context has no deadline.
+func withTimeout(ctx aws.Context, timeout time.Duration) (aws.Context, context.CancelFunc) {
+	if _, hasDeadline := ctx.Deadline(); hasDeadline || timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+func (c *timeoutClient) DescribeTableWithContext(ctx aws.Context,
+	input *dynamodb.DescribeTableInput, opts ...request.Option) (*dynamodb.DescribeTableOutput, error) {
+
+	ctx, cancel := withTimeout(ctx, c.timeouts.Metadata)
+	defer cancel()
+	return c.DynamoDBAPI.DescribeTableWithContext(ctx, input, opts...)
+}
+
+func (c *timeoutClient) DescribeTimeToLiveWithContext(ctx aws.Context,
+	input *dynamodb.DescribeTimeToLiveInput,
+	opts ...request.Option) (*dynamodb.DescribeTimeToLiveOutput, error) {
+
+	ctx, cancel := withTimeout(ctx, c.timeouts.Metadata)
+	defer cancel()
+	return c.DynamoDBAPI.DescribeTimeToLiveWithContext(ctx, input, opts...)
+}
+
+func (c *timeoutClient) GetItemWithContext(ctx aws.Context,
+	input *dynamodb.GetItemInput, opts ...request.Option) (*dynamodb.GetItemOutput, error) {
+
+	ctx, cancel := withTimeout(ctx, c.timeouts.Read)
+	defer cancel()
+	return c.DynamoDBAPI.GetItemWithContext(ctx, input, opts...)
+}
+
+func (c *timeoutClient) QueryWithContext(ctx aws.Context,
+	input *dynamodb.QueryInput, opts ...request.Option) (*dynamodb.QueryOutput, error) {
+
+	ctx, cancel := withTimeout(ctx, c.timeouts.Read)
+	defer cancel()
+	return c.DynamoDBAPI.QueryWithContext(ctx, input, opts...)
+}
+
+func (c *timeoutClient) ScanWithContext(ctx aws.Context,
+	input *dynamodb.ScanInput, opts ...request.Option) (*dynamodb.ScanOutput, error) {
+
+	ctx, cancel := withTimeout(ctx, c.timeouts.Read)
+	defer cancel()
+	return c.DynamoDBAPI.ScanWithContext(ctx, input, opts...)
+}
+
+func (c *timeoutClient) PutItemWithContext(ctx aws.Context,
+	input *dynamodb.PutItemInput, opts ...request.Option) (*dynamodb.PutItemOutput, error) {
+
+	ctx, cancel := withTimeout(ctx, c.timeouts.Write)
+	defer cancel()
+	return c.DynamoDBAPI.PutItemWithContext(ctx, input, opts...)
+}
+
+func (c *timeoutClient) UpdateItemWithContext(ctx aws.Context,
+	input *dynamodb.UpdateItemInput, opts ...request.Option) (*dynamodb.UpdateItemOutput, error) {
+
+	ctx, cancel := withTimeout(ctx, c.timeouts.Write)
+	defer cancel()
+	return c.DynamoDBAPI.UpdateItemWithContext(ctx, input, opts...)
+}
+
+func (c *timeoutClient) DeleteItemWithContext(ctx aws.Context,
+	input *dynamodb.DeleteItemInput, opts ...request.Option) (*dynamodb.DeleteItemOutput, error) {
+
+	ctx, cancel := withTimeout(ctx, c.timeouts.Write)
+	defer cancel()
+	return c.DynamoDBAPI.DeleteItemWithContext(ctx, input, opts...)
+}
+
+func (c *timeoutClient) TransactWriteItemsWithContext(ctx aws.Context,
+	input *dynamodb.TransactWriteItemsInput,
+	opts ...request.Option) (*dynamodb.TransactWriteItemsOutput, error) {
+
+	ctx, cancel := withTimeout(ctx, c.timeouts.Write)
+	defer cancel()
+	return c.DynamoDBAPI.TransactWriteItemsWithContext(ctx, input, opts...)
+}
+
+func (c *timeoutClient) TransactGetItemsWithContext(ctx aws.Context,
+	input *dynamodb.TransactGetItemsInput,
+	opts ...request.Option) (*dynamodb.TransactGetItemsOutput, error) {
+
+	ctx, cancel := withTimeout(ctx, c.timeouts.Read)
+	defer cancel()
+	return c.DynamoDBAPI.TransactGetItemsWithContext(ctx, input, opts...)
+}
+
+func (c *timeoutClient) BatchGetItemWithContext(ctx aws.Context,
+	input *dynamodb.BatchGetItemInput, opts ...request.Option) (*dynamodb.BatchGetItemOutput, error) {
+
+	ctx, cancel := withTimeout(ctx, c.timeouts.Batch)
+	defer cancel()
+	return c.DynamoDBAPI.BatchGetItemWithContext(ctx, input, opts...)
+}
+
+func (c *timeoutClient) BatchWriteItemWithContext(ctx aws.Context,
+	input *dynamodb.BatchWriteItemInput,
+	opts ...request.Option) (*dynamodb.BatchWriteItemOutput, error) {
+
+	ctx, cancel := withTimeout(ctx, c.timeouts.Batch)
+	defer cancel()
+	return c.DynamoDBAPI.BatchWriteItemWithContext(ctx, input, opts...)
+}
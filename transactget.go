@@ -0,0 +1,132 @@
+package dynamodbfriend
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// TransactGet collects reads of items from one or more tables to be read atomically as a single
+// DynamoDB transaction. All tables must be accessible through the client that began the read.
+type TransactGet struct {
+	client *Client
+	gets   []*transactGet
+
+	// base overrides the client's DynamoDB client, if set
+	base dynamodbiface.DynamoDBAPI
+}
+
+type transactGet struct {
+	table *Table
+	key   interface{}
+	val   interface{}
+	found bool
+}
+
+// NewTransactGet begins a new transactional read.
+func (client *Client) NewTransactGet() *TransactGet {
+	return &TransactGet{client: client}
+}
+
+// Get adds a read of the item with the specified key from the table into val, which must be a
+// non-nil pointer. The key may be a struct or map containing the table's primary key attributes,
+// and any other attributes are ignored.
+func (tg *TransactGet) Get(table *Table, key, val interface{}) *TransactGet {
+	tg.gets = append(tg.gets, &transactGet{
+		table: table,
+		key:   key,
+		val:   val,
+	})
+	return tg
+}
+
+// Found returns whether the item of the read at index, in the order added with Get, was found by
+// the last call to Run.
+func (tg *TransactGet) Found(index int) bool {
+	return index >= 0 && index < len(tg.gets) && tg.gets[index].found
+}
+
+// Run reads all items in a single transaction, so that the items are read as of the same point in
+// time. Each found item is unmarshaled into its destination, and destinations of items that are
+// not found are left unmodified. Soft-deleted and expired items are reported as not found when
+// those features are enabled on their table. If DynamoDB cancels the transaction, such as due to
+// a conflicting write, ErrTransactionCanceled is returned.
+func (tg *TransactGet) Run(ctx context.Context) error {
+	logger := tg.client.getLogger()
+
+	if len(tg.gets) > maxTransactionItems {
+		err := ErrTransactionTooLarge{Items: len(tg.gets)}
+		logger.Printf("error: %s\n", err.Error())
+		return err
+	}
+
+	items := make([]*dynamodb.TransactGetItem, len(tg.gets))
+	for i, get := range tg.gets {
+		get.found = false
+
+		if err := get.table.loadIndexMetadata(ctx); err != nil {
+			return err
+		}
+		keyMap, err := get.table.marshalStoredKey(ctx, get.key)
+		if err != nil {
+			logger.Printf("error: %s\n", err.Error())
+			return err
+		}
+		if get.table.excludeExpiredItems {
+			if err := get.table.loadTTLMetadata(ctx); err != nil {
+				return err
+			}
+		}
+
+		items[i] = &dynamodb.TransactGetItem{Get: &dynamodb.Get{
+			TableName: aws.String(get.table.Name),
+			Key:       get.table.primaryKeyOf(keyMap),
+		}}
+	}
+
+	base := tg.base
+	if base == nil {
+		base = tg.client.Base
+	}
+	base = withOperationTimeouts(base, tg.client.operationTimeouts)
+	output, err := base.TransactGetItemsWithContext(ctx, &dynamodb.TransactGetItemsInput{
+		TransactItems: items,
+	})
+	if err != nil {
+		err = transactionCanceledError(err, nil)
+		if canceledErr, isCanceled := err.(ErrTransactionCanceled); isCanceled {
+			for i, reason := range canceledErr.Reasons {
+				canceledErr.Reasons[i].TableName = tg.gets[reason.Index].table.Name
+				canceledErr.Reasons[i].Operation = "Get"
+			}
+		}
+		logger.Printf("error: %s\n", err.Error())
+		return err
+	}
+
+	// responses are ordered as the requested items
+	for i, response := range output.Responses {
+		get := tg.gets[i]
+		storedItem := response.Item
+		if len(storedItem) == 0 || get.table.isSoftDeleted(storedItem) ||
+			get.table.isExpired(storedItem) {
+			continue
+		}
+
+		item, err := get.table.itemFromStore(storedItem)
+		if err != nil {
+			return err
+		}
+		get.table.stripRestrictedAttributes(ctx, item)
+
+		if err := get.table.unmarshalItem(item, get.val); err != nil {
+			logger.Printf("error: %s\n", err.Error())
+			return err
+		}
+		get.found = true
+	}
+
+	return nil
+}
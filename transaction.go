@@ -0,0 +1,281 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+const (
+	maxTransactionItems = 100
+	maxTransactionBytes = 4 * 1024 * 1024
+)
+
+// ErrTransactionTooLarge is returned when a transaction exceeds DynamoDB's limits on the number of
+// items or aggregate item size.
+type ErrTransactionTooLarge struct {
+	Items int
+	Bytes int
+}
+
+func (e ErrTransactionTooLarge) Error() string {
+	if e.Items > maxTransactionItems {
+		return fmt.Sprintf("transaction has %d items, exceeding limit of %d items",
+			e.Items, maxTransactionItems)
+	}
+	return fmt.Sprintf("transaction has approximately %d bytes, exceeding limit of %d bytes",
+		e.Bytes, maxTransactionBytes)
+}
+
+// ErrDuplicateTransactionKey is returned when more than one operation in a transaction targets the
+// same item. FirstIndex and SecondIndex are the positions of the conflicting operations.
+type ErrDuplicateTransactionKey struct {
+	TableName   string
+	FirstIndex  int
+	SecondIndex int
+}
+
+func (e ErrDuplicateTransactionKey) Error() string {
+	return fmt.Sprintf("transaction operations %d and %d target the same item in table \"%s\"",
+		e.FirstIndex, e.SecondIndex, e.TableName)
+}
+
+// TransactionCancelReason describes why a single operation caused a transaction to be canceled.
+type TransactionCancelReason struct {
+	Index     int
+	TableName string
+	Operation string
+	Code      string
+	Message   string
+}
+
+// ErrTransactionCanceled is returned when DynamoDB cancels a transaction. Reasons holds an entry
+// for each operation that caused the cancellation, with Index matching the operation's position
+// in the transaction.
+type ErrTransactionCanceled struct {
+	Reasons []TransactionCancelReason
+}
+
+func (e ErrTransactionCanceled) Error() string {
+	if len(e.Reasons) == 0 {
+		return "transaction canceled"
+	}
+	reason := e.Reasons[0]
+	return fmt.Sprintf(
+		"transaction canceled: operation %d (%s on table \"%s\") failed with %s: %s",
+		reason.Index, reason.Operation, reason.TableName, reason.Code, reason.Message)
+}
+
+// TransactWrite submits operations as a single DynamoDB transaction. The transaction is validated
+// against DynamoDB's size limits and for duplicate item keys before it is submitted, and
+// cancellations are returned as ErrTransactionCanceled.
+func (client *Client) TransactWrite(ctx context.Context, items []*dynamodb.TransactWriteItem) error {
+	return client.transactWrite(ctx, client.Base, items, nil)
+}
+
+// transactWrite submits a transaction through base. The tables of the caller are used to determine
+// the keys of put items, and any other tables are resolved through the client.
+func (client *Client) transactWrite(ctx context.Context, base dynamodbiface.DynamoDBAPI,
+	items []*dynamodb.TransactWriteItem, tables []*Table) error {
+
+	logger := client.getLogger()
+
+	if err := client.validateTransactWriteItems(ctx, items, tables); err != nil {
+		logger.Printf("error: %s\n", err.Error())
+		return err
+	}
+
+	base = withOperationTimeouts(base, client.operationTimeouts)
+	_, err := base.TransactWriteItemsWithContext(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: items,
+	})
+	if err != nil {
+		err = transactionCanceledError(err, items)
+		logger.Printf("error: %s\n", err.Error())
+	}
+
+	return err
+}
+
+func (client *Client) validateTransactWriteItems(ctx context.Context,
+	items []*dynamodb.TransactWriteItem, tables []*Table) error {
+
+	totalBytes := 0
+	for _, item := range items {
+		if item.Put != nil {
+			totalBytes += itemSize(item.Put.Item)
+		}
+	}
+	if len(items) > maxTransactionItems || totalBytes > maxTransactionBytes {
+		return ErrTransactionTooLarge{Items: len(items), Bytes: totalBytes}
+	}
+
+	knownTables := map[string]*Table{}
+	for _, table := range tables {
+		knownTables[table.Name] = table
+	}
+
+	seenKeys := map[string]int{}
+	for i, item := range items {
+		tableName, key, err := client.transactWriteItemKey(ctx, knownTables, item)
+		if err != nil {
+			return err
+		}
+
+		cacheKey := itemCacheKey(tableName, key)
+		if firstIndex, found := seenKeys[cacheKey]; found {
+			return ErrDuplicateTransactionKey{
+				TableName:   tableName,
+				FirstIndex:  firstIndex,
+				SecondIndex: i,
+			}
+		}
+		seenKeys[cacheKey] = i
+	}
+
+	return nil
+}
+
+func (client *Client) transactWriteItemKey(ctx context.Context, tables map[string]*Table,
+	item *dynamodb.TransactWriteItem) (string, map[string]*dynamodb.AttributeValue, error) {
+
+	switch {
+	case item.ConditionCheck != nil:
+		return aws.StringValue(item.ConditionCheck.TableName), item.ConditionCheck.Key, nil
+	case item.Delete != nil:
+		return aws.StringValue(item.Delete.TableName), item.Delete.Key, nil
+	case item.Update != nil:
+		return aws.StringValue(item.Update.TableName), item.Update.Key, nil
+	case item.Put != nil:
+		// key schema is needed to determine the key of a put item
+		tableName := aws.StringValue(item.Put.TableName)
+		if table, found := tables[tableName]; found {
+			if err := table.loadIndexMetadata(ctx); err != nil {
+				return "", nil, err
+			}
+			return tableName, table.primaryKeyOf(item.Put.Item), nil
+		}
+		key, err := client.primaryKeyOf(ctx, tableName, item.Put.Item)
+		if err != nil {
+			return "", nil, err
+		}
+		return tableName, key, nil
+	}
+	return "", nil, fmt.Errorf("transaction operation has no action set")
+}
+
+// transactionTables holds the tables whose index metadata is used to validate transactions, so
+// that their metadata is only loaded once.
+type transactionTables struct {
+	mu     sync.Mutex
+	tables map[string]*Table
+}
+
+// primaryKeyOf extracts the primary key attributes of an item of the named table. The table's
+// index metadata is loaded once and reused by subsequent calls.
+func (client *Client) primaryKeyOf(ctx context.Context, tableName string,
+	item map[string]*dynamodb.AttributeValue) (map[string]*dynamodb.AttributeValue, error) {
+
+	cache := client.transactionTables
+	if cache == nil {
+		table := client.Table(tableName)
+		if err := table.loadIndexMetadata(ctx); err != nil {
+			return nil, err
+		}
+		return table.primaryKeyOf(item), nil
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	table, found := cache.tables[tableName]
+	if !found {
+		table = client.Table(tableName)
+		cache.tables[tableName] = table
+	}
+	if err := table.loadIndexMetadata(ctx); err != nil {
+		return nil, err
+	}
+	return table.primaryKeyOf(item), nil
+}
+
+func transactionCanceledError(err error, items []*dynamodb.TransactWriteItem) error {
+	canceledErr, isCanceled := err.(*dynamodb.TransactionCanceledException)
+	if !isCanceled {
+		return err
+	}
+
+	// reasons are reported for every operation, with "None" for operations that did not fail
+	e := ErrTransactionCanceled{}
+	for i, reason := range canceledErr.CancellationReasons {
+		code := aws.StringValue(reason.Code)
+		if code == "" || code == "None" {
+			continue
+		}
+		cancelReason := TransactionCancelReason{
+			Index:   i,
+			Code:    code,
+			Message: aws.StringValue(reason.Message),
+		}
+		if i < len(items) {
+			cancelReason.TableName, cancelReason.Operation = transactWriteItemTarget(items[i])
+		}
+		e.Reasons = append(e.Reasons, cancelReason)
+	}
+	return e
+}
+
+// transactWriteItemTarget returns the table name and operation of a transaction item.
+func transactWriteItemTarget(item *dynamodb.TransactWriteItem) (tableName, operation string) {
+	switch {
+	case item.ConditionCheck != nil:
+		return aws.StringValue(item.ConditionCheck.TableName), "ConditionCheck"
+	case item.Delete != nil:
+		return aws.StringValue(item.Delete.TableName), "Delete"
+	case item.Update != nil:
+		return aws.StringValue(item.Update.TableName), "Update"
+	case item.Put != nil:
+		return aws.StringValue(item.Put.TableName), "Put"
+	}
+	return "", ""
+}
+
+// itemSize approximates the size of an item as counted by DynamoDB.
+func itemSize(item map[string]*dynamodb.AttributeValue) int {
+	size := 0
+	for name, av := range item {
+		size += len(name) + attributeValueSize(av)
+	}
+	return size
+}
+
+func attributeValueSize(av *dynamodb.AttributeValue) int {
+	if av == nil {
+		return 0
+	}
+
+	size := len(aws.StringValue(av.S)) + len(aws.StringValue(av.N)) + len(av.B)
+	for _, s := range av.SS {
+		size += len(aws.StringValue(s))
+	}
+	for _, n := range av.NS {
+		size += len(aws.StringValue(n))
+	}
+	for _, b := range av.BS {
+		size += len(b)
+	}
+	for _, elem := range av.L {
+		size += 1 + attributeValueSize(elem)
+	}
+	if av.M != nil {
+		size += 3 + itemSize(av.M)
+	}
+	if av.BOOL != nil || av.NULL != nil {
+		size++
+	}
+	return size
+}
@@ -0,0 +1,138 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestTransactWriteValidation(t *testing.T) {
+	update := func(id string) *dynamodb.TransactWriteItem {
+		return &dynamodb.TransactWriteItem{Update: &dynamodb.Update{
+			TableName:        aws.String("items"),
+			Key:              stringItem(map[string]string{"id": id}),
+			UpdateExpression: aws.String("SET #n = :v"),
+		}}
+	}
+	deleteItem := func(id string) *dynamodb.TransactWriteItem {
+		return &dynamodb.TransactWriteItem{Delete: &dynamodb.Delete{
+			TableName: aws.String("items"),
+			Key:       stringItem(map[string]string{"id": id}),
+		}}
+	}
+	put := func(id string) *dynamodb.TransactWriteItem {
+		return &dynamodb.TransactWriteItem{Put: &dynamodb.Put{
+			TableName: aws.String("items"),
+			Item:      stringItem(map[string]string{"id": id, "status": "open"}),
+		}}
+	}
+
+	tooMany := []*dynamodb.TransactWriteItem{}
+	for i := 0; i <= maxTransactionItems; i++ {
+		tooMany = append(tooMany, update(fmt.Sprintf("item%d", i)))
+	}
+
+	cases := []struct {
+		name      string
+		items     []*dynamodb.TransactWriteItem
+		expectErr error
+	}{
+		{
+			name:  "distinct keys",
+			items: []*dynamodb.TransactWriteItem{update("a"), deleteItem("b"), put("c")},
+		},
+		{
+			name:      "update and delete of the same key",
+			items:     []*dynamodb.TransactWriteItem{update("a"), deleteItem("b"), deleteItem("a")},
+			expectErr: ErrDuplicateTransactionKey{TableName: "items", FirstIndex: 0, SecondIndex: 2},
+		},
+		{
+			name:      "put and update of the same key",
+			items:     []*dynamodb.TransactWriteItem{put("a"), update("a")},
+			expectErr: ErrDuplicateTransactionKey{TableName: "items", FirstIndex: 0, SecondIndex: 1},
+		},
+		{
+			name:      "too many items",
+			items:     tooMany,
+			expectErr: ErrTransactionTooLarge{Items: maxTransactionItems + 1},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := newFakeDynamoDB("items", "id", "")
+			client := NewClient(fake)
+
+			err := client.TransactWrite(context.Background(), tc.items)
+			if !reflect.DeepEqual(err, tc.expectErr) {
+				t.Fatalf("expected error %v, got %v", tc.expectErr, err)
+			}
+
+			// invalid transactions are not submitted
+			expectSubmitted := 0
+			if tc.expectErr == nil {
+				expectSubmitted = 1
+			}
+			if len(fake.transactWriteInputs) != expectSubmitted {
+				t.Errorf("expected %d transactions, got %d", expectSubmitted,
+					len(fake.transactWriteInputs))
+			}
+		})
+	}
+}
+
+func TestTransactionCancellationReasons(t *testing.T) {
+	fake := newFakeDynamoDB("items", "id", "")
+	fake.transactWriteErr = &dynamodb.TransactionCanceledException{
+		CancellationReasons: []*dynamodb.CancellationReason{
+			{Code: aws.String("None")},
+			{Code: aws.String("ConditionalCheckFailed"), Message: aws.String("condition failed")},
+			{},
+			{Code: aws.String("TransactionConflict"), Message: aws.String("conflict")},
+		},
+	}
+	client := NewClient(fake)
+
+	err := client.TransactWrite(context.Background(), []*dynamodb.TransactWriteItem{
+		{Put: &dynamodb.Put{
+			TableName: aws.String("items"),
+			Item:      stringItem(map[string]string{"id": "a"}),
+		}},
+		{Delete: &dynamodb.Delete{
+			TableName: aws.String("items"),
+			Key:       stringItem(map[string]string{"id": "b"}),
+		}},
+		{ConditionCheck: &dynamodb.ConditionCheck{
+			TableName: aws.String("items"),
+			Key:       stringItem(map[string]string{"id": "c"}),
+		}},
+		{Update: &dynamodb.Update{
+			TableName: aws.String("items"),
+			Key:       stringItem(map[string]string{"id": "d"}),
+		}},
+	})
+
+	expected := ErrTransactionCanceled{Reasons: []TransactionCancelReason{
+		{
+			Index:     1,
+			TableName: "items",
+			Operation: "Delete",
+			Code:      "ConditionalCheckFailed",
+			Message:   "condition failed",
+		},
+		{
+			Index:     3,
+			TableName: "items",
+			Operation: "Update",
+			Code:      "TransactionConflict",
+			Message:   "conflict",
+		},
+	}}
+	if !reflect.DeepEqual(err, expected) {
+		t.Errorf("expected error %v, got %v", expected, err)
+	}
+}
@@ -0,0 +1,69 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+// WithExpiredItemsExcluded sets whether items whose time to live has passed, but which DynamoDB
+// has not yet deleted, are excluded from reads on this table. The time to live attribute is
+// learned from the table's time to live description.
+func (table *Table) WithExpiredItemsExcluded(exclude bool) *Table {
+	table.excludeExpiredItems = exclude
+	return table
+}
+
+func (table *Table) loadTTLMetadata(ctx context.Context) error {
+	// learn time to live attribute if not already known
+	if table.ttlMetadataLoaded {
+		return nil
+	}
+
+	table.logger.Printf("fetching time to live metadata for table \"%s\"\n", table.Name)
+
+	describeInfo, err := table.baseClient.DescribeTimeToLiveWithContext(ctx,
+		&dynamodb.DescribeTimeToLiveInput{
+			TableName: aws.String(table.Name),
+		})
+	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
+		return err
+	}
+
+	table.ttlAttribute = ""
+	ttlDescription := describeInfo.TimeToLiveDescription
+	if ttlDescription != nil && ttlDescription.AttributeName != nil &&
+		aws.StringValue(ttlDescription.TimeToLiveStatus) == dynamodb.TimeToLiveStatusEnabled {
+		table.ttlAttribute = *ttlDescription.AttributeName
+		table.logger.Printf("found time to live attribute \"%s\" in table \"%s\"\n",
+			table.ttlAttribute, table.Name)
+	}
+	table.ttlMetadataLoaded = true
+
+	return nil
+}
+
+// unexpiredCondition returns a condition excluding expired items, if applicable.
+func (table *Table) unexpiredCondition(ctx context.Context) (*expression.ConditionBuilder, error) {
+	if !table.excludeExpiredItems {
+		return nil, nil
+	}
+
+	if err := table.loadTTLMetadata(ctx); err != nil {
+		return nil, err
+	}
+
+	if table.ttlAttribute == "" {
+		return nil, nil
+	}
+
+	ttlName := expression.Name(table.ttlAttribute)
+	condition := expression.Or(
+		expression.AttributeNotExists(ttlName),
+		ttlName.GreaterThan(expression.Value(time.Now().Unix())))
+	return &condition, nil
+}
@@ -0,0 +1,286 @@
+package dynamodbfriend
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+// Transaction collects write operations on items of one or more tables to be submitted as a
+// single DynamoDB transaction. All tables must be accessible through the client that began the
+// transaction.
+type Transaction struct {
+	client *Client
+	ops    []*transactionOp
+}
+
+// transactionOp is a single operation of a transaction, built into a transaction item on commit.
+type transactionOp struct {
+	table     *Table
+	operation AuditOperation
+	build     func(ctx context.Context) (*dynamodb.TransactWriteItem, error)
+
+	// key is the stored key of the item, set once the operation is built
+	key map[string]*dynamodb.AttributeValue
+
+	// committed is called once the transaction is committed, if set
+	committed func()
+}
+
+// NewTransaction begins a new transaction.
+func (client *Client) NewTransaction() *Transaction {
+	return &Transaction{client: client}
+}
+
+// itemPrecondition is a requirement on whether the item of a transaction operation exists.
+type itemPrecondition int
+
+const (
+	anyItem itemPrecondition = iota
+	newItem
+	existingItem
+)
+
+// withCondition returns the condition combined with the precondition on the table's partition
+// key, or the condition as is if there is no precondition. Index metadata must already be loaded.
+func (precondition itemPrecondition) withCondition(table *Table,
+	condition *expression.ConditionBuilder) *expression.ConditionBuilder {
+
+	partitionKey := expression.Name(table.allIndexes()[tablePrimaryIndexName].PartitionKey)
+	var itemCondition expression.ConditionBuilder
+	switch precondition {
+	case newItem:
+		itemCondition = expression.AttributeNotExists(partitionKey)
+	case existingItem:
+		itemCondition = expression.AttributeExists(partitionKey)
+	default:
+		return condition
+	}
+
+	if condition != nil {
+		itemCondition = condition.And(itemCondition)
+	}
+	return &itemCondition
+}
+
+// Put adds an operation putting an item into the table.
+func (tx *Transaction) Put(table *Table, item interface{}) *Transaction {
+	return tx.put(table, item, nil, anyItem)
+}
+
+// PutIf adds an operation putting an item into the table if the existing item meets the
+// condition. On tables with a version attribute, the stored version of the item must also match,
+// as with Table.Put, and the version of an item passed by pointer is updated on commit.
+func (tx *Transaction) PutIf(table *Table, item interface{},
+	condition expression.ConditionBuilder) *Transaction {
+
+	return tx.put(table, item, &condition, anyItem)
+}
+
+func (tx *Transaction) put(table *Table, item interface{}, condition *expression.ConditionBuilder,
+	precondition itemPrecondition) *Transaction {
+
+	op := &transactionOp{table: table, operation: AuditOperationPut}
+	op.build = func(ctx context.Context) (*dynamodb.TransactWriteItem, error) {
+		attrMap, err := table.encodeItem(item)
+		if err != nil {
+			return nil, err
+		}
+		if err := table.checkProtectedAttributes(attrMap); err != nil {
+			return nil, err
+		}
+
+		versionCondition, version, err := table.versionCondition(attrMap)
+		if err != nil {
+			return nil, err
+		}
+		if versionCondition != nil {
+			if condition != nil {
+				combined := condition.And(*versionCondition)
+				versionCondition = &combined
+			}
+			condition = versionCondition
+			op.committed = func() { table.updateItemVersion(item, version+1) }
+		}
+
+		if err := table.prepareItemForWrite(ctx, attrMap); err != nil {
+			return nil, err
+		}
+		op.key = table.primaryKeyOf(attrMap)
+		condition := precondition.withCondition(table, condition)
+
+		put := &dynamodb.Put{
+			TableName: aws.String(table.Name),
+			Item:      attrMap,
+		}
+		if condition != nil {
+			dbExpr, err := expression.NewBuilder().WithCondition(*condition).Build()
+			if err != nil {
+				return nil, err
+			}
+			put.ConditionExpression = dbExpr.Condition()
+			put.ExpressionAttributeNames = dbExpr.Names()
+			put.ExpressionAttributeValues = dbExpr.Values()
+		}
+		return &dynamodb.TransactWriteItem{Put: put}, nil
+	}
+	return tx.add(op)
+}
+
+// Update adds an operation applying the actions of an update expression to the item with the
+// specified key. Conditions of the update expression must be met by the existing item. On tables
+// with a version attribute, the version is incremented as with Table.Update.
+func (tx *Transaction) Update(table *Table, key interface{}, expr *UpdateExpr) *Transaction {
+	op := &transactionOp{table: table, operation: AuditOperationUpdate}
+	op.build = func(ctx context.Context) (*dynamodb.TransactWriteItem, error) {
+		if err := table.checkUpdateProtectedAttributes(expr); err != nil {
+			return nil, err
+		}
+		expr, err := table.versionedUpdate(expr)
+		if err != nil {
+			return nil, err
+		}
+		keyMap, err := table.marshalStoredKey(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		op.key = keyMap
+
+		dbExpr, err := expr.build(table.storedName)
+		if err != nil {
+			return nil, err
+		}
+		return &dynamodb.TransactWriteItem{Update: &dynamodb.Update{
+			TableName:                 aws.String(table.Name),
+			Key:                       keyMap,
+			UpdateExpression:          dbExpr.Update(),
+			ConditionExpression:       dbExpr.Condition(),
+			ExpressionAttributeNames:  dbExpr.Names(),
+			ExpressionAttributeValues: dbExpr.Values(),
+		}}, nil
+	}
+	return tx.add(op)
+}
+
+// Delete adds an operation removing the item with the specified key from the table.
+func (tx *Transaction) Delete(table *Table, key interface{}) *Transaction {
+	return tx.DeleteIf(table, key, NewDelete())
+}
+
+// DeleteIf adds an operation removing the item with the specified key from the table if the
+// existing item meets all conditions of the delete expression.
+func (tx *Transaction) DeleteIf(table *Table, key interface{}, expr *DeleteExpr) *Transaction {
+	return tx.deleteIf(table, key, expr, anyItem)
+}
+
+func (tx *Transaction) deleteIf(table *Table, key interface{}, expr *DeleteExpr,
+	precondition itemPrecondition) *Transaction {
+
+	op := &transactionOp{table: table, operation: AuditOperationDelete}
+	op.build = func(ctx context.Context) (*dynamodb.TransactWriteItem, error) {
+		keyMap, err := table.marshalStoredKey(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		op.key = keyMap
+
+		del := &dynamodb.Delete{
+			TableName: aws.String(table.Name),
+			Key:       keyMap,
+		}
+		condition, err := expr.condition(table.storedName)
+		if err != nil {
+			return nil, err
+		}
+		if condition := precondition.withCondition(table, condition); condition != nil {
+			dbExpr, err := expression.NewBuilder().WithCondition(*condition).Build()
+			if err != nil {
+				return nil, err
+			}
+			del.ConditionExpression = dbExpr.Condition()
+			del.ExpressionAttributeNames = dbExpr.Names()
+			del.ExpressionAttributeValues = dbExpr.Values()
+		}
+		return &dynamodb.TransactWriteItem{Delete: del}, nil
+	}
+	return tx.add(op)
+}
+
+// ConditionCheck adds an operation requiring the item with the specified key to meet the
+// condition, without modifying the item.
+func (tx *Transaction) ConditionCheck(table *Table, key interface{},
+	condition expression.ConditionBuilder) *Transaction {
+
+	op := &transactionOp{table: table}
+	op.build = func(ctx context.Context) (*dynamodb.TransactWriteItem, error) {
+		keyMap, err := table.marshalStoredKey(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		op.key = keyMap
+
+		dbExpr, err := expression.NewBuilder().WithCondition(condition).Build()
+		if err != nil {
+			return nil, err
+		}
+		return &dynamodb.TransactWriteItem{ConditionCheck: &dynamodb.ConditionCheck{
+			TableName:                 aws.String(table.Name),
+			Key:                       keyMap,
+			ConditionExpression:       dbExpr.Condition(),
+			ExpressionAttributeNames:  dbExpr.Names(),
+			ExpressionAttributeValues: dbExpr.Values(),
+		}}, nil
+	}
+	return tx.add(op)
+}
+
+func (tx *Transaction) add(op *transactionOp) *Transaction {
+	tx.ops = append(tx.ops, op)
+	return tx
+}
+
+// Commit submits all operations of the transaction as a single DynamoDB transaction. Operations
+// are validated as with Client.TransactWrite. If DynamoDB cancels the transaction,
+// ErrTransactionCanceled is returned with a reason for each operation that caused the
+// cancellation, including the operation's table and position in the transaction.
+func (tx *Transaction) Commit(ctx context.Context) error {
+	logger := tx.client.getLogger()
+
+	items := make([]*dynamodb.TransactWriteItem, len(tx.ops))
+	tables := make([]*Table, len(tx.ops))
+	for i, op := range tx.ops {
+		tables[i] = op.table
+		if err := op.table.loadIndexMetadata(ctx); err != nil {
+			return err
+		}
+
+		item, err := op.build(ctx)
+		if err != nil {
+			logger.Printf("error: %s\n", err.Error())
+			return err
+		}
+		items[i] = item
+	}
+
+	// metadata of the operations' tables is reused to validate the transaction
+	if err := tx.client.transactWrite(ctx, tx.client.Base, items, tables); err != nil {
+		return err
+	}
+
+	for _, op := range tx.ops {
+		op.table.invalidateItem(op.key)
+		if op.committed != nil {
+			op.committed()
+		}
+		if op.operation != "" {
+			op.table.audit(ctx, AuditEvent{
+				Operation: op.operation,
+				Key:       op.key,
+			})
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+func TestTransactionCommit(t *testing.T) {
+	canceled := &dynamodb.TransactionCanceledException{
+		CancellationReasons: []*dynamodb.CancellationReason{
+			{Code: aws.String("None")},
+			{Code: aws.String("ConditionalCheckFailed"), Message: aws.String("condition failed")},
+			{Code: aws.String("None")},
+		},
+	}
+
+	cases := []struct {
+		name   string
+		build  func(tx *Transaction, items, orders *Table) *Transaction
+		txErr  error
+		expect error
+
+		// expectOperations are the operations submitted, in order, if the transaction is valid
+		expectOperations []string
+		expectTables     []string
+	}{
+		{
+			name: "operations on multiple tables",
+			build: func(tx *Transaction, items, orders *Table) *Transaction {
+				return tx.Put(items, map[string]string{"id": "a"}).
+					Update(orders, map[string]string{"id": "a"}, NewUpdate().Set("status", "paid")).
+					ConditionCheck(items, map[string]string{"id": "b"},
+						expression.AttributeExists(expression.Name("id")))
+			},
+			expectOperations: []string{"Put", "Update", "ConditionCheck"},
+			expectTables:     []string{"items", "orders", "items"},
+		},
+		{
+			name: "operations on the same item",
+			build: func(tx *Transaction, items, orders *Table) *Transaction {
+				return tx.Put(items, map[string]string{"id": "a"}).
+					Delete(items, map[string]string{"id": "a"})
+			},
+			expect: ErrDuplicateTransactionKey{TableName: "items", FirstIndex: 0, SecondIndex: 1},
+		},
+		{
+			name: "canceled transaction",
+			build: func(tx *Transaction, items, orders *Table) *Transaction {
+				return tx.Put(items, map[string]string{"id": "a"}).
+					Delete(orders, map[string]string{"id": "a"}).
+					Delete(items, map[string]string{"id": "b"})
+			},
+			txErr: canceled,
+			expect: ErrTransactionCanceled{Reasons: []TransactionCancelReason{{
+				Index:     1,
+				TableName: "orders",
+				Operation: "Delete",
+				Code:      "ConditionalCheckFailed",
+				Message:   "condition failed",
+			}}},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := newFakeDynamoDB("items", "id", "")
+			fake.transactWriteErr = tc.txErr
+			client := NewClient(fake)
+			items, orders := client.Table("items"), client.Table("orders")
+
+			err := tc.build(client.NewTransaction(), items, orders).Commit(context.Background())
+			if !reflect.DeepEqual(err, tc.expect) {
+				t.Fatalf("expected error %v, got %v", tc.expect, err)
+			} else if tc.expectOperations == nil {
+				return
+			}
+
+			if len(fake.transactWriteInputs) != 1 {
+				t.Fatalf("expected 1 transaction, got %d", len(fake.transactWriteInputs))
+			}
+			operations, tables := []string{}, []string{}
+			for _, item := range fake.transactWriteInputs[0].TransactItems {
+				tableName, operation := transactWriteItemTarget(item)
+				operations = append(operations, operation)
+				tables = append(tables, tableName)
+			}
+			if !reflect.DeepEqual(operations, tc.expectOperations) {
+				t.Errorf("expected operations %v, got %v", tc.expectOperations, operations)
+			}
+			if !reflect.DeepEqual(tables, tc.expectTables) {
+				t.Errorf("expected tables %v, got %v", tc.expectTables, tables)
+			}
+		})
+	}
+}
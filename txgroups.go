@@ -0,0 +1,103 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// TransactionGroups collects write operations in caller-declared dependency groups, for workloads
+// of more than 100 operations that cannot be submitted as a single transaction. The operations of
+// each group are all-or-nothing, and groups are committed independently of each other, so that
+// operations which must succeed or fail together are declared in the same group.
+type TransactionGroups struct {
+	client *Client
+	names  []string
+	groups map[string]*Transaction
+}
+
+// TransactionGroupResult is the outcome of committing a single group of TransactionGroups.
+type TransactionGroupResult struct {
+	Name      string
+	Committed bool
+
+	// Err is the reason the group was not committed, such as ErrTransactionCanceled.
+	Err error
+}
+
+// ErrTransactionGroupsIncomplete is returned when not all groups of TransactionGroups were
+// committed. Failed holds the names of the groups that were not committed, in order.
+type ErrTransactionGroupsIncomplete struct {
+	Failed []string
+	Total  int
+}
+
+func (e ErrTransactionGroupsIncomplete) Error() string {
+	return fmt.Sprintf("%d of %d transaction groups not committed: %s", len(e.Failed), e.Total,
+		strings.Join(e.Failed, ", "))
+}
+
+// NewTransactionGroups begins a new set of transaction groups.
+func (client *Client) NewTransactionGroups() *TransactionGroups {
+	return &TransactionGroups{
+		client: client,
+		groups: map[string]*Transaction{},
+	}
+}
+
+// Group returns the transaction of the named group, to which operations of the group are added.
+// The group is created on first use, and groups are committed in the order they are created.
+func (tg *TransactionGroups) Group(name string) *Transaction {
+	tx, found := tg.groups[name]
+	if !found {
+		tx = tg.client.NewTransaction()
+		tg.groups[name] = tx
+		tg.names = append(tg.names, name)
+	}
+	return tx
+}
+
+// Commit submits each group as its own transaction, in the order the groups were created. All
+// groups are checked against the operation limit of a transaction before any group is submitted,
+// and ErrTransactionTooLarge is returned if any group exceeds it. A group that fails to commit
+// does not prevent later groups from being submitted, unless ctx is canceled. The result of each
+// group is returned in order, along with ErrTransactionGroupsIncomplete if any group was not
+// committed.
+func (tg *TransactionGroups) Commit(ctx context.Context) ([]TransactionGroupResult, error) {
+	logger := tg.client.getLogger()
+
+	for _, name := range tg.names {
+		if ops := len(tg.groups[name].ops); ops > maxTransactionItems {
+			err := ErrTransactionTooLarge{Items: ops}
+			logger.Printf("error: transaction group \"%s\": %s\n", name, err.Error())
+			return nil, err
+		}
+	}
+
+	results := make([]TransactionGroupResult, len(tg.names))
+	failed := []string{}
+	for i, name := range tg.names {
+		results[i].Name = name
+
+		err := ctx.Err()
+		if err == nil && len(tg.groups[name].ops) > 0 {
+			err = tg.groups[name].Commit(ctx)
+		}
+		if err != nil {
+			results[i].Err = err
+			failed = append(failed, name)
+			continue
+		}
+		results[i].Committed = true
+	}
+
+	logger.Printf("committed %d of %d transaction groups\n", len(tg.names)-len(failed),
+		len(tg.names))
+
+	if len(failed) > 0 {
+		err := ErrTransactionGroupsIncomplete{Failed: failed, Total: len(tg.names)}
+		logger.Printf("error: %s\n", err.Error())
+		return results, err
+	}
+	return results, nil
+}
@@ -0,0 +1,77 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"iter"
+)
+
+// QueryParserOf is a query parser that unmarshals items into values of type T, so that callers
+// need not pass interface{} destinations to Next.
+type QueryParserOf[T any] struct {
+	parser *QueryParser
+}
+
+// Query returns a new parser of the query's items as values of type T, as with Table.Query.
+func Query[T any](ctx context.Context, table *Table, expr *QueryExpr) (*QueryParserOf[T], error) {
+	parser, err := table.Query(ctx, expr)
+	if err != nil {
+		return nil, err
+	}
+	return Typed[T](parser), nil
+}
+
+// Typed returns a parser of the remaining items of an existing parser as values of type T, such as
+// for a parser returned by Table.QueryRaw.
+func Typed[T any](parser *QueryParser) *QueryParserOf[T] {
+	return &QueryParserOf[T]{parser: parser}
+}
+
+// Parser returns the underlying parser, such as to read its cursor or query statistics.
+func (p *QueryParserOf[T]) Parser() *QueryParser {
+	return p.parser
+}
+
+// Next returns the next item of the query. ErrParsingComplete is returned once no items remain.
+func (p *QueryParserOf[T]) Next(ctx context.Context) (T, error) {
+	var item T
+	if err := p.parser.Next(ctx, &item); err != nil {
+		var zero T
+		return zero, err
+	}
+	return item, nil
+}
+
+// All returns all remaining items of the query. ErrMemoryBudgetExceeded is returned if the items
+// exceed the table's result memory budget.
+func (p *QueryParserOf[T]) All(ctx context.Context) ([]T, error) {
+	items := []T{}
+	if err := p.parser.All(ctx, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// Items returns the remaining items of the query as a sequence that may be ranged over. Iteration
+// ends after the last item or after the first error is yielded.
+func (p *QueryParserOf[T]) Items(ctx context.Context) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for {
+			item, err := p.Next(ctx)
+			if _, parsingComplete := err.(ErrParsingComplete); parsingComplete {
+				return
+			} else if err != nil {
+				yield(item, err)
+				return
+			}
+
+			if !yield(item, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Close releases any buffered items held by the parser. Close always returns nil.
+func (p *QueryParserOf[T]) Close() error {
+	return p.parser.Close()
+}
@@ -0,0 +1,49 @@
+package dynamodbfriend
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"time"
+)
+
+// crockfordBase32 is the alphabet used to encode ULIDs, which sorts in the same order as the
+// encoded values.
+const crockfordBase32 = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewULID returns a new ULID for the current time. ULIDs are 26-character strings that sort
+// lexically by creation time to the millisecond, which makes them suitable as sort key values.
+// The ordering of ULIDs created within the same millisecond is random. An error is returned if
+// random bits cannot be read.
+func NewULID() (string, error) {
+	return NewULIDAt(time.Now())
+}
+
+// NewULIDAt returns a new ULID for the specified time. This may also be used to create bounds for
+// sort key conditions on ULIDs, such as to query items created within a time range. An error is
+// returned if random bits cannot be read.
+func NewULIDAt(t time.Time) (string, error) {
+	var id [16]byte
+
+	// first 48 bits are the unix time in milliseconds, remaining 80 bits are random
+	var timestamp [8]byte
+	binary.BigEndian.PutUint64(timestamp[:], uint64(t.UnixNano()/int64(time.Millisecond)))
+	copy(id[:6], timestamp[2:])
+	if _, err := rand.Read(id[6:]); err != nil {
+		return "", err
+	}
+
+	return encodeULID(id), nil
+}
+
+// encodeULID encodes the 128-bit id as 26 base32 characters, with 2 leading zero bits.
+func encodeULID(id [16]byte) string {
+	encoded := make([]byte, 26)
+	hi := binary.BigEndian.Uint64(id[:8])
+	lo := binary.BigEndian.Uint64(id[8:])
+	for i := 25; i >= 0; i-- {
+		encoded[i] = crockfordBase32[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(encoded)
+}
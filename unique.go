@@ -0,0 +1,307 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+const (
+	uniqueConstraintPrefix    = "UNIQUE#"
+	uniqueConstraintOwnerAttr = "uniqueOwner"
+)
+
+// ErrUniqueConstraintViolated is returned when a write would give an item the same value for a
+// unique attribute as another item in the table. The value is redacted according to the table's
+// log redaction policy.
+type ErrUniqueConstraintViolated struct {
+	TableName string
+	Attribute string
+	Value     string
+}
+
+func (e ErrUniqueConstraintViolated) Error() string {
+	return fmt.Sprintf("value \"%s\" of unique attribute \"%s\" already used in table \"%s\"",
+		e.Value, e.Attribute, e.TableName)
+}
+
+// PutWithUniqueConstraint puts an item into the table while guaranteeing that no other item has
+// the same value for uniqueAttr. The item and a constraint record keyed by the unique value are
+// written in a single transaction. ErrUniqueConstraintViolated is returned if the value is already
+// used by another item. Items put with this method should be deleted with
+// DeleteWithUniqueConstraint so that the constraint record is also removed. When an item's unique
+// value changes, the constraint record for the previous value is removed. Constraint records are
+// keyed by strings, so all primary key attributes of the table must be string attributes. On
+// tables with a version attribute, the item is versioned as with Put.
+func (table *Table) PutWithUniqueConstraint(ctx context.Context, item interface{},
+	uniqueAttr string) error {
+
+	attrMap, err := table.encodeItem(item)
+	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
+		return err
+	}
+
+	if err := table.checkProtectedAttributes(attrMap); err != nil {
+		return err
+	}
+
+	uniqueValue, err := uniqueAttributeValue(attrMap, uniqueAttr)
+	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
+		return err
+	}
+
+	versionCondition, version, err := table.versionCondition(attrMap)
+	if err != nil {
+		return err
+	}
+
+	if err := table.prepareItemForWrite(ctx, attrMap); err != nil {
+		return err
+	}
+	if err := table.loadIndexMetadata(ctx); err != nil {
+		return err
+	}
+
+	key := table.primaryKeyOf(attrMap)
+	owner := itemCacheKey(table.Name, key)
+
+	// read previous unique value of the item, if any, to release its constraint record
+	previous, err := table.getStoredItem(ctx, key, true)
+	if err != nil {
+		return err
+	}
+
+	constraintRecord, err := table.uniqueConstraintRecord(ctx, uniqueAttr, uniqueValue, owner)
+	if err != nil {
+		return err
+	}
+
+	// constraint record may only be written if unused or already owned by this item
+	partitionName := expression.Name(table.allIndexes()[tablePrimaryIndexName].PartitionKey)
+	ownerName := expression.Name(uniqueConstraintOwnerAttr)
+	dbExpr, err := expression.NewBuilder().WithCondition(expression.Or(
+		expression.AttributeNotExists(partitionName),
+		ownerName.Equal(expression.Value(owner)))).Build()
+	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
+		return err
+	}
+
+	itemPut := &dynamodb.Put{
+		TableName: aws.String(table.Name),
+		Item:      attrMap,
+	}
+	if versionCondition != nil {
+		versionExpr, err := expression.NewBuilder().WithCondition(*versionCondition).Build()
+		if err != nil {
+			table.logger.Printf("error: %s\n", err.Error())
+			return err
+		}
+		itemPut.ConditionExpression = versionExpr.Condition()
+		itemPut.ExpressionAttributeNames = versionExpr.Names()
+		itemPut.ExpressionAttributeValues = versionExpr.Values()
+	}
+
+	transactItems := []*dynamodb.TransactWriteItem{
+		{Put: itemPut},
+		{Put: &dynamodb.Put{
+			TableName:                 aws.String(table.Name),
+			Item:                      constraintRecord,
+			ConditionExpression:       dbExpr.Condition(),
+			ExpressionAttributeNames:  dbExpr.Names(),
+			ExpressionAttributeValues: dbExpr.Values(),
+		}},
+	}
+
+	// the previous item is stored with aliased attribute names
+	previousValue, err := uniqueAttributeValue(previous, table.storedName(uniqueAttr))
+	if err == nil && previousValue != uniqueValue {
+		deleteItem, err := table.uniqueConstraintDelete(ctx, uniqueAttr, previousValue, owner)
+		if err != nil {
+			return err
+		}
+		transactItems = append(transactItems, deleteItem)
+	}
+
+	err = table.transactWrite(ctx, transactItems)
+	if canceledErr, isCanceled := err.(ErrTransactionCanceled); isCanceled {
+		for _, reason := range canceledErr.Reasons {
+			if reason.Index == 0 && reason.Code == "ConditionalCheckFailed" {
+				err = ErrVersionConflict{TableName: table.Name, Version: version}
+				table.logger.Printf("error: %s\n", err.Error())
+			}
+			if reason.Index == 1 && reason.Code == "ConditionalCheckFailed" {
+				// the value is redacted, since the error is likely to be logged by the caller
+				err = ErrUniqueConstraintViolated{
+					TableName: table.Name,
+					Attribute: uniqueAttr,
+					Value:     table.redactValue(uniqueAttr, uniqueValue),
+				}
+				table.logger.Printf("error: %s\n", err.Error())
+			}
+		}
+	}
+	if err == nil {
+		table.invalidateItem(key)
+		if versionCondition != nil {
+			table.updateItemVersion(item, version+1)
+		}
+	}
+
+	table.audit(ctx, AuditEvent{
+		Operation: AuditOperationPut,
+		Key:       key,
+		OldImage:  previous,
+		NewImage:  attrMap,
+		Err:       err,
+	})
+
+	return err
+}
+
+// DeleteWithUniqueConstraint deletes an item put with PutWithUniqueConstraint, along with its
+// constraint record for uniqueAttr. The key may be a struct or map containing the table's primary
+// key attributes. Deleting an item that does not exist succeeds without effect.
+func (table *Table) DeleteWithUniqueConstraint(ctx context.Context, key interface{},
+	uniqueAttr string) error {
+
+	keyMap, err := table.marshalStoredKey(ctx, key)
+	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
+		return err
+	}
+
+	previous, err := table.getStoredItem(ctx, keyMap, true)
+	if err != nil {
+		return err
+	} else if previous == nil {
+		return nil
+	}
+
+	transactItems := []*dynamodb.TransactWriteItem{
+		{Delete: &dynamodb.Delete{
+			TableName: aws.String(table.Name),
+			Key:       keyMap,
+		}},
+	}
+
+	uniqueValue, err := uniqueAttributeValue(previous, table.storedName(uniqueAttr))
+	if err == nil {
+		deleteItem, err := table.uniqueConstraintDelete(ctx, uniqueAttr, uniqueValue,
+			itemCacheKey(table.Name, keyMap))
+		if err != nil {
+			return err
+		}
+		transactItems = append(transactItems, deleteItem)
+	}
+
+	err = table.transactWrite(ctx, transactItems)
+	if err == nil {
+		table.invalidateItem(keyMap)
+	}
+
+	table.audit(ctx, AuditEvent{
+		Operation: AuditOperationDelete,
+		Key:       keyMap,
+		OldImage:  previous,
+		Err:       err,
+	})
+
+	return err
+}
+
+func uniqueAttributeValue(item map[string]*dynamodb.AttributeValue, uniqueAttr string) (string, error) {
+	av, found := item[uniqueAttr]
+	switch {
+	case !found:
+		return "", fmt.Errorf("unique attribute \"%s\" not found in item", uniqueAttr)
+	case av.S != nil:
+		return *av.S, nil
+	case av.N != nil:
+		return *av.N, nil
+	}
+	return "", fmt.Errorf("unique attribute \"%s\" must be a string or number", uniqueAttr)
+}
+
+// uniqueConstraintKey returns the key of the constraint record for a unique value. Index metadata
+// must already be loaded.
+func (table *Table) uniqueConstraintKey(ctx context.Context, uniqueAttr,
+	uniqueValue string) (map[string]*dynamodb.AttributeValue, error) {
+
+	return table.reservedItemKey(ctx, uniqueConstraintPrefix+uniqueAttr+"#"+uniqueValue)
+}
+
+// reservedItemKey returns the key of a package-managed record, such as a constraint record or a
+// sequence counter, with all primary key attributes set to the id. Index metadata must already be
+// loaded.
+func (table *Table) reservedItemKey(ctx context.Context,
+	id string) (map[string]*dynamodb.AttributeValue, error) {
+
+	key := map[string]*dynamodb.AttributeValue{}
+	for _, keyName := range table.allIndexes()[tablePrimaryIndexName].getKeys() {
+		key[keyName] = &dynamodb.AttributeValue{S: aws.String(id)}
+	}
+
+	if err := table.applyTenantPrefix(ctx, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func (table *Table) uniqueConstraintRecord(ctx context.Context, uniqueAttr, uniqueValue,
+	owner string) (map[string]*dynamodb.AttributeValue, error) {
+
+	record, err := table.uniqueConstraintKey(ctx, uniqueAttr, uniqueValue)
+	if err != nil {
+		return nil, err
+	}
+	record[uniqueConstraintOwnerAttr] = &dynamodb.AttributeValue{S: aws.String(owner)}
+	return record, nil
+}
+
+func (table *Table) uniqueConstraintDelete(ctx context.Context, uniqueAttr, uniqueValue,
+	owner string) (*dynamodb.TransactWriteItem, error) {
+
+	key, err := table.uniqueConstraintKey(ctx, uniqueAttr, uniqueValue)
+	if err != nil {
+		return nil, err
+	}
+
+	// only release constraint records owned by the item
+	dbExpr, err := expression.NewBuilder().WithCondition(
+		expression.Name(uniqueConstraintOwnerAttr).Equal(expression.Value(owner))).Build()
+	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
+		return nil, err
+	}
+
+	return &dynamodb.TransactWriteItem{Delete: &dynamodb.Delete{
+		TableName:                 aws.String(table.Name),
+		Key:                       key,
+		ConditionExpression:       dbExpr.Condition(),
+		ExpressionAttributeNames:  dbExpr.Names(),
+		ExpressionAttributeValues: dbExpr.Values(),
+	}}, nil
+}
+
+// transactWrite submits a transaction through the client that instantiated the table, using the
+// table's DynamoDB client so that the table's timeouts and request scheduling apply.
+func (table *Table) transactWrite(ctx context.Context, items []*dynamodb.TransactWriteItem) error {
+	start := time.Now()
+	err := table.client.transactWrite(ctx, table.baseClient, items, []*Table{table})
+
+	table.emitStats(ctx, OperationStats{
+		Operation: "TransactWriteItems",
+		Latency:   time.Since(start),
+		Items:     len(items),
+		Err:       err,
+	})
+
+	return err
+}
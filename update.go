@@ -0,0 +1,125 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Update applies the actions of an update expression to the item with the specified key. The key
+// may be a struct or map containing the table's primary key attributes. The item is created if it
+// does not exist, unless prevented by a condition of the update expression. On tables with
+// derived or sparse attributes, the item is read to compute the attributes as changed by the
+// update, and the update fails with a conditional check error if the item changes concurrently.
+func (table *Table) Update(ctx context.Context, key interface{}, expr *UpdateExpr) error {
+	// fall back to table logger if no logger is set on the expression
+	if !expr.loggerSpecified {
+		expr.logger = table.logger
+	}
+
+	keyMap, err := table.marshalStoredKey(ctx, key)
+	if err != nil {
+		expr.logger.Printf("error: %s\n", err.Error())
+		return err
+	}
+
+	if err := table.checkUpdateProtectedAttributes(expr); err != nil {
+		return err
+	}
+
+	// version conflicts are only distinguishable when the version is the only condition
+	versionChecked := table.versionAttribute != "" && expr.expectedVersionSpecified &&
+		expr.conditions.empty()
+	expectedVersion := expr.expectedVersion
+
+	if len(table.derivedAttributes) > 0 || len(table.sparseAttributes) > 0 {
+		expr, err = table.derivedUpdate(ctx, keyMap, expr)
+		if err != nil {
+			return err
+		}
+	}
+
+	expr, err = table.versionedUpdate(expr)
+	if err != nil {
+		return err
+	}
+
+	dbExpr, err := expr.build(table.storedName)
+	if err != nil {
+		expr.logger.Printf("error: %s\n", err.Error())
+		return err
+	}
+
+	err = table.validateExpressions(dbExpr.Names(),
+		expressionPart{kind: "update", expression: dbExpr.Update()},
+		expressionPart{kind: "condition", expression: dbExpr.Condition()})
+	if err != nil {
+		return err
+	}
+
+	updateInput := &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(table.Name),
+		Key:                       keyMap,
+		UpdateExpression:          dbExpr.Update(),
+		ConditionExpression:       dbExpr.Condition(),
+		ExpressionAttributeNames:  dbExpr.Names(),
+		ExpressionAttributeValues: dbExpr.Values(),
+		ReturnConsumedCapacity:    table.returnConsumedCapacity(),
+	}
+
+	// request new image for auditing, if applicable
+	if table.auditor != nil {
+		updateInput.ReturnValues = aws.String(dynamodb.ReturnValueAllNew)
+	}
+
+	start := time.Now()
+	updateOutput, err := table.baseClient.UpdateItemWithContext(ctx, updateInput)
+
+	stats := OperationStats{
+		Operation: "UpdateItem",
+		Latency:   time.Since(start),
+		Items:     1,
+		Err:       err,
+	}
+	if updateOutput != nil {
+		stats.ConsumedCapacity = consumedCapacityUnits(updateOutput.ConsumedCapacity)
+	}
+	table.emitStats(ctx, stats)
+
+	if err != nil {
+		expr.logger.Printf("error: %s\n", err.Error())
+		if versionChecked {
+			err = table.versionConflict(err, expectedVersion)
+		}
+	} else {
+		table.invalidateItem(keyMap)
+	}
+
+	event := AuditEvent{
+		Operation: AuditOperationUpdate,
+		Key:       keyMap,
+		Err:       err,
+	}
+	if updateOutput != nil {
+		event.NewImage = updateOutput.Attributes
+	}
+	table.audit(ctx, event)
+
+	return err
+}
+
+// checkUpdateProtectedAttributes refuses update expressions setting protected attributes.
+func (table *Table) checkUpdateProtectedAttributes(expr *UpdateExpr) error {
+	for _, action := range expr.actions {
+		if action.op == removeOp {
+			continue
+		}
+		err := table.checkProtectedAttributes(map[string]*dynamodb.AttributeValue{action.name: {}})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
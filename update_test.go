@@ -0,0 +1,107 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestUpdateDerivedAndSparseAttributes(t *testing.T) {
+	cases := []struct {
+		name   string
+		update *UpdateExpr
+
+		// expectSet are the values of attributes expected to be set by the update
+		expectSet    map[string]string
+		expectRemove []string
+		expectErr    bool
+	}{
+		{
+			name:         "update of source attribute",
+			update:       NewUpdate().Set("status", "closed"),
+			expectSet:    map[string]string{"status": "closed", "statusKey": "closed#1"},
+			expectRemove: []string{"openKey"},
+		},
+		{
+			name:      "update of other attribute",
+			update:    NewUpdate().Set("title", "t"),
+			expectSet: map[string]string{"title": "t"},
+		},
+		{
+			name:      "update overridden by derived attribute",
+			update:    NewUpdate().Set("statusKey", "x").Set("ts", "2"),
+			expectSet: map[string]string{"ts": "2", "statusKey": "open#2"},
+		},
+		{
+			name:      "update of nested attribute",
+			update:    NewUpdate().Set("meta.status", "closed"),
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := newFakeDynamoDB("items", "id", "")
+			fake.putItems(stringItem(map[string]string{
+				"id": "a", "status": "open", "ts": "1", "statusKey": "open#1", "openKey": "1",
+			}))
+			table := newFakeTable(fake).
+				WithDerivedAttribute("statusKey",
+					func(item map[string]*dynamodb.AttributeValue) (*dynamodb.AttributeValue, error) {
+						status, ts := aws.StringValue(item["status"].S), aws.StringValue(item["ts"].S)
+						return &dynamodb.AttributeValue{S: aws.String(status + "#" + ts)}, nil
+					}).
+				WithSparseAttribute("openKey", func(item map[string]*dynamodb.AttributeValue) bool {
+					return aws.StringValue(item["status"].S) == "open"
+				})
+
+			err := table.Update(context.Background(), map[string]string{"id": "a"}, tc.update)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("expected error")
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if len(fake.updateInputs) != 1 {
+				t.Fatalf("expected 1 update, got %d", len(fake.updateInputs))
+			}
+			input := fake.updateInputs[0]
+			if input.ConditionExpression == nil {
+				t.Errorf("expected update to be conditioned on the item being unchanged")
+			}
+
+			placeholders := map[string]string{}
+			for placeholder, name := range input.ExpressionAttributeNames {
+				placeholders[*name] = placeholder
+			}
+			update := *input.UpdateExpression
+			for name, value := range tc.expectSet {
+				found := false
+				for placeholder, av := range input.ExpressionAttributeValues {
+					if strings.Contains(update, placeholders[name]+" = "+placeholder) &&
+						aws.StringValue(av.S) == value {
+						found = true
+					}
+				}
+				if !found {
+					t.Errorf("expected \"%s\" to be set to \"%s\" in %s", name, value, update)
+				}
+			}
+			if setCount := strings.Count(update, " = "); setCount != len(tc.expectSet) {
+				t.Errorf("expected %d set actions, got %d in %s", len(tc.expectSet), setCount, update)
+			}
+			for _, name := range tc.expectRemove {
+				placeholder, found := placeholders[name]
+				if !found || !strings.Contains(update, "REMOVE "+placeholder) {
+					t.Errorf("expected \"%s\" to be removed in %s", name, update)
+				}
+			}
+		})
+	}
+}
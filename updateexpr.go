@@ -0,0 +1,157 @@
+package dynamodbfriend
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+type updateOp int
+
+const (
+	setOp updateOp = iota
+	setIfNotExistsOp
+	removeOp
+	addOp
+	deleteOp
+)
+
+func (op updateOp) String() string {
+	switch op {
+	case setOp:
+		return "set"
+	case setIfNotExistsOp:
+		return "set if not exists"
+	case removeOp:
+		return "remove"
+	case addOp:
+		return "add"
+	case deleteOp:
+		return "delete"
+	}
+	return "unknown"
+}
+
+type updateAction struct {
+	op    updateOp
+	name  string
+	value interface{}
+}
+
+// UpdateExpr is an expression of update actions applied to a single item with Table.Update.
+type UpdateExpr struct {
+	actions []updateAction
+
+	conditions writeConditions
+
+	expectedVersionSpecified bool
+	expectedVersion          int64
+
+	loggerSpecified bool
+	logger          Logger
+}
+
+// NewUpdate begins a new update expression.
+func NewUpdate() *UpdateExpr {
+	return &UpdateExpr{
+		actions: []updateAction{},
+		logger:  nullLogger{},
+	}
+}
+
+// Set sets the value of an attribute, replacing any existing value.
+func (expr *UpdateExpr) Set(name string, val interface{}) *UpdateExpr {
+	return expr.addAction(setOp, name, val)
+}
+
+// SetIfNotExists sets the value of an attribute only if the attribute does not already exist.
+func (expr *UpdateExpr) SetIfNotExists(name string, val interface{}) *UpdateExpr {
+	return expr.addAction(setIfNotExistsOp, name, val)
+}
+
+// Remove removes an attribute from the item.
+func (expr *UpdateExpr) Remove(name string) *UpdateExpr {
+	return expr.addAction(removeOp, name, nil)
+}
+
+// Add adds val to a number attribute, or adds the elements of val to a set attribute. The
+// attribute is created if it does not exist.
+func (expr *UpdateExpr) Add(name string, val interface{}) *UpdateExpr {
+	return expr.addAction(addOp, name, val)
+}
+
+// Delete removes the elements of val from a set attribute.
+func (expr *UpdateExpr) Delete(name string, val interface{}) *UpdateExpr {
+	return expr.addAction(deleteOp, name, val)
+}
+
+// UpdateExprKey is a partially-formed condition of an update expression.
+//
+// To add the condition to the update expression, the key part must be followed by a conditional.
+type UpdateExprKey = ConditionKey[*UpdateExpr]
+
+// Where begins a condition on the named attribute that must be met by the existing item for the
+// update to be made. Multiple conditions must all be met.
+func (expr *UpdateExpr) Where(key string) *UpdateExprKey {
+	return newWriteConditionKey(expr, key, &expr.conditions, &expr.logger)
+}
+
+// WithCondition adds a condition that must be met by the existing item for the update to be made.
+// Attribute names in the condition are used as stored in the table, and are not translated by
+// attribute aliases.
+func (expr *UpdateExpr) WithCondition(condition expression.ConditionBuilder) *UpdateExpr {
+	expr.conditions.raw = append(expr.conditions.raw, condition)
+	return expr
+}
+
+// WithLogger sets a logger used to print logs about update operations performed using this
+// expression. If no logger is set, the logger of the table being updated is used.
+func (expr *UpdateExpr) WithLogger(logger Logger) *UpdateExpr {
+	expr.loggerSpecified = true
+	expr.logger = logger
+	return expr
+}
+
+func (expr *UpdateExpr) addAction(op updateOp, name string, val interface{}) *UpdateExpr {
+	expr.actions = append(expr.actions, updateAction{op: op, name: name, value: val})
+	expr.logger.Printf("update expression will %s attribute \"%s\"\n", op, name)
+	return expr
+}
+
+// build constructs the DynamoDB expression for the update, with attribute names mapped by
+// storedName.
+func (expr *UpdateExpr) build(storedName func(string) string) (expression.Expression, error) {
+	if len(expr.actions) == 0 {
+		return expression.Expression{}, fmt.Errorf("update expression has no actions")
+	}
+
+	var update expression.UpdateBuilder
+	for _, action := range expr.actions {
+		name := expression.Name(storedName(action.name))
+		value := expression.Value(action.value)
+		switch action.op {
+		case setOp:
+			update = update.Set(name, value)
+		case setIfNotExistsOp:
+			update = update.Set(name, expression.IfNotExists(name, value))
+		case removeOp:
+			update = update.Remove(name)
+		case addOp:
+			update = update.Add(name, value)
+		case deleteOp:
+			update = update.Delete(name, value)
+		}
+	}
+
+	dbExprBuilder := expression.NewBuilder().WithUpdate(update)
+
+	condition, err := expr.conditions.condition(storedName)
+	if err != nil {
+		return expression.Expression{}, err
+	}
+	if condition != nil {
+		dbExprBuilder = dbExprBuilder.WithCondition(*condition)
+	}
+
+	return dbExprBuilder.Build()
+}
@@ -0,0 +1,207 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+// ErrVersionConflict is returned by a versioned write when the stored version of the item does not
+// match the version being written over, such as when the item was modified concurrently.
+type ErrVersionConflict struct {
+	TableName string
+	Version   int64
+}
+
+func (e ErrVersionConflict) Error() string {
+	return fmt.Sprintf("version conflict on item in table \"%s\": stored version is not %d",
+		e.TableName, e.Version)
+}
+
+// WithVersionAttribute enables optimistic locking on the table using the named numeric attribute.
+// Put and PutIf require the stored version of the item to equal the version of the item being
+// put, or the item to not exist if the version is zero or unset, and write the item with its
+// version incremented. If the item is passed by pointer, its version is updated after a successful
+// put. Update increments the version, and requires the stored version to match the version given
+// with UpdateExpr.ExpectVersion, if any. ErrVersionConflict is returned on a mismatch. Transaction
+// puts and updates and PutWithUniqueConstraint are versioned the same way, while BatchPut and
+// maintaining the table as a view return ErrVersionedTable.
+func (table *Table) WithVersionAttribute(attribute string) *Table {
+	table.versionAttribute = attribute
+	return table
+}
+
+// ErrVersionedTable is returned by writes that cannot check the version of the items written,
+// such as batch writes, on tables with a version attribute.
+type ErrVersionedTable struct {
+	TableName string
+	Operation string
+}
+
+func (e ErrVersionedTable) Error() string {
+	return fmt.Sprintf("%s is not supported on table \"%s\" with a version attribute",
+		e.Operation, e.TableName)
+}
+
+// requireUnversioned returns ErrVersionedTable if the table has a version attribute.
+func (table *Table) requireUnversioned(operation string) error {
+	if table.versionAttribute == "" {
+		return nil
+	}
+	err := ErrVersionedTable{TableName: table.Name, Operation: operation}
+	table.logger.Printf("error: %s\n", err.Error())
+	return err
+}
+
+// ExpectVersion requires the stored version of the item to equal version for the update to be
+// applied, on tables with a version attribute. A version of zero requires the item to have no
+// version.
+func (expr *UpdateExpr) ExpectVersion(version int64) *UpdateExpr {
+	expr.expectedVersionSpecified = true
+	expr.expectedVersion = version
+	return expr
+}
+
+// versionCondition returns the condition on the stored version of an item to be put and
+// increments the version of the item. A nil condition is returned if the table is not versioned.
+func (table *Table) versionCondition(
+	attrMap map[string]*dynamodb.AttributeValue) (*expression.ConditionBuilder, int64, error) {
+
+	if table.versionAttribute == "" {
+		return nil, 0, nil
+	}
+
+	version, err := table.incrementItemVersion(attrMap)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	condition := table.expectedVersionCondition(version)
+	return &condition, version, nil
+}
+
+// incrementItemVersion increments the version of an item to be put on a versioned table, and
+// returns the version the item had. Items of unversioned tables are left unmodified.
+func (table *Table) incrementItemVersion(attrMap map[string]*dynamodb.AttributeValue) (int64, error) {
+	if table.versionAttribute == "" {
+		return 0, nil
+	}
+
+	version, err := table.itemVersion(attrMap)
+	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
+		return 0, err
+	}
+	attrMap[table.versionAttribute] = &dynamodb.AttributeValue{
+		N: aws.String(strconv.FormatInt(version+1, 10)),
+	}
+	return version, nil
+}
+
+// itemVersion returns the version of an item, or zero if the item has no version.
+func (table *Table) itemVersion(attrMap map[string]*dynamodb.AttributeValue) (int64, error) {
+	av, found := attrMap[table.versionAttribute]
+	if !found || aws.BoolValue(av.NULL) {
+		return 0, nil
+	}
+	if av.N == nil {
+		return 0, fmt.Errorf("version attribute \"%s\" of table \"%s\" must be a number",
+			table.versionAttribute, table.Name)
+	}
+	return strconv.ParseInt(*av.N, 10, 64)
+}
+
+func (table *Table) expectedVersionCondition(version int64) expression.ConditionBuilder {
+	name := expression.Name(table.storedName(table.versionAttribute))
+	if version == 0 {
+		return expression.AttributeNotExists(name)
+	}
+	return name.Equal(expression.Value(version))
+}
+
+// versionedUpdate returns a copy of the update expression that increments the version of the item
+// and requires the expected version, if any. The expression is returned as is if the table is not
+// versioned.
+func (table *Table) versionedUpdate(expr *UpdateExpr) (*UpdateExpr, error) {
+	if table.versionAttribute == "" {
+		return expr, nil
+	}
+
+	for _, action := range expr.actions {
+		if action.name == table.versionAttribute {
+			err := fmt.Errorf("version attribute \"%s\" of table \"%s\" may not be updated directly",
+				table.versionAttribute, table.Name)
+			expr.logger.Printf("error: %s\n", err.Error())
+			return nil, err
+		}
+	}
+
+	versioned := *expr
+	versioned.actions = append([]updateAction{}, expr.actions...)
+	versioned.addAction(addOp, table.versionAttribute, 1)
+
+	if expr.expectedVersionSpecified {
+		versioned.conditions = expr.conditions.withRaw(
+			table.expectedVersionCondition(expr.expectedVersion))
+	}
+	return &versioned, nil
+}
+
+// versionConflict returns ErrVersionConflict if err is a failed condition of a versioned write.
+func (table *Table) versionConflict(err error, version int64) error {
+	if awsErr, isAWSErr := err.(awserr.Error); isAWSErr &&
+		awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+
+		err = ErrVersionConflict{TableName: table.Name, Version: version}
+		table.logger.Printf("error: %s\n", err.Error())
+	}
+	return err
+}
+
+// updateItemVersion sets the version of an item passed by pointer to its written version.
+func (table *Table) updateItemVersion(item interface{}, version int64) {
+	if reflect.ValueOf(item).Kind() != reflect.Ptr {
+		return
+	}
+
+	versionMap := map[string]*dynamodb.AttributeValue{
+		table.versionAttribute: {N: aws.String(strconv.FormatInt(version, 10))},
+	}
+	if err := dynamodbattribute.UnmarshalMap(versionMap, item); err != nil {
+		table.logger.Printf("warning: failed to update version of item: %s\n", err.Error())
+	}
+}
+
+// putVersioned puts an item into a versioned table, requiring the stored version of the item to
+// match in addition to the condition, if any. ErrVersionConflict is returned if the version does
+// not match and there is no other condition.
+func (table *Table) putVersioned(ctx context.Context, item interface{},
+	attrMap map[string]*dynamodb.AttributeValue, condition *expression.ConditionBuilder) error {
+
+	versionCondition, version, err := table.versionCondition(attrMap)
+	if err != nil {
+		return err
+	}
+	if condition != nil {
+		combined := condition.And(*versionCondition)
+		versionCondition = &combined
+	}
+
+	err = table.putItem(ctx, attrMap, versionCondition, AuditOperationPut)
+	if err != nil {
+		if condition == nil {
+			return table.versionConflict(err, version)
+		}
+		return err
+	}
+
+	table.updateItemVersion(item, version+1)
+	return nil
+}
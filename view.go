@@ -0,0 +1,135 @@
+package dynamodbfriend
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams/dynamodbstreamsiface"
+)
+
+// ViewProjection maps an item of a source table to the view items derived from it. View items
+// should have all attributes tagged with the "dynamodbav" struct tag, as with Put. The projection
+// must be deterministic, since it is also used to find the view items of an item's previous image
+// that should be removed.
+type ViewProjection func(ctx context.Context,
+	item map[string]*dynamodb.AttributeValue) ([]interface{}, error)
+
+// MaintainView keeps a materialized view up to date with this table by consuming the table's
+// stream. For each write to this table, the view items projected from the item's new image are put
+// into the view table, and view items projected only from the item's previous image are deleted.
+// The stream must include both new and old images. Progress is saved in the checkpoint store, and
+// since all view writes are idempotent, records replayed after a restart leave the view unchanged.
+// MaintainView blocks until the context is cancelled or maintaining the view fails. The view table
+// may not have a version attribute, since view items are overwritten by each write to this table.
+func (table *Table) MaintainView(ctx context.Context,
+	streams dynamodbstreamsiface.DynamoDBStreamsAPI, view *Table,
+	checkpoints CheckpointStore, projection ViewProjection) error {
+
+	if err := view.requireUnversioned("MaintainView"); err != nil {
+		return err
+	}
+	if err := view.loadIndexMetadata(ctx); err != nil {
+		return err
+	}
+
+	return table.ConsumeStreamWithCheckpoints(ctx, streams, checkpoints,
+		func(ctx context.Context, record *dynamodbstreams.Record) error {
+			if record.Dynamodb == nil {
+				return nil
+			}
+
+			oldViewItems, err := table.projectStoredItem(ctx, record.Dynamodb.OldImage, projection)
+			if err != nil {
+				return err
+			}
+			newViewItems, err := table.projectStoredItem(ctx, record.Dynamodb.NewImage, projection)
+			if err != nil {
+				return err
+			}
+
+			return view.applyViewChange(ctx, oldViewItems, newViewItems)
+		})
+}
+
+// projectStoredItem projects an item image as stored in the table. No view items are projected
+// from an empty image.
+func (table *Table) projectStoredItem(ctx context.Context,
+	storedItem map[string]*dynamodb.AttributeValue,
+	projection ViewProjection) ([]interface{}, error) {
+
+	if len(storedItem) == 0 {
+		return nil, nil
+	}
+
+	item, err := table.itemFromStore(storedItem)
+	if err != nil {
+		return nil, err
+	}
+
+	viewItems, err := projection(ctx, item)
+	if err != nil {
+		table.logger.Printf("error: %s\n", err.Error())
+		return nil, err
+	}
+	return viewItems, nil
+}
+
+// applyViewChange puts all new view items and deletes any old view items not replaced by a new
+// view item. Index metadata must already be loaded.
+func (table *Table) applyViewChange(ctx context.Context,
+	oldViewItems, newViewItems []interface{}) error {
+
+	newKeys := newNameSet()
+	for _, viewItem := range newViewItems {
+		attrMap, err := table.encodeItem(viewItem)
+		if err != nil {
+			table.logger.Printf("error: %s\n", err.Error())
+			return err
+		}
+
+		key, err := table.storedKeyOf(ctx, attrMap)
+		if err != nil {
+			return err
+		}
+		newKeys.Insert(itemCacheKey(table.Name, key))
+
+		if err := table.putItem(ctx, attrMap, nil, AuditOperationPut); err != nil {
+			return err
+		}
+	}
+
+	for _, viewItem := range oldViewItems {
+		attrMap, err := table.encodeItem(viewItem)
+		if err != nil {
+			table.logger.Printf("error: %s\n", err.Error())
+			return err
+		}
+
+		key, err := table.storedKeyOf(ctx, attrMap)
+		if err != nil {
+			return err
+		}
+		if newKeys.Contains(itemCacheKey(table.Name, key)) {
+			continue
+		}
+
+		if err := table.deleteItem(ctx, key, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// storedKeyOf returns the primary key of an item as it would be stored in the table. The item is
+// left unmodified. Index metadata must already be loaded.
+func (table *Table) storedKeyOf(ctx context.Context,
+	item map[string]*dynamodb.AttributeValue) (map[string]*dynamodb.AttributeValue, error) {
+
+	storedItem := copyItem(item)
+	if err := table.prepareItemForWrite(ctx, storedItem); err != nil {
+		return nil, err
+	}
+	return table.primaryKeyOf(storedItem), nil
+}
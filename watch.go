@@ -0,0 +1,226 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams/dynamodbstreamsiface"
+)
+
+// WatchEventType is the kind of change to the result set of a watched query.
+type WatchEventType int
+
+const (
+	// WatchAdded is an item that entered the result set.
+	WatchAdded WatchEventType = iota
+	// WatchRemoved is an item that left the result set.
+	WatchRemoved
+	// WatchChanged is an item of the result set whose attributes changed.
+	WatchChanged
+)
+
+func (t WatchEventType) String() string {
+	switch t {
+	case WatchAdded:
+		return "added"
+	case WatchRemoved:
+		return "removed"
+	case WatchChanged:
+		return "changed"
+	}
+	return fmt.Sprintf("WatchEventType(%d)", int(t))
+}
+
+// WatchEvent is a change to the result set of a watched query. Items are given as attribute maps
+// with all table-level transformations reversed, and may be unmarshaled with
+// dynamodbattribute.UnmarshalMap.
+type WatchEvent struct {
+	Type WatchEventType
+
+	// Item is the item as of the latest execution of the query. It is nil for removed items.
+	Item map[string]*dynamodb.AttributeValue
+
+	// OldItem is the item as of the previous execution of the query. It is nil for added items.
+	OldItem map[string]*dynamodb.AttributeValue
+
+	// Err is set if an execution of the query failed, in which case no other fields are set. The
+	// query is executed again after the next interval.
+	Err error
+}
+
+// Watch executes the query on an interval and sends the changes to its result set on the returned
+// channel, such as to keep a dashboard or cache up to date. Every item of the first result set is
+// sent as added. Items are compared by primary key and by the fingerprints of their attributes.
+// The channel is closed once ctx is canceled. Events must be received promptly, since the query
+// is not executed again until all events of the previous execution have been received.
+func (table *Table) Watch(ctx context.Context, expr *QueryExpr,
+	interval time.Duration) (<-chan WatchEvent, error) {
+
+	return table.watch(ctx, expr, interval, nil)
+}
+
+// WatchWithStream watches the query as with Watch, and also executes the query as soon as any
+// change is read from the table's stream using a DynamoDB Streams client, so that changes are
+// observed without waiting for the interval. If the stream cannot be read, the query is only
+// executed on the interval.
+func (table *Table) WatchWithStream(ctx context.Context, expr *QueryExpr, interval time.Duration,
+	streams dynamodbstreamsiface.DynamoDBStreamsAPI) (<-chan WatchEvent, error) {
+
+	return table.watch(ctx, expr, interval, streams)
+}
+
+func (table *Table) watch(ctx context.Context, expr *QueryExpr, interval time.Duration,
+	streams dynamodbstreamsiface.DynamoDBStreamsAPI) (<-chan WatchEvent, error) {
+
+	if interval <= 0 {
+		err := fmt.Errorf("watch interval must be positive, got %s", interval)
+		table.logger.Printf("error: %s\n", err.Error())
+		return nil, err
+	}
+
+	// execute the first query immediately, so that invalid queries are reported to the caller
+	results, err := table.watchQuery(ctx, expr)
+	if err != nil {
+		return nil, err
+	}
+
+	// changes read from the stream trigger an early execution of the query
+	trigger := make(chan struct{}, 1)
+	if streams != nil {
+		go func() {
+			err := table.ConsumeStream(ctx, streams,
+				func(ctx context.Context, record *dynamodbstreams.Record) error {
+					select {
+					case trigger <- struct{}{}:
+					default:
+					}
+					return nil
+				})
+			if err != nil && ctx.Err() == nil {
+				table.logger.Printf("warning: watch of table \"%s\" stopped reading stream: %s\n",
+					table.Name, err.Error())
+			}
+		}()
+	}
+
+	events := make(chan WatchEvent)
+	go func() {
+		defer close(events)
+
+		send := func(event WatchEvent) bool {
+			select {
+			case events <- event:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		previous := newWatchResults()
+		for {
+			if results != nil {
+				for _, event := range previous.changesTo(results) {
+					if !send(event) {
+						return
+					}
+				}
+				previous = results
+			}
+
+			timer := time.NewTimer(interval)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			case <-trigger:
+				timer.Stop()
+			}
+
+			results, err = table.watchQuery(ctx, expr)
+			if err != nil {
+				if ctx.Err() != nil || !send(WatchEvent{Err: err}) {
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// watchResults is the result set of a single execution of a watched query.
+type watchResults struct {
+	// keys are the item cache keys of items, in the order returned by the query
+	keys  []string
+	items map[string]watchItem
+}
+
+type watchItem struct {
+	item        map[string]*dynamodb.AttributeValue
+	fingerprint string
+}
+
+func newWatchResults() *watchResults {
+	return &watchResults{items: map[string]watchItem{}}
+}
+
+// changesTo returns the events changing these results into the next results. Added and changed
+// items are given in the order of the next results, followed by removed items.
+func (results *watchResults) changesTo(next *watchResults) []WatchEvent {
+	events := []WatchEvent{}
+	for _, key := range next.keys {
+		nextItem := next.items[key]
+		item, found := results.items[key]
+		if !found {
+			events = append(events, WatchEvent{Type: WatchAdded, Item: nextItem.item})
+		} else if item.fingerprint != nextItem.fingerprint {
+			events = append(events, WatchEvent{
+				Type:    WatchChanged,
+				Item:    nextItem.item,
+				OldItem: item.item,
+			})
+		}
+	}
+	for _, key := range results.keys {
+		if _, found := next.items[key]; !found {
+			item := results.items[key]
+			events = append(events, WatchEvent{Type: WatchRemoved, OldItem: item.item})
+		}
+	}
+	return events
+}
+
+// watchQuery executes the query and reads all of its results.
+func (table *Table) watchQuery(ctx context.Context, expr *QueryExpr) (*watchResults, error) {
+	parser, err := table.Query(ctx, expr)
+	if err != nil {
+		return nil, err
+	}
+	defer parser.Close()
+
+	results := newWatchResults()
+	for {
+		storedItem, err := parser.nextStoredItem(ctx)
+		if _, parsingComplete := err.(ErrParsingComplete); parsingComplete {
+			return results, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		item, err := table.itemFromStore(storedItem)
+		if err != nil {
+			return nil, err
+		}
+		table.stripRestrictedAttributes(ctx, item)
+
+		key := itemCacheKey(table.Name, table.primaryKeyOf(storedItem))
+		if _, found := results.items[key]; !found {
+			results.keys = append(results.keys, key)
+		}
+		results.items[key] = watchItem{item: item, fingerprint: canonicalItemHash(item)}
+	}
+}
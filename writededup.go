@@ -0,0 +1,52 @@
+package dynamodbfriend
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// writeDeduplicator remembers recently written item images by key.
+type writeDeduplicator struct {
+	store  *lruStore
+	window time.Duration
+}
+
+// WithWriteDeduplication suppresses puts of items identical to an item put through this table
+// within the window, such as from sync processes that repeatedly save unchanged items. Items are
+// compared by the fingerprints of their marshaled attributes, as with ItemFingerprint. At most
+// capacity recently written items are remembered. Conditional puts are never suppressed.
+func (table *Table) WithWriteDeduplication(window time.Duration, capacity int) *Table {
+	table.writeDedup = &writeDeduplicator{
+		store:  newLRUStore(capacity),
+		window: window,
+	}
+	return table
+}
+
+// isDuplicateWrite returns true if an identical item was recently written with the same key. The
+// returned image hash should be passed to recordWrite once the item is written.
+func (table *Table) isDuplicateWrite(key, item map[string]*dynamodb.AttributeValue) (bool, string) {
+	if table.writeDedup == nil {
+		return false, ""
+	}
+
+	imageHash := canonicalItemHash(item)
+
+	lastHash, found := table.writeDedup.store.Get(itemCacheKey(table.Name, key))
+	return found && lastHash.(string) == imageHash, imageHash
+}
+
+func (table *Table) recordWrite(key map[string]*dynamodb.AttributeValue, imageHash string) {
+	if table.writeDedup == nil || imageHash == "" {
+		return
+	}
+	table.writeDedup.store.Set(itemCacheKey(table.Name, key), imageHash, table.writeDedup.window)
+}
+
+func (table *Table) forgetWrite(key map[string]*dynamodb.AttributeValue) {
+	if table.writeDedup == nil {
+		return
+	}
+	table.writeDedup.store.Delete(itemCacheKey(table.Name, key))
+}
@@ -0,0 +1,38 @@
+package dynamodbfriend
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWriteDeduplicationConcurrentPuts(t *testing.T) {
+	fake := newFakeDynamoDB("items", "id", "")
+	table := newFakeTable(fake).WithWriteDeduplication(time.Minute, 16)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			item := map[string]string{"id": "a", "status": "open"}
+			if err := table.Put(context.Background(), item); err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// puts racing the first write may also be made, but later puts are suppressed
+	if len(fake.putInputs) < 1 {
+		t.Fatalf("expected the item to be put")
+	}
+	puts := len(fake.putInputs)
+	err := table.Put(context.Background(), map[string]string{"id": "a", "status": "open"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if len(fake.putInputs) != puts {
+		t.Errorf("expected put of unchanged item to be suppressed")
+	}
+}